@@ -0,0 +1,182 @@
+package engineio
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ManifestBuilder constructs a Manifest programmatically, allocating the pointers of its elements and
+// tracking their dependencies. It is intended for compilers that target MOI and emit Manifests.
+//
+// Elements are allocated compact pointers (0, 1, 2, ...) in the order in which they are added, so the same
+// sequence of calls always builds the same Manifest. Elements can also be given symbolic names with which they
+// can be depended on, even before they are added. Errors from the builder methods are deferred until Build.
+//
+// A ManifestBuilder is not safe for concurrent use.
+type ManifestBuilder struct {
+	engine   ManifestEngine
+	elements []*builderElement
+	names    map[string]ElementPtr
+
+	err error
+}
+
+// builderElement is an element in a ManifestBuilder along
+// with its dependencies that are referenced by a symbolic name
+type builderElement struct {
+	kind  ElementKind
+	data  ManifestElementObject
+	deps  []ElementPtr
+	named []string
+}
+
+// NewManifestBuilder returns a new ManifestBuilder for a Manifest of the current syntax with the given engine
+func NewManifestBuilder(engine ManifestEngine) *ManifestBuilder {
+	flags := make([]string, 0, len(engine.Flags))
+	flags = append(flags, engine.Flags...)
+
+	return &ManifestBuilder{
+		engine:   ManifestEngine{Kind: engine.Kind, Flags: flags, Version: engine.Version},
+		elements: make([]*builderElement, 0),
+		names:    make(map[string]ElementPtr),
+	}
+}
+
+// AddElement adds an element with the given kind and data to the
+// ManifestBuilder and returns the pointer allocated for the element
+func (builder *ManifestBuilder) AddElement(kind ElementKind, data ManifestElementObject) ElementPtr {
+	ptr := ElementPtr(len(builder.elements))
+
+	if kind == "" {
+		builder.fail(errors.Errorf("element %v has no kind", ptr))
+	}
+
+	if data == nil {
+		builder.fail(errors.Errorf("element %v has no data", ptr))
+	}
+
+	builder.elements = append(builder.elements, &builderElement{kind: kind, data: data})
+
+	return ptr
+}
+
+// AddNamedElement adds an element with the given kind and data to the ManifestBuilder with a symbolic name
+// and returns the pointer allocated for the element. The name must be unique within the ManifestBuilder.
+func (builder *ManifestBuilder) AddNamedElement(name string, kind ElementKind, data ManifestElementObject) ElementPtr {
+	ptr := builder.AddElement(kind, data)
+
+	switch _, exists := builder.names[name]; {
+	case name == "":
+		builder.fail(errors.Errorf("element %v has an empty name", ptr))
+	case exists:
+		builder.fail(errors.Errorf("duplicate element name '%v'", name))
+	default:
+		builder.names[name] = ptr
+	}
+
+	return ptr
+}
+
+// Lookup returns the pointer of the element with the given symbolic name and whether it exists
+func (builder *ManifestBuilder) Lookup(name string) (ElementPtr, bool) {
+	ptr, ok := builder.names[name]
+
+	return ptr, ok
+}
+
+// Names returns the symbolic names of the elements in the ManifestBuilder mapped to their pointers
+func (builder *ManifestBuilder) Names() map[string]ElementPtr {
+	names := make(map[string]ElementPtr, len(builder.names))
+	for name, ptr := range builder.names {
+		names[name] = ptr
+	}
+
+	return names
+}
+
+// DependsOn declares that the element at ptr depends on the elements at the given pointers.
+// Dependencies that are declared more than once are only recorded once.
+func (builder *ManifestBuilder) DependsOn(ptr ElementPtr, deps ...ElementPtr) {
+	element, ok := builder.element(ptr)
+	if !ok {
+		return
+	}
+
+	element.deps = append(element.deps, deps...)
+}
+
+// DependsOnNamed declares that the element at ptr depends on the elements with the given symbolic names.
+// The names are resolved when the Manifest is built, so the named elements can be added afterwards.
+func (builder *ManifestBuilder) DependsOnNamed(ptr ElementPtr, names ...string) {
+	element, ok := builder.element(ptr)
+	if !ok {
+		return
+	}
+
+	element.named = append(element.named, names...)
+}
+
+// Build resolves the symbolic dependencies of the elements and returns the built Manifest.
+// Returns an error if any of the builder methods failed, if a symbolic name is not declared
+// or if the Manifest is structurally invalid (see Manifest.Validate). The dependencies of
+// each element are sorted, and the ManifestBuilder can continue to be used after Build.
+func (builder *ManifestBuilder) Build() (*Manifest, error) {
+	if builder.err != nil {
+		return nil, builder.err
+	}
+
+	manifest := &Manifest{
+		Syntax: CurrentSyntax(),
+		Engine: ManifestEngine{
+			Kind:    builder.engine.Kind,
+			Flags:   append(make([]string, 0, len(builder.engine.Flags)), builder.engine.Flags...),
+			Version: builder.engine.Version,
+		},
+		Elements: make([]ManifestElement, 0, len(builder.elements)),
+	}
+
+	for idx, element := range builder.elements {
+		deps := make([]ElementPtr, 0, len(element.deps)+len(element.named))
+		deps = append(deps, element.deps...)
+
+		for _, name := range element.named {
+			dep, ok := builder.names[name]
+			if !ok {
+				return nil, errors.Errorf("element %v depends on undeclared element name '%v'", idx, name)
+			}
+
+			deps = append(deps, dep)
+		}
+
+		manifest.Elements = append(manifest.Elements, ManifestElement{
+			Ptr:  ElementPtr(idx),
+			Deps: canonicalDeps(deps),
+			Kind: element.kind,
+			Data: element.data,
+		})
+	}
+
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// element returns the element at ptr in the ManifestBuilder.
+// Records an error for Build if no element exists at ptr.
+func (builder *ManifestBuilder) element(ptr ElementPtr) (*builderElement, bool) {
+	if ptr >= ElementPtr(len(builder.elements)) {
+		builder.fail(errors.Errorf("no element at pointer %v", ptr))
+
+		return nil, false
+	}
+
+	return builder.elements[ptr], true
+}
+
+// fail records the first error that occurs in the builder methods, which is returned by Build
+func (builder *ManifestBuilder) fail(err error) {
+	if builder.err == nil {
+		builder.err = err
+	}
+}
@@ -0,0 +1,123 @@
+package engineio
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestBuilder(t *testing.T) {
+	builder := NewManifestBuilder(ManifestEngine{Kind: "mock"})
+
+	state := builder.AddNamedElement("state", "mock", &mockElement{Name: "state"})
+	routine := builder.AddElement("mock", &mockElement{Name: "routine"})
+	builder.DependsOn(routine, state, state)
+	builder.DependsOnNamed(routine, "helper")
+
+	helper := builder.AddNamedElement("helper", "mock", &mockElement{Name: "helper", Value: 1})
+	builder.DependsOnNamed(helper, "state")
+
+	require.Equal(t, []ElementPtr{0, 1, 2}, []ElementPtr{state, routine, helper})
+	require.Equal(t, map[string]ElementPtr{"state": 0, "helper": 2}, builder.Names())
+
+	ptr, ok := builder.Lookup("helper")
+	require.True(t, ok)
+	require.Equal(t, helper, ptr)
+
+	_, ok = builder.Lookup("routine")
+	require.False(t, ok)
+
+	manifest, err := builder.Build()
+	require.NoError(t, err)
+	require.Equal(t, &Manifest{
+		Syntax: CurrentSyntax(),
+		Engine: ManifestEngine{Kind: "mock", Flags: []string{}},
+		Elements: []ManifestElement{
+			{Ptr: 0, Deps: []ElementPtr{}, Kind: "mock", Data: &mockElement{Name: "state"}},
+			{Ptr: 1, Deps: []ElementPtr{0, 2}, Kind: "mock", Data: &mockElement{Name: "routine"}},
+			{Ptr: 2, Deps: []ElementPtr{0}, Kind: "mock", Data: &mockElement{Name: "helper", Value: 1}},
+		},
+	}, manifest)
+
+	// Building again must produce an identical Manifest
+	rebuilt, err := builder.Build()
+	require.NoError(t, err)
+	require.Equal(t, manifest, rebuilt)
+}
+
+func TestManifestBuilder_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(*ManifestBuilder)
+		err   string
+	}{
+		{
+			"missing kind",
+			func(builder *ManifestBuilder) { builder.AddElement("", &mockElement{}) },
+			"element 0 has no kind",
+		},
+		{
+			"missing data",
+			func(builder *ManifestBuilder) { builder.AddElement("mock", nil) },
+			"element 0 has no data",
+		},
+		{
+			"empty name",
+			func(builder *ManifestBuilder) { builder.AddNamedElement("", "mock", &mockElement{}) },
+			"element 0 has an empty name",
+		},
+		{
+			"duplicate name",
+			func(builder *ManifestBuilder) {
+				builder.AddNamedElement("foo", "mock", &mockElement{})
+				builder.AddNamedElement("foo", "mock", &mockElement{})
+			},
+			"duplicate element name 'foo'",
+		},
+		{
+			"unknown element",
+			func(builder *ManifestBuilder) { builder.DependsOn(3, 0) },
+			"no element at pointer 3",
+		},
+		{
+			"undeclared name",
+			func(builder *ManifestBuilder) {
+				builder.DependsOnNamed(builder.AddElement("mock", &mockElement{}), "bar")
+			},
+			"element 0 depends on undeclared element name 'bar'",
+		},
+		{
+			"missing dependency",
+			func(builder *ManifestBuilder) {
+				builder.DependsOn(builder.AddElement("mock", &mockElement{}), 4)
+			},
+			"invalid manifest: dependency on missing element 4 [ptr: 0]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := NewManifestBuilder(ManifestEngine{Kind: "mock"})
+			test.build(builder)
+
+			_, err := builder.Build()
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestManifestBuilder_Cycle(t *testing.T) {
+	builder := NewManifestBuilder(ManifestEngine{Kind: "mock"})
+
+	foo := builder.AddNamedElement("foo", "mock", &mockElement{})
+	bar := builder.AddNamedElement("bar", "mock", &mockElement{})
+	builder.DependsOnNamed(foo, "bar")
+	builder.DependsOn(bar, foo)
+
+	_, err := builder.Build()
+
+	validation := new(ManifestValidationError)
+	require.True(t, errors.As(err, &validation))
+	require.Equal(t, []ManifestIssue{{0, "dependency cycle: 0 -> 1 -> 0"}}, validation.Issues)
+}
@@ -0,0 +1,99 @@
+package engineio
+
+import (
+	"sort"
+	"strings"
+)
+
+// Canonical returns the canonical form of the Manifest, in which two Manifests that describe the same
+// logic are identical regardless of how they were authored or the encoding they were decoded from.
+//
+// In the canonical form, the engine kind is normalized to uppercase, the engine flags are trimmed, sorted
+// and deduplicated (with empty flags dropped), the elements are sorted by their pointer and the dependencies
+// of each element are sorted and deduplicated. Empty flags and dependencies are always non-nil slices.
+// The Manifest is not modified, but the element data objects are shared with the canonical form.
+func (manifest Manifest) Canonical() Manifest {
+	canonical := Manifest{
+		Syntax: manifest.Syntax,
+		Engine: ManifestEngine{
+			Kind:    string(manifest.Header().LogicEngine()),
+			Flags:   canonicalFlags(manifest.Engine.Flags),
+			Version: strings.TrimSpace(manifest.Engine.Version),
+		},
+		Elements: make([]ManifestElement, 0, len(manifest.Elements)),
+	}
+
+	for _, element := range manifest.Elements {
+		canonical.Elements = append(canonical.Elements, ManifestElement{
+			Ptr:  element.Ptr,
+			Deps: canonicalDeps(element.Deps),
+			Kind: element.Kind,
+			Data: element.Data,
+		})
+	}
+
+	sort.SliceStable(canonical.Elements, func(i, j int) bool {
+		return canonical.Elements[i].Ptr < canonical.Elements[j].Ptr
+	})
+
+	return canonical
+}
+
+// CanonicalHash returns the 256-bit hash of the canonical form of the Manifest (see Manifest.Canonical).
+// The hash is derived by applying the Blake2b hashing function on the POLO encoded bytes of the canonical
+// form, and is identical for a Manifest decoded from any encoding or authored in any element order.
+//
+// Unlike Hash, which commits to the exact form of the Manifest, CanonicalHash
+// is suitable for determining whether two Manifests describe the same logic.
+func (manifest Manifest) CanonicalHash() (Hash, error) {
+	return manifest.Canonical().Hash()
+}
+
+// CanonicalDigest returns the Digest of the canonical form of the Manifest (see Manifest.Canonical) for the
+// given HashAlgorithm. It is to Manifest.Digest what CanonicalHash is to Manifest.Hash, so the Digest for
+// Blake2b256 has the same hash as CanonicalHash. Returns an error if the algorithm is not supported.
+func (manifest Manifest) CanonicalDigest(algorithm HashAlgorithm) (Digest, error) {
+	return manifest.Canonical().Digest(algorithm)
+}
+
+// canonicalFlags returns the trimmed engine flags in sorted order without duplicates or empty flags
+func canonicalFlags(flags []string) []string {
+	unique := make(map[string]struct{}, len(flags))
+	canonical := make([]string, 0, len(flags))
+
+	for _, flag := range flags {
+		flag = strings.TrimSpace(flag)
+		if flag == "" {
+			continue
+		}
+
+		if _, exists := unique[flag]; exists {
+			continue
+		}
+
+		unique[flag] = struct{}{}
+		canonical = append(canonical, flag)
+	}
+
+	sort.Strings(canonical)
+
+	return canonical
+}
+
+// canonicalDeps returns the element dependencies in sorted order without duplicates
+func canonicalDeps(deps []ElementPtr) []ElementPtr {
+	canonical := make([]ElementPtr, 0, len(deps))
+	canonical = append(canonical, deps...)
+
+	sort.Slice(canonical, func(i, j int) bool { return canonical[i] < canonical[j] })
+
+	deduped := canonical[:0]
+
+	for idx, dep := range canonical {
+		if idx == 0 || dep != canonical[idx-1] {
+			deduped = append(deduped, dep)
+		}
+	}
+
+	return deduped
+}
@@ -0,0 +1,98 @@
+package engineio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest_Canonical(t *testing.T) {
+	manifest := Manifest{
+		Syntax: "0.1.0",
+		Engine: ManifestEngine{Kind: " mock", Flags: []string{"b", " a ", "", "b"}},
+		Elements: []ManifestElement{
+			{Ptr: 2, Deps: []ElementPtr{1, 0, 1}, Kind: "mock", Data: &mockElement{Name: "baz"}},
+			{Ptr: 0, Kind: "mock", Data: &mockElement{Name: "foo"}},
+			{Ptr: 1, Deps: []ElementPtr{0}, Kind: "mock", Data: &mockElement{Name: "bar"}},
+		},
+	}
+
+	canonical := manifest.Canonical()
+	require.Equal(t, Manifest{
+		Syntax: "0.1.0",
+		Engine: ManifestEngine{Kind: "MOCK", Flags: []string{"a", "b"}},
+		Elements: []ManifestElement{
+			{Ptr: 0, Deps: []ElementPtr{}, Kind: "mock", Data: &mockElement{Name: "foo"}},
+			{Ptr: 1, Deps: []ElementPtr{0}, Kind: "mock", Data: &mockElement{Name: "bar"}},
+			{Ptr: 2, Deps: []ElementPtr{0, 1}, Kind: "mock", Data: &mockElement{Name: "baz"}},
+		},
+	}, canonical)
+
+	// the original manifest is not modified
+	require.Equal(t, ElementPtr(2), manifest.Elements[0].Ptr)
+	require.Equal(t, []ElementPtr{1, 0, 1}, manifest.Elements[0].Deps)
+	require.Equal(t, []string{"b", " a ", "", "b"}, manifest.Engine.Flags)
+
+	// the canonical form is idempotent
+	require.Equal(t, canonical, canonical.Canonical())
+}
+
+func TestManifest_CanonicalHash(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+
+	expected, err := manifest.CanonicalHash()
+	require.NoError(t, err)
+
+	// the canonical hash of a canonical manifest is its hash
+	hash, err := manifest.Hash()
+	require.NoError(t, err)
+	require.Equal(t, hash, expected)
+
+	reordered := newMockManifest("mock")
+	reordered.Engine.Flags = nil
+	reordered.Elements[0], reordered.Elements[1] = reordered.Elements[1], reordered.Elements[0]
+	reordered.Elements[0].Deps = []ElementPtr{0, 0}
+	reordered.Elements[1].Deps = nil
+
+	hash, err = reordered.Hash()
+	require.NoError(t, err)
+	require.NotEqual(t, expected, hash)
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		encoded, err := reordered.Encode(encoding)
+		require.NoError(t, err)
+
+		decoded, err := registry.NewManifest(encoded, encoding)
+		require.NoError(t, err)
+
+		hash, err = decoded.CanonicalHash()
+		require.NoError(t, err)
+		require.Equal(t, expected, hash, "encoding: %v", encoding)
+	}
+
+	for _, algorithm := range []HashAlgorithm{Blake2b256, SHA256, Keccak256} {
+		digest, err := reordered.CanonicalDigest(algorithm)
+		require.NoError(t, err)
+
+		canonical, err := manifest.Canonical().Digest(algorithm)
+		require.NoError(t, err)
+		require.Equal(t, canonical, digest)
+	}
+
+	digest, err := reordered.CanonicalDigest(Blake2b256)
+	require.NoError(t, err)
+	require.Equal(t, expected, digest.Hash)
+
+	_, err = reordered.CanonicalDigest(HashAlgorithm(0x99))
+	require.EqualError(t, err, "unsupported hash algorithm '153'")
+
+	changed := newMockManifest("MOCK")
+	changed.Elements[1].Deps = []ElementPtr{}
+
+	hash, err = changed.CanonicalHash()
+	require.NoError(t, err)
+	require.NotEqual(t, expected, hash)
+}
@@ -0,0 +1,222 @@
+package engineio
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RuntimeCapabilities is a descriptor of the features supported by an EngineRuntime.
+// It allows tooling to discover what manifests and calls a runtime can handle without
+// having to perform trial calls on the runtime (like GetElementGenerator).
+type RuntimeCapabilities struct {
+	// Elements is the set of ElementKind values that the runtime can generate
+	Elements []ElementKind `yaml:"elements" json:"elements"`
+	// Callsites is the set of CallsiteKind values that the runtime can execute
+	Callsites []CallsiteKind `yaml:"callsites" json:"callsites"`
+	// Syntaxes is the set of Manifest syntax versions that the runtime can compile
+	Syntaxes []string `yaml:"syntaxes" json:"syntaxes"`
+	// Flags is the set of engine flag names that the runtime accepts in a ManifestEngine
+	Flags []string `yaml:"flags" json:"flags"`
+	// Encodings is the set of Encoding formats that the runtime can decode its objects from
+	Encodings []Encoding `yaml:"encodings" json:"encodings"`
+}
+
+// CapabilityDescriber is an optional interface that can be implemented by an
+// EngineRuntime to describe its capabilities with a RuntimeCapabilities descriptor.
+type CapabilityDescriber interface {
+	Capabilities() RuntimeCapabilities
+}
+
+// CapabilitiesOf returns the RuntimeCapabilities of the given EngineRuntime. If the runtime declares its
+// engine flags (see FlagDeclarer), the Flags of the capabilities are the names of the declared flags.
+// Returns false if the runtime does not implement the CapabilityDescriber interface.
+func CapabilitiesOf(runtime EngineRuntime) (RuntimeCapabilities, bool) {
+	describer, ok := runtime.(CapabilityDescriber)
+	if !ok {
+		return RuntimeCapabilities{}, false
+	}
+
+	capabilities := describer.Capabilities()
+
+	if declared, ok := FlagsOf(runtime); ok {
+		capabilities.Flags = make([]string, 0, len(declared))
+		for _, flag := range declared {
+			capabilities.Flags = append(capabilities.Flags, flag.Name)
+		}
+	}
+
+	return capabilities, true
+}
+
+// SupportsElement returns whether the ElementKind is supported
+func (capabilities RuntimeCapabilities) SupportsElement(kind ElementKind) bool {
+	for _, element := range capabilities.Elements {
+		if element == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SupportsCallsite returns whether the CallsiteKind is supported
+func (capabilities RuntimeCapabilities) SupportsCallsite(kind CallsiteKind) bool {
+	for _, callsite := range capabilities.Callsites {
+		if callsite == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SupportsSyntax returns whether the Manifest syntax version is supported
+func (capabilities RuntimeCapabilities) SupportsSyntax(syntax string) bool {
+	for _, supported := range capabilities.Syntaxes {
+		if supported == syntax {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SupportsFlag returns whether the engine flag is supported. Flags of the form
+// "name=value" are matched against the supported flag names with their name.
+func (capabilities RuntimeCapabilities) SupportsFlag(flag string) bool {
+	name := flag
+	if idx := strings.IndexByte(flag, '='); idx >= 0 {
+		name = flag[:idx]
+	}
+
+	for _, supported := range capabilities.Flags {
+		if supported == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SupportsEncoding returns whether the Encoding is supported
+func (capabilities RuntimeCapabilities) SupportsEncoding(encoding Encoding) bool {
+	for _, supported := range capabilities.Encodings {
+		if supported == encoding {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FetchCapabilities retrieves the RuntimeCapabilities of the latest EngineRuntime for a given EngineKind.
+// Returns false if the runtime is not registered or if it does not describe its capabilities.
+func (registry *Registry) FetchCapabilities(kind EngineKind) (RuntimeCapabilities, bool) {
+	runtime, ok := registry.FetchEngineRuntime(kind)
+	if !ok {
+		return RuntimeCapabilities{}, false
+	}
+
+	return CapabilitiesOf(runtime)
+}
+
+// RuntimesSupporting returns every registered EngineRuntime (across all kinds and versions) that supports the
+// ElementKind, in the order of IterRuntimes. Runtimes that do not describe their capabilities are checked for
+// support by looking up a generator for the element kind with their GetElementGenerator method.
+func (registry *Registry) RuntimesSupporting(kind ElementKind) []EngineRuntime {
+	runtimes := make([]EngineRuntime, 0)
+
+	for object := range registry.IterRuntimes() {
+		if capabilities, ok := CapabilitiesOf(object.Runtime); ok {
+			if capabilities.SupportsElement(kind) {
+				runtimes = append(runtimes, object.Runtime)
+			}
+
+			continue
+		}
+
+		if _, ok := object.Runtime.GetElementGenerator(kind); ok {
+			runtimes = append(runtimes, object.Runtime)
+		}
+	}
+
+	return runtimes
+}
+
+// CheckCapabilities verifies that the Manifest can be handled by the EngineRuntime that it resolves to in the
+// Registry. If the runtime declares its engine flags (see FlagDeclarer), the engine flags of the Manifest are
+// parsed with them. If the runtime describes its capabilities, the syntax, element kinds and (undeclared) engine
+// flags of the Manifest are checked against them. Otherwise, only the element kinds are checked with the
+// runtime's GetElementGenerator.
+func (registry *Registry) CheckCapabilities(manifest *Manifest) error {
+	runtime, err := registry.ResolveEngineRuntime(manifest.Header().LogicEngine(), manifest.Engine.Version)
+	if err != nil {
+		return err
+	}
+
+	declared, declares := FlagsOf(runtime)
+	if declares {
+		if _, err = manifest.Engine.ParseFlags(declared); err != nil {
+			return errors.Wrapf(err, "unsupported engine flags for runtime %v@%v", runtime.Kind(), runtime.Version())
+		}
+	}
+
+	capabilities, ok := CapabilitiesOf(runtime)
+	if !ok {
+		for _, element := range manifest.Elements {
+			if _, exists := runtime.GetElementGenerator(element.Kind); !exists {
+				return errors.Errorf("unsupported element kind '%v' [ptr: %v]", element.Kind, element.Ptr)
+			}
+		}
+
+		return nil
+	}
+
+	if !capabilities.SupportsSyntax(manifest.Syntax) {
+		return errors.Errorf(
+			"unsupported manifest syntax '%v' for runtime %v@%v",
+			manifest.Syntax, runtime.Kind(), runtime.Version(),
+		)
+	}
+
+	// The flags of runtimes that declare them have already been parsed with the declarations
+	for _, flag := range manifest.Engine.Flags {
+		if declares {
+			break
+		}
+
+		if !capabilities.SupportsFlag(flag) {
+			return errors.Errorf(
+				"unsupported engine flag '%v' for runtime %v@%v",
+				flag, runtime.Kind(), runtime.Version(),
+			)
+		}
+	}
+
+	for _, element := range manifest.Elements {
+		if !capabilities.SupportsElement(element.Kind) {
+			return errors.Errorf("unsupported element kind '%v' [ptr: %v]", element.Kind, element.Ptr)
+		}
+	}
+
+	return nil
+}
+
+// FetchCapabilities retrieves the RuntimeCapabilities of the latest EngineRuntime for a given EngineKind
+// from the default Registry. Returns false if the runtime is not registered or does not describe them.
+func FetchCapabilities(kind EngineKind) (RuntimeCapabilities, bool) {
+	return defaultRegistry.FetchCapabilities(kind)
+}
+
+// RuntimesSupporting returns every EngineRuntime in the default Registry that supports the ElementKind.
+// See Registry.RuntimesSupporting for details.
+func RuntimesSupporting(kind ElementKind) []EngineRuntime {
+	return defaultRegistry.RuntimesSupporting(kind)
+}
+
+// CheckCapabilities verifies that the Manifest can be handled by the EngineRuntime that it
+// resolves to in the default Registry. See Registry.CheckCapabilities for details.
+func CheckCapabilities(manifest *Manifest) error {
+	return defaultRegistry.CheckCapabilities(manifest)
+}
@@ -0,0 +1,171 @@
+package engineio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockDescribedRuntime is a mockEngineRuntime that describes its capabilities
+type mockDescribedRuntime struct {
+	*mockEngineRuntime
+	capabilities RuntimeCapabilities
+}
+
+func (m *mockDescribedRuntime) Capabilities() RuntimeCapabilities {
+	return m.capabilities
+}
+
+// mockDeclaringRuntime is a mockDescribedRuntime that also declares its engine flags
+type mockDeclaringRuntime struct {
+	*mockDescribedRuntime
+}
+
+func (m mockDeclaringRuntime) DeclaredFlags() []EngineFlag {
+	return []EngineFlag{{Name: "debug", Type: BoolFlag}, {Name: "fuel", Type: UintFlag}}
+}
+
+func newMockDescribedRuntime(kind EngineKind, version string) *mockDescribedRuntime {
+	return &mockDescribedRuntime{
+		mockEngineRuntime: newMockRuntime(kind, version),
+		capabilities: RuntimeCapabilities{
+			Elements:  []ElementKind{"mock"},
+			Callsites: []CallsiteKind{InvokableCallsite, DeployerCallsite},
+			Syntaxes:  []string{"0.1.0"},
+			Flags:     []string{"debug", "fuel"},
+			Encodings: []Encoding{POLO, JSON},
+		},
+	}
+}
+
+func TestRuntimeCapabilities(t *testing.T) {
+	capabilities := newMockDescribedRuntime("DESCRIBED", "0.1.0").Capabilities()
+
+	require.True(t, capabilities.SupportsElement("mock"))
+	require.False(t, capabilities.SupportsElement("routine"))
+
+	require.True(t, capabilities.SupportsCallsite(DeployerCallsite))
+	require.False(t, capabilities.SupportsCallsite(EnlisterCallsite))
+
+	require.True(t, capabilities.SupportsSyntax("0.1.0"))
+	require.False(t, capabilities.SupportsSyntax("0.2.0"))
+
+	require.True(t, capabilities.SupportsFlag("debug"))
+	require.True(t, capabilities.SupportsFlag("fuel=100"))
+	require.False(t, capabilities.SupportsFlag("fuels=100"))
+
+	require.True(t, capabilities.SupportsEncoding(JSON))
+	require.False(t, capabilities.SupportsEncoding(YAML))
+
+	_, ok := CapabilitiesOf(newMockRuntime("MOCK", "0.1.0"))
+	require.False(t, ok)
+
+	// the flags of runtimes that declare them are the names of the declared flags
+	declaring := mockDeclaringRuntime{newMockDescribedRuntime("DECLARING", "0.1.0")}
+	declaring.capabilities.Flags = []string{"verbose"}
+
+	capabilities, ok = CapabilitiesOf(declaring)
+	require.True(t, ok)
+	require.Equal(t, []string{"debug", "fuel"}, capabilities.Flags)
+	require.False(t, capabilities.SupportsFlag("verbose"))
+}
+
+func TestRegistry_Capabilities(t *testing.T) {
+	registry := NewRegistry()
+
+	described := newMockDescribedRuntime("DESCRIBED", "0.1.0")
+	undescribed := newMockRuntime("UNDESCRIBED", "0.1.0")
+	unrelated := &mockEngineRuntime{kind: "UNRELATED"}
+
+	registry.Register(described, nil)
+	registry.Register(undescribed, nil)
+	registry.Register(unrelated, nil)
+
+	capabilities, ok := registry.FetchCapabilities("DESCRIBED")
+	require.True(t, ok)
+	require.Equal(t, described.Capabilities(), capabilities)
+
+	_, ok = registry.FetchCapabilities("UNDESCRIBED")
+	require.False(t, ok)
+
+	_, ok = registry.FetchCapabilities("MISSING")
+	require.False(t, ok)
+
+	require.Equal(t, []EngineRuntime{described, undescribed}, registry.RuntimesSupporting("mock"))
+	require.Empty(t, registry.RuntimesSupporting("routine"))
+}
+
+func TestRegistry_CheckCapabilities(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockDescribedRuntime("DESCRIBED", "0.1.0"), nil)
+	registry.Register(mockDeclaringRuntime{newMockDescribedRuntime("DECLARING", "0.1.0")}, nil)
+	registry.Register(newMockRuntime("UNDESCRIBED", "0.1.0"), nil)
+
+	tests := []struct {
+		name   string
+		kind   string
+		modify func(*Manifest)
+		err    string
+	}{
+		{"valid", "described", func(*Manifest) {}, ""},
+		{"valid flags", "described", func(m *Manifest) { m.Engine.Flags = []string{"debug", "fuel=10"} }, ""},
+		{"valid declared flags", "declaring", func(m *Manifest) { m.Engine.Flags = []string{"debug", "fuel=10"} }, ""},
+		{
+			"unsupported syntax",
+			"described",
+			func(m *Manifest) { m.Syntax = "0.2.0" },
+			"unsupported manifest syntax '0.2.0' for runtime DESCRIBED@0.1.0",
+		},
+		{
+			"unsupported flag",
+			"described",
+			func(m *Manifest) { m.Engine.Flags = []string{"verbose"} },
+			"unsupported engine flag 'verbose' for runtime DESCRIBED@0.1.0",
+		},
+		{
+			"undeclared flag",
+			"declaring",
+			func(m *Manifest) { m.Engine.Flags = []string{"verbose"} },
+			"unsupported engine flags for runtime DECLARING@0.1.0: unknown engine flag 'verbose'",
+		},
+		{
+			"malformed flag",
+			"declaring",
+			func(m *Manifest) { m.Engine.Flags = []string{"fuel=lots"} },
+			"unsupported engine flags for runtime DECLARING@0.1.0: invalid value 'lots' for engine flag 'fuel' of type uint",
+		},
+		{
+			"unsupported element",
+			"described",
+			func(m *Manifest) { m.Elements[1].Kind = "routine" },
+			"unsupported element kind 'routine' [ptr: 1]",
+		},
+		{
+			"unregistered engine",
+			"described",
+			func(m *Manifest) { m.Engine.Kind = "missing" },
+			"unknown engine 'MISSING'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest := newMockManifest(test.kind)
+			test.modify(&manifest)
+
+			err := registry.CheckCapabilities(&manifest)
+			if test.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.err)
+			}
+		})
+	}
+
+	manifest := newMockManifest("undescribed")
+	manifest.Engine.Flags = []string{"anything"}
+	require.NoError(t, registry.CheckCapabilities(&manifest))
+
+	manifest.Elements[0].Kind = "routine"
+	require.EqualError(t, registry.CheckCapabilities(&manifest), "unsupported element kind 'routine' [ptr: 0]")
+}
@@ -0,0 +1,482 @@
+// Package conformance provides a test harness that verifies whether an EngineRuntime
+// honours the contracts described by the interfaces of engineio.
+//
+// The harness is driven by fixtures supplied by the runtime author: manifests that the runtime
+// must be able to compile and calls that must be executable against the compiled logic. Every
+// contract violation that is observed while exercising the runtime is reported, instead of
+// stopping at the first failure, so that a runtime can be brought into conformance in one pass.
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, myruntime.New(), conformance.Fixtures{
+//			Manifests: []conformance.ManifestFixture{...},
+//		})
+//	}
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/sarvalabs/go-moi-identifiers"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+	"github.com/sarvalabs/go-moi-engineio/engineiotest"
+)
+
+// DefaultFuel is the amount of fuel used for compiling manifests
+// and spawning engines when a fixture does not specify one
+const DefaultFuel engineio.EngineFuel = 100_000
+
+// Fixtures is the set of inputs that an EngineRuntime is exercised with
+type Fixtures struct {
+	// Manifests are the manifests that are compiled and called on the runtime
+	Manifests []ManifestFixture
+	// Env is the EnvDriver used for spawning engines.
+	// Defaults to an engineiotest.EnvDriver with a zero timestamp.
+	Env engineio.EnvDriver
+}
+
+// ManifestFixture is a manifest for the runtime and the calls to perform on the logic compiled from it
+type ManifestFixture struct {
+	// Name identifies the fixture in the test output and in violations
+	Name string
+	// Manifest is the encoded manifest
+	Manifest []byte
+	// Encoding is the encoding of the manifest
+	Encoding engineio.Encoding
+	// Fuel is the fuel available for compiling the manifest and for each spawned engine.
+	// Defaults to DefaultFuel if zero.
+	Fuel engineio.EngineFuel
+	// Invalid indicates that the manifest is expected to be rejected by CompileManifest
+	Invalid bool
+	// Calls are the calls performed (in order) on a single engine spawned for the compiled logic.
+	// The storage of the logic's context is retained between calls, allowing stateful sequences.
+	Calls []CallFixture
+}
+
+// CallFixture is a call to perform on an Engine
+type CallFixture struct {
+	// Name identifies the call in the test output and in violations
+	Name string
+	// Callsite is the name of the callsite to call
+	Callsite string
+	// Calldata is the encoded input for the call. It is ignored if Inputs is set.
+	Calldata []byte
+	// Inputs are encoded into the calldata with the CallEncoder of the runtime for the callsite
+	Inputs map[string]any
+	// Invalid indicates that the calldata is expected to be rejected by ValidateCalldata.
+	// Invalid calls are only validated and never executed.
+	Invalid bool
+	// Fails indicates that the call is expected to return a failed CallResult
+	Fails bool
+	// Outputs (if not nil) are the expected outputs of the call after
+	// being decoded with the CallEncoder of the runtime for the callsite
+	Outputs map[string]any
+}
+
+// Violation is a contract of engineio that was not honoured by the EngineRuntime
+type Violation struct {
+	// Fixture is the path of the fixture (manifest and call names) where the violation was observed
+	Fixture string
+	// Contract is the method or interface whose contract was violated
+	Contract string
+	// Message describes the violation
+	Message string
+}
+
+// String implements the Stringer interface for Violation
+func (violation Violation) String() string {
+	return fmt.Sprintf("[%v] %v: %v", violation.Fixture, violation.Contract, violation.Message)
+}
+
+// Run exercises the EngineRuntime with the fixtures and reports every contract violation as a test error.
+// The checks for the runtime itself and for each manifest fixture are performed in their own subtest.
+func Run(t *testing.T, runtime engineio.EngineRuntime, fixtures Fixtures) {
+	t.Helper()
+
+	t.Run("runtime", func(t *testing.T) {
+		report(t, checkRuntime(runtime))
+	})
+
+	if t.Failed() {
+		return
+	}
+
+	for _, fixture := range fixtures.Manifests {
+		fixture := fixture
+
+		t.Run(fixture.Name, func(t *testing.T) {
+			report(t, checkManifest(runtime, fixtures.env(), fixture))
+		})
+	}
+}
+
+// Check exercises the EngineRuntime with the fixtures and returns every contract violation.
+// It performs the same checks as Run and is useful outside of tests, such as in tooling.
+func Check(runtime engineio.EngineRuntime, fixtures Fixtures) []Violation {
+	violations := checkRuntime(runtime)
+	if len(violations) > 0 {
+		return violations
+	}
+
+	for _, fixture := range fixtures.Manifests {
+		violations = append(violations, checkManifest(runtime, fixtures.env(), fixture)...)
+	}
+
+	return violations
+}
+
+func report(t *testing.T, violations []Violation) {
+	t.Helper()
+
+	for _, violation := range violations {
+		t.Error(violation.String())
+	}
+}
+
+func (fixtures Fixtures) env() engineio.EnvDriver {
+	if fixtures.Env != nil {
+		return fixtures.Env
+	}
+
+	return engineiotest.NewEnvDriver(0, "")
+}
+
+// checker accumulates violations for a fixture path
+type checker struct {
+	fixture    string
+	violations []Violation
+}
+
+func (c *checker) violate(contract, format string, args ...any) {
+	c.violations = append(c.violations, Violation{
+		Fixture:  c.fixture,
+		Contract: contract,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// checkRuntime checks the identity of the EngineRuntime and that it can be registered
+func checkRuntime(runtime engineio.EngineRuntime) []Violation {
+	c := &checker{fixture: "runtime"}
+
+	if kind, err := engineio.ParseEngineKind(string(runtime.Kind())); err != nil {
+		c.violate("EngineRuntime.Kind", "%v", err)
+	} else if kind != runtime.Kind() {
+		c.violate("EngineRuntime.Kind", "engine kind '%v' is not normalized to '%v'", runtime.Kind(), kind)
+	}
+
+	if err := engineio.NewRegistry().Register(runtime, nil); err != nil {
+		c.violate("EngineRuntime.Version", "runtime cannot be registered: %v", err)
+	}
+
+	return c.violations
+}
+
+// checkManifest compiles the manifest of the fixture and performs its calls
+func checkManifest(runtime engineio.EngineRuntime, env engineio.EnvDriver, fixture ManifestFixture) []Violation {
+	c := &checker{fixture: fixture.Name}
+
+	fuel := fixture.Fuel
+	if fuel == 0 {
+		fuel = DefaultFuel
+	}
+
+	// The runtime can be registered, since the manifests are only checked once checkRuntime passes
+	registry := engineio.NewRegistry()
+	_ = registry.Register(runtime, nil)
+
+	manifest, err := registry.NewManifest(fixture.Manifest, fixture.Encoding)
+	if err != nil {
+		if !fixture.Invalid {
+			c.violate("EngineRuntime.GetElementGenerator", "manifest cannot be decoded: %v", err)
+		}
+
+		return c.violations
+	}
+
+	descriptor, consumed, err := runtime.CompileManifest(fuel, manifest)
+	if consumed > fuel {
+		c.violate("EngineRuntime.CompileManifest", "consumed fuel %v exceeds available fuel %v", consumed, fuel)
+	}
+
+	switch {
+	case err != nil && fixture.Invalid:
+		return c.violations
+	case err != nil:
+		c.violate("EngineRuntime.CompileManifest", "failed to compile manifest: %v", err)
+
+		return c.violations
+	case fixture.Invalid:
+		c.violate("EngineRuntime.CompileManifest", "invalid manifest was compiled without an error")
+
+		return c.violations
+	case descriptor == nil:
+		c.violate("EngineRuntime.CompileManifest", "returned a nil LogicDescriptor without an error")
+
+		return c.violations
+	}
+
+	c.checkDescriptor(runtime, manifest, descriptor)
+
+	address := identifiers.NewAddressFromBytes([]byte(fixture.Name))
+	logic := engineiotest.NewLogic(address, descriptor)
+
+	c.checkValidation(runtime, logic)
+
+	// Spawning an engine with the context of another logic must fail
+	mismatched := engineiotest.NewCtxDriver(address, identifiers.NewLogicIDv0(true, true, true, true, 1, address))
+	if engine, err := runtime.SpawnEngine(fuel, logic, mismatched, env); err == nil || engine != nil {
+		c.violate("EngineRuntime.SpawnEngine", "spawned an engine for a Logic with a mismatched CtxDriver")
+	}
+
+	ctx := engineiotest.NewCtxDriver(address, logic.LogicID())
+
+	engine, err := runtime.SpawnEngine(fuel, logic, ctx, env)
+	if err != nil {
+		c.violate("EngineRuntime.SpawnEngine", "failed to spawn engine: %v", err)
+
+		return c.violations
+	}
+
+	if engine.Kind() != runtime.Kind() {
+		c.violate("Engine.Kind", "engine kind '%v' does not match runtime kind '%v'", engine.Kind(), runtime.Kind())
+	}
+
+	for _, call := range fixture.Calls {
+		calls := &checker{fixture: fixture.Name + "/" + call.Name}
+		calls.checkCall(runtime, logic, engine, fuel, call)
+
+		c.violations = append(c.violations, calls.violations...)
+	}
+
+	return c.violations
+}
+
+// checkDescriptor checks the LogicDescriptor generated by the runtime for the Manifest
+func (c *checker) checkDescriptor(
+	runtime engineio.EngineRuntime,
+	manifest *engineio.Manifest,
+	descriptor *engineio.LogicDescriptor,
+) {
+	if descriptor.Engine != runtime.Kind() {
+		c.violate("LogicDescriptor.Engine", "engine '%v' does not match runtime kind '%v'", descriptor.Engine, runtime.Kind())
+	}
+
+	if hash, err := manifest.Hash(); err == nil && hash != descriptor.ManifestHash {
+		c.violate("LogicDescriptor.ManifestHash", "hash %v does not match manifest hash %v", descriptor.ManifestHash, hash)
+	}
+
+	for name, callsite := range descriptor.Callsites {
+		if _, ok := descriptor.Elements[callsite.Ptr]; !ok {
+			c.violate("LogicDescriptor.Callsites", "callsite '%v' refers to missing element %v", name, callsite.Ptr)
+		}
+	}
+
+	for name, classdef := range descriptor.Classdefs {
+		if _, ok := descriptor.Elements[classdef.Ptr]; !ok {
+			c.violate("LogicDescriptor.Classdefs", "classdef '%v' refers to missing element %v", name, classdef.Ptr)
+		}
+	}
+
+	for kind, ptr := range descriptor.CtxState {
+		if _, ok := descriptor.Elements[ptr]; !ok {
+			c.violate("LogicDescriptor.CtxState", "%v state refers to missing element %v", kind, ptr)
+		}
+	}
+
+	if descriptor.Dependency == nil {
+		c.violate("LogicDescriptor.Dependency", "dependency driver is nil")
+
+		return
+	}
+
+	for _, element := range manifest.Elements {
+		if !descriptor.Dependency.Contains(element.Ptr) {
+			c.violate("DependencyDriver.Contains", "manifest element %v is missing from the dependency driver", element.Ptr)
+		}
+	}
+
+	c.checkDependencyEncoding(runtime, descriptor.Dependency)
+}
+
+// checkDependencyEncoding checks that the DependencyDriver can be round-tripped
+// through its encoded forms with the DecodeDependencyDriver method of the runtime
+func (c *checker) checkDependencyEncoding(runtime engineio.EngineRuntime, dependency engineio.DependencyDriver) {
+	const decodeContract = "EngineRuntime.DecodeDependencyDriver"
+
+	encoders := []struct {
+		encoding engineio.Encoding
+		encode   func() ([]byte, error)
+	}{
+		{engineio.JSON, dependency.MarshalJSON},
+		{engineio.POLO, func() ([]byte, error) {
+			polorizer, err := dependency.Polorize()
+			if err != nil {
+				return nil, err
+			}
+
+			return polorizer.Bytes(), nil
+		}},
+	}
+
+	for _, encoder := range encoders {
+		encoded, err := encoder.encode()
+		if err != nil {
+			c.violate("DependencyDriver", "failed to encode dependency driver (%v): %v", encoder.encoding, err)
+
+			continue
+		}
+
+		decoded, err := runtime.DecodeDependencyDriver(encoded, encoder.encoding)
+		if err != nil {
+			c.violate(decodeContract, "failed to decode dependency driver (%v): %v", encoder.encoding, err)
+
+			continue
+		}
+
+		if decoded.String() != dependency.String() || decoded.Size() != dependency.Size() {
+			c.violate(decodeContract, "decoded dependency driver (%v) does not match the original", encoder.encoding)
+		}
+	}
+}
+
+// checkValidation checks that calldata for undefined callsites is rejected
+func (c *checker) checkValidation(runtime engineio.EngineRuntime, logic engineio.Logic) {
+	const undefined = "conformance:undefined"
+
+	if _, ok := logic.GetCallsite(undefined); ok {
+		return
+	}
+
+	ixn := engineiotest.NewIxnDriver(engineiotest.IxnType{}, undefined, nil)
+	if err := runtime.ValidateCalldata(logic, ixn); err == nil {
+		c.violate("EngineRuntime.ValidateCalldata", "accepted calldata for an undefined callsite")
+	}
+}
+
+// checkCall performs a call on the Engine and checks its CallResult
+func (c *checker) checkCall(
+	runtime engineio.EngineRuntime,
+	logic engineio.Logic,
+	engine engineio.Engine,
+	fuel engineio.EngineFuel,
+	call CallFixture,
+) {
+	callsite, ok := logic.GetCallsite(call.Callsite)
+	if !ok {
+		c.violate("EngineRuntime.CompileManifest", "callsite '%v' is not defined in the compiled logic", call.Callsite)
+
+		return
+	}
+
+	encoder, err := runtime.GetCallEncoder(callsite, logic)
+	if err != nil {
+		c.violate("EngineRuntime.GetCallEncoder", "failed to get call encoder: %v", err)
+
+		return
+	}
+
+	calldata := call.Calldata
+	if call.Inputs != nil {
+		if calldata, err = encoder.EncodeInputs(call.Inputs, nil); err != nil {
+			c.violate("CallEncoder.EncodeInputs", "failed to encode inputs: %v", err)
+
+			return
+		}
+	}
+
+	ixn := engineiotest.NewIxnDriver(engineiotest.IxnType{}, call.Callsite, calldata).WithFuel(big.NewInt(1), fuel)
+
+	err = runtime.ValidateCalldata(logic, ixn)
+
+	switch {
+	case call.Invalid && err == nil:
+		c.violate("EngineRuntime.ValidateCalldata", "invalid calldata was accepted")
+
+		return
+	case call.Invalid:
+		return
+	case err != nil:
+		c.violate("EngineRuntime.ValidateCalldata", "valid calldata was rejected: %v", err)
+
+		return
+	}
+
+	result, err := engine.Call(context.Background(), ixn)
+	if err != nil {
+		c.violate("Engine.Call", "call failed: %v", err)
+
+		return
+	}
+
+	if result == nil {
+		c.violate("Engine.Call", "returned a nil CallResult without an error")
+
+		return
+	}
+
+	if result.Fuel() > fuel {
+		c.violate("CallResult.Fuel", "consumed fuel %v exceeds available fuel %v", result.Fuel(), fuel)
+	}
+
+	if !result.Ok() {
+		c.checkFailedResult(runtime, result, call)
+
+		return
+	}
+
+	if result.Error() != nil {
+		c.violate("CallResult.Error", "error is not nil for a successful result")
+	}
+
+	if call.Fails {
+		c.violate("CallResult.Ok", "call was expected to fail but succeeded")
+	}
+
+	if result.Outputs() == nil {
+		if len(call.Outputs) > 0 {
+			c.violate("CallResult.Outputs", "expected outputs %v but got none", call.Outputs)
+		}
+
+		return
+	}
+
+	outputs, err := encoder.DecodeOutputs(result.Outputs())
+	if err != nil {
+		c.violate("CallEncoder.DecodeOutputs", "failed to decode outputs: %v", err)
+
+		return
+	}
+
+	if call.Outputs != nil && !reflect.DeepEqual(outputs, call.Outputs) {
+		c.violate("CallResult.Outputs", "expected outputs %v but got %v", call.Outputs, outputs)
+	}
+}
+
+// checkFailedResult checks the error of a failed CallResult
+func (c *checker) checkFailedResult(runtime engineio.EngineRuntime, result engineio.CallResult, call CallFixture) {
+	if !call.Fails {
+		c.violate("CallResult.Ok", "call was expected to succeed but failed")
+	}
+
+	if result.Error() == nil {
+		c.violate("CallResult.Error", "error is nil for a failed result")
+
+		return
+	}
+
+	decoded, err := runtime.DecodeErrorResult(result.Error())
+	if err != nil {
+		c.violate("EngineRuntime.DecodeErrorResult", "failed to decode error result: %v", err)
+
+		return
+	}
+
+	if decoded.Engine() != runtime.Kind() {
+		c.violate("ErrorResult.Engine", "engine '%v' does not match runtime kind '%v'", decoded.Engine(), runtime.Kind())
+	}
+}
@@ -0,0 +1,575 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+	"github.com/stretchr/testify/require"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+	"github.com/sarvalabs/go-moi-engineio/engineiotest"
+)
+
+func TestRun(t *testing.T) {
+	Run(t, &counterRuntime{version: "1.0.0"}, newCounterFixtures(t))
+}
+
+func TestCheck(t *testing.T) {
+	require.Empty(t, Check(&counterRuntime{version: "1.0.0"}, newCounterFixtures(t)))
+
+	tests := []struct {
+		name       string
+		runtime    engineio.EngineRuntime
+		violations []string
+	}{
+		{
+			"invalid version",
+			&counterRuntime{version: "latest"},
+			[]string{"[runtime] EngineRuntime.Version"},
+		},
+		{
+			"accepts mismatched ctx",
+			&brokenRuntime{counterRuntime: newCounterRuntime(), acceptMismatch: true},
+			[]string{"[counter] EngineRuntime.SpawnEngine"},
+		},
+		{
+			"overspends fuel",
+			&brokenRuntime{counterRuntime: newCounterRuntime(), overspend: true},
+			[]string{
+				"[counter] EngineRuntime.CompileManifest",
+				"[invalid] EngineRuntime.CompileManifest",
+			},
+		},
+		{
+			"wrong manifest hash",
+			&brokenRuntime{counterRuntime: newCounterRuntime(), wrongHash: true},
+			[]string{"[counter] LogicDescriptor.ManifestHash"},
+		},
+		{
+			"error with ok result",
+			&brokenRuntime{counterRuntime: newCounterRuntime(), errorWithOk: true},
+			[]string{
+				"[counter/increment] CallResult.Error",
+				"[counter/increment by] CallResult.Error",
+				"[counter/fail] CallResult.Error",
+				"[counter/fail] CallResult.Ok",
+			},
+		},
+		{
+			"undecodable error result",
+			&brokenRuntime{counterRuntime: newCounterRuntime(), undecodableError: true},
+			[]string{"[counter/fail] EngineRuntime.DecodeErrorResult"},
+		},
+		{
+			"accepts any calldata",
+			&brokenRuntime{counterRuntime: newCounterRuntime(), acceptCalldata: true},
+			[]string{
+				"[counter] EngineRuntime.ValidateCalldata",
+				"[counter/malformed] EngineRuntime.ValidateCalldata",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			violations := Check(test.runtime, newCounterFixtures(t))
+
+			reported := make([]string, 0, len(violations))
+			for _, violation := range violations {
+				reported = append(reported, fmt.Sprintf("[%v] %v", violation.Fixture, violation.Contract))
+			}
+
+			require.Equal(t, test.violations, reported, "%v", violations)
+		})
+	}
+}
+
+func TestViolation_String(t *testing.T) {
+	violation := Violation{Fixture: "counter/fail", Contract: "CallResult.Error", Message: "error is nil"}
+	require.Equal(t, "[counter/fail] CallResult.Error: error is nil", violation.String())
+}
+
+func newCounterFixtures(t *testing.T) Fixtures {
+	t.Helper()
+
+	manifest := engineio.Manifest{
+		Syntax: "0.1.0",
+		Engine: engineio.ManifestEngine{Kind: "COUNTER", Flags: []string{}},
+		Elements: []engineio.ManifestElement{
+			{Ptr: 0, Deps: []engineio.ElementPtr{}, Kind: "routine", Data: &routine{Name: "Increment"}},
+			{Ptr: 1, Deps: []engineio.ElementPtr{0}, Kind: "routine", Data: &routine{Name: "Fail", Fails: true}},
+		},
+	}
+
+	encoded, err := manifest.Encode(engineio.JSON)
+	require.NoError(t, err)
+
+	empty, err := engineio.Manifest{
+		Syntax:   "0.1.0",
+		Engine:   engineio.ManifestEngine{Kind: "COUNTER", Flags: []string{}},
+		Elements: []engineio.ManifestElement{},
+	}.Encode(engineio.POLO)
+	require.NoError(t, err)
+
+	return Fixtures{
+		Manifests: []ManifestFixture{
+			{
+				Name:     "counter",
+				Manifest: encoded,
+				Encoding: engineio.JSON,
+				Calls: []CallFixture{
+					{Name: "increment", Callsite: "Increment", Outputs: map[string]any{"count": uint64(1)}},
+					{
+						Name:     "increment by",
+						Callsite: "Increment",
+						Inputs:   map[string]any{"by": uint64(5)},
+						Outputs:  map[string]any{"count": uint64(6)},
+					},
+					{Name: "malformed", Callsite: "Increment", Calldata: []byte{0xff}, Invalid: true},
+					{Name: "fail", Callsite: "Fail", Fails: true},
+				},
+			},
+			{
+				Name:     "invalid",
+				Manifest: empty,
+				Encoding: engineio.POLO,
+				Fuel:     1,
+				Invalid:  true,
+			},
+		},
+	}
+}
+
+// routine is the element of the counter runtime
+type routine struct {
+	Name  string `json:"name"`
+	Fails bool   `json:"fails"`
+}
+
+func (element routine) Polorize() (*polo.Polorizer, error) {
+	polorizer := polo.NewPolorizer()
+	polorizer.PolorizeString(element.Name)
+	polorizer.PolorizeBool(element.Fails)
+
+	return polorizer, nil
+}
+
+func (element *routine) Depolorize(depolorizer *polo.Depolorizer) (err error) {
+	if depolorizer, err = depolorizer.DepolorizePacked(); err != nil {
+		return err
+	}
+
+	if element.Name, err = depolorizer.DepolorizeString(); err != nil {
+		return err
+	}
+
+	element.Fails, err = depolorizer.DepolorizeBool()
+
+	return err
+}
+
+// counterRuntime is a conforming runtime with routines that increment a counter in storage
+type counterRuntime struct {
+	version string
+}
+
+func newCounterRuntime() *counterRuntime {
+	return &counterRuntime{version: "1.0.0"}
+}
+
+func (runtime *counterRuntime) Kind() engineio.EngineKind { return "COUNTER" }
+func (runtime *counterRuntime) Version() string           { return runtime.version }
+
+func (runtime *counterRuntime) SpawnEngine(
+	fuel engineio.EngineFuel,
+	logic engineio.Logic,
+	ctx engineio.CtxDriver,
+	_ engineio.EnvDriver,
+) (engineio.Engine, error) {
+	if ctx.LogicID() != logic.LogicID() {
+		return nil, errors.New("logic and ctx driver mismatch")
+	}
+
+	return &counterEngine{logic: logic, ctx: ctx}, nil
+}
+
+func (runtime *counterRuntime) CompileManifest(
+	fuel engineio.EngineFuel,
+	manifest *engineio.Manifest,
+) (*engineio.LogicDescriptor, engineio.EngineFuel, error) {
+	if len(manifest.Elements) == 0 {
+		return nil, 1, errors.New("manifest has no elements")
+	}
+
+	hash, err := manifest.Hash()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	descriptor := &engineio.LogicDescriptor{
+		Engine:       runtime.Kind(),
+		ManifestHash: hash,
+		Dependency:   graph{},
+		Elements:     make(engineio.LogicElementTable),
+		CtxState:     engineio.ContextStateMatrix{},
+		Callsites:    make(map[string]*engineio.Callsite),
+		Classdefs:    make(map[string]*engineio.Classdef),
+	}
+
+	for _, element := range manifest.Elements {
+		data, err := polo.Polorize(element.Data)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		descriptor.Dependency.Insert(element.Ptr, element.Deps...)
+		descriptor.Elements[element.Ptr] = &engineio.LogicElement{Kind: element.Kind, Deps: element.Deps, Data: data}
+		descriptor.Callsites[element.Data.(*routine).Name] = &engineio.Callsite{ //nolint:forcetypeassert
+			Ptr:  element.Ptr,
+			Kind: engineio.InvokableCallsite,
+		}
+	}
+
+	return descriptor, engineio.EngineFuel(len(manifest.Elements)), nil
+}
+
+func (runtime *counterRuntime) ValidateCalldata(logic engineio.Logic, ixn engineio.IxnDriver) error {
+	if _, ok := logic.GetCallsite(ixn.Callsite()); !ok {
+		return errors.Errorf("callsite '%v' not found", ixn.Callsite())
+	}
+
+	if _, err := decodeIncrement(ixn.Calldata()); err != nil {
+		return errors.Wrap(err, "malformed calldata")
+	}
+
+	return nil
+}
+
+func (runtime *counterRuntime) GetElementGenerator(
+	kind engineio.ElementKind,
+) (engineio.ManifestElementGenerator, bool) {
+	if kind != "routine" {
+		return nil, false
+	}
+
+	return func() engineio.ManifestElementObject { return new(routine) }, true
+}
+
+func (runtime *counterRuntime) GetCallEncoder(*engineio.Callsite, engineio.Logic) (engineio.CallEncoder, error) {
+	return counterEncoder{}, nil
+}
+
+func (runtime *counterRuntime) DecodeDependencyDriver(
+	data []byte,
+	encoding engineio.Encoding,
+) (engineio.DependencyDriver, error) {
+	decoded := graph{}
+
+	switch encoding {
+	case engineio.JSON:
+		return decoded, decoded.UnmarshalJSON(data)
+	case engineio.POLO:
+		depolorizer, err := polo.NewDepolorizer(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return decoded, decoded.Depolorize(depolorizer)
+	default:
+		return nil, errors.New("unsupported encoding")
+	}
+}
+
+func (runtime *counterRuntime) DecodeErrorResult(data []byte) (engineio.ErrorResult, error) {
+	return engineiotest.DecodeErrorResult(data)
+}
+
+type counterEngine struct {
+	logic engineio.Logic
+	ctx   engineio.CtxDriver
+}
+
+func (engine *counterEngine) Kind() engineio.EngineKind { return "COUNTER" }
+
+func (engine *counterEngine) Call(
+	_ context.Context,
+	ixn engineio.IxnDriver,
+	_ ...engineio.CtxDriver,
+) (engineio.CallResult, error) {
+	callsite, _ := engine.logic.GetCallsite(ixn.Callsite())
+	element, _ := engine.logic.GetElement(callsite.Ptr)
+
+	decoded := new(routine)
+	if err := polo.Depolorize(decoded, element.Data); err != nil {
+		return nil, err
+	}
+
+	if decoded.Fails {
+		return engineiotest.NewErrorCallResult(5, engineiotest.NewErrorResult("COUNTER", "routine failed", true)), nil
+	}
+
+	by, err := decodeIncrement(ixn.Calldata())
+	if err != nil {
+		return nil, err
+	}
+
+	count := uint64(0)
+	if raw, ok := engine.ctx.GetStorageEntry([]byte("count")); ok {
+		if err = polo.Depolorize(&count, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	count += by
+
+	raw, _ := polo.Polorize(count)
+	engine.ctx.SetStorageEntry([]byte("count"), raw)
+
+	outputs, err := engineio.EncodeValues(map[string]any{"count": count}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return engineiotest.NewCallResult(10, outputs), nil
+}
+
+// decodeIncrement decodes the amount to increment by from the calldata, defaulting to 1
+func decodeIncrement(calldata []byte) (uint64, error) {
+	if len(calldata) == 0 {
+		return 1, nil
+	}
+
+	document := make(polo.Document)
+	if err := polo.Depolorize(&document, calldata); err != nil {
+		return 0, err
+	}
+
+	by := uint64(1)
+	if raw := document.GetRaw("by"); raw != nil {
+		if err := polo.Depolorize(&by, raw); err != nil {
+			return 0, err
+		}
+	}
+
+	return by, nil
+}
+
+type counterEncoder struct{}
+
+func (counterEncoder) EncodeInputs(inputs map[string]any, refs engineio.ReferenceProvider) ([]byte, error) {
+	return engineio.EncodeValues(inputs, refs)
+}
+
+func (counterEncoder) DecodeOutputs(data []byte) (map[string]any, error) {
+	document := make(polo.Document)
+	if err := polo.Depolorize(&document, data); err != nil {
+		return nil, err
+	}
+
+	var count uint64
+	if err := document.Get("count", &count); err != nil {
+		return nil, err
+	}
+
+	return map[string]any{"count": count}, nil
+}
+
+// brokenRuntime is a counterRuntime that violates some specific contracts
+type brokenRuntime struct {
+	*counterRuntime
+
+	acceptMismatch   bool
+	acceptCalldata   bool
+	overspend        bool
+	wrongHash        bool
+	errorWithOk      bool
+	undecodableError bool
+}
+
+func (runtime *brokenRuntime) SpawnEngine(
+	fuel engineio.EngineFuel,
+	logic engineio.Logic,
+	ctx engineio.CtxDriver,
+	env engineio.EnvDriver,
+) (engineio.Engine, error) {
+	if runtime.acceptMismatch {
+		return &counterEngine{logic: logic, ctx: engineiotest.NewCtxDriver(ctx.Address(), logic.LogicID())}, nil
+	}
+
+	engine, err := runtime.counterRuntime.SpawnEngine(fuel, logic, ctx, env)
+	if err != nil || !runtime.errorWithOk {
+		return engine, err
+	}
+
+	return &brokenEngine{engine}, nil
+}
+
+func (runtime *brokenRuntime) CompileManifest(
+	fuel engineio.EngineFuel,
+	manifest *engineio.Manifest,
+) (*engineio.LogicDescriptor, engineio.EngineFuel, error) {
+	descriptor, consumed, err := runtime.counterRuntime.CompileManifest(fuel, manifest)
+	if runtime.overspend {
+		consumed = fuel + 1
+	}
+
+	if runtime.wrongHash && descriptor != nil {
+		descriptor.ManifestHash = engineio.Hash{}
+	}
+
+	return descriptor, consumed, err
+}
+
+func (runtime *brokenRuntime) ValidateCalldata(logic engineio.Logic, ixn engineio.IxnDriver) error {
+	if runtime.acceptCalldata {
+		return nil
+	}
+
+	return runtime.counterRuntime.ValidateCalldata(logic, ixn)
+}
+
+func (runtime *brokenRuntime) DecodeErrorResult(data []byte) (engineio.ErrorResult, error) {
+	if runtime.undecodableError {
+		return nil, errors.New("cannot decode")
+	}
+
+	return runtime.counterRuntime.DecodeErrorResult(data)
+}
+
+// brokenEngine returns results that are ok but also have an error
+type brokenEngine struct {
+	engineio.Engine
+}
+
+func (engine *brokenEngine) Call(
+	ctx context.Context,
+	ixn engineio.IxnDriver,
+	participants ...engineio.CtxDriver,
+) (engineio.CallResult, error) {
+	result, err := engine.Engine.Call(ctx, ixn, participants...)
+	if err != nil {
+		return nil, err
+	}
+
+	return brokenResult{result}, nil
+}
+
+type brokenResult struct {
+	engineio.CallResult
+}
+
+func (brokenResult) Ok() bool { return true }
+
+func (result brokenResult) Error() []byte {
+	if err := result.CallResult.Error(); err != nil {
+		return err
+	}
+
+	return []byte{0}
+}
+
+// graph is a DependencyDriver backed by a map of adjacency lists
+type graph map[engineio.ElementPtr][]engineio.ElementPtr
+
+func (g graph) String() string {
+	return fmt.Sprintf("%v", map[engineio.ElementPtr][]engineio.ElementPtr(g))
+}
+
+func (g graph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[engineio.ElementPtr][]engineio.ElementPtr(g))
+}
+
+func (g graph) UnmarshalJSON(data []byte) error {
+	decoded := make(map[engineio.ElementPtr][]engineio.ElementPtr)
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	for ptr, deps := range decoded {
+		g[ptr] = deps
+	}
+
+	return nil
+}
+
+func (g graph) Polorize() (*polo.Polorizer, error) {
+	polorizer := polo.NewPolorizer()
+	if err := polorizer.Polorize(map[engineio.ElementPtr][]engineio.ElementPtr(g)); err != nil {
+		return nil, err
+	}
+
+	return polorizer, nil
+}
+
+func (g graph) Depolorize(depolorizer *polo.Depolorizer) error {
+	decoded := make(map[engineio.ElementPtr][]engineio.ElementPtr)
+	if err := depolorizer.Depolorize(&decoded); err != nil {
+		return err
+	}
+
+	for ptr, deps := range decoded {
+		g[ptr] = deps
+	}
+
+	return nil
+}
+
+func (g graph) Insert(ptr engineio.ElementPtr, deps ...engineio.ElementPtr) {
+	g[ptr] = append([]engineio.ElementPtr{}, deps...)
+}
+
+func (g graph) Remove(ptr engineio.ElementPtr) { delete(g, ptr) }
+func (g graph) Size() uint64                   { return uint64(len(g)) }
+
+func (g graph) Iter() <-chan engineio.ElementPtr {
+	iter := make(chan engineio.ElementPtr, len(g))
+	for _, ptr := range g.sorted() {
+		iter <- ptr
+	}
+
+	close(iter)
+
+	return iter
+}
+
+func (g graph) Contains(ptr engineio.ElementPtr) bool {
+	_, ok := g[ptr]
+
+	return ok
+}
+
+func (g graph) Edges(ptr engineio.ElementPtr) []engineio.ElementPtr { return g[ptr] }
+
+func (g graph) Dependencies(ptr engineio.ElementPtr) []engineio.ElementPtr {
+	visited := make(graph)
+
+	var visit func(engineio.ElementPtr)
+	visit = func(ptr engineio.ElementPtr) {
+		for _, dep := range g[ptr] {
+			if !visited.Contains(dep) {
+				visited[dep] = nil
+				visit(dep)
+			}
+		}
+	}
+
+	visit(ptr)
+
+	return visited.sorted()
+}
+
+func (g graph) sorted() []engineio.ElementPtr {
+	ptrs := make([]engineio.ElementPtr, 0, len(g))
+	for ptr := range g {
+		ptrs = append(ptrs, ptr)
+	}
+
+	sort.Slice(ptrs, func(i, j int) bool { return ptrs[i] < ptrs[j] })
+
+	return ptrs
+}
@@ -9,11 +9,6 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-type (
-	// Hash is a 256-bit checksum digest
-	Hash = [32]byte
-)
-
 // CtxDriver represents an interface for accessing and manipulating
 // context information of an account state. It is bounded to the context
 // of particular account and can only mutate within applicable portions
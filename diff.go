@@ -0,0 +1,442 @@
+package engineio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ManifestDiff describes the structural differences between two Manifests. It is generated with DiffManifests.
+// The String method renders the diff in a human-readable form, while the diff can be rendered as JSON with
+// json.Marshal. The elements in each list of the diff are sorted by their pointer.
+type ManifestDiff struct {
+	// Header contains the changes to the syntax and engine of the Manifest
+	Header []HeaderChange `json:"header,omitempty"`
+	// Added contains the elements that only exist in the new Manifest
+	Added []ManifestElement `json:"added,omitempty"`
+	// Removed contains the elements that only exist in the old Manifest
+	Removed []ManifestElement `json:"removed,omitempty"`
+	// Changed contains the changes to the elements that exist in both Manifests
+	Changed []ElementChange `json:"changed,omitempty"`
+}
+
+// HeaderChange describes a change to a field of the ManifestHeader. The field is one of 'syntax',
+// 'engine.kind', 'engine.version' or 'engine.flags' (whose values are the comma separated flags).
+type HeaderChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// ElementChange describes the changes to an element that exists in both Manifests.
+// The kind of the element is only set in FromKind and ToKind if it has changed.
+type ElementChange struct {
+	Ptr  ElementPtr  `json:"ptr"`
+	Kind ElementKind `json:"kind"`
+
+	FromKind ElementKind `json:"from_kind,omitempty"`
+	ToKind   ElementKind `json:"to_kind,omitempty"`
+
+	AddedDeps   []ElementPtr `json:"added_deps,omitempty"`
+	RemovedDeps []ElementPtr `json:"removed_deps,omitempty"`
+
+	Data []DataChange `json:"data,omitempty"`
+}
+
+// DataChange describes a change to a value in the data of an element.
+//
+// The data of the elements is compared in the JSON form of the element types of their runtime.
+// The Path locates the value in the data with a JSONPath expression such as '$.fields[0].name'.
+// Keys that are not identifiers are quoted in brackets, such as '$["a.b"]' for the key "a.b".
+// From is Missing if the value was added and To is Missing if the value was removed, while a
+// null value is nil. In the JSON form of a DataChange, a Missing value is omitted.
+type DataChange struct {
+	Path string `json:"path"`
+	From any    `json:"from"`
+	To   any    `json:"to"`
+}
+
+// Missing is the value of a DataChange for a value that does not exist, such as an object key that was added
+// or removed or the data of an element without data. It distinguishes a missing value from a null value.
+var Missing any = missing{}
+
+// missing is the type of the Missing value
+type missing struct{}
+
+// isMissing returns whether a generic value is Missing
+func isMissing(value any) bool {
+	_, ok := value.(missing)
+
+	return ok
+}
+
+// MarshalJSON implements the json.Marshaler interface for DataChange.
+// The 'from' or 'to' field is omitted if its value is Missing.
+func (change DataChange) MarshalJSON() ([]byte, error) {
+	encoded := struct {
+		Path string `json:"path"`
+		From *any   `json:"from,omitempty"`
+		To   *any   `json:"to,omitempty"`
+	}{Path: change.Path}
+
+	if !isMissing(change.From) {
+		encoded.From = &change.From
+	}
+
+	if !isMissing(change.To) {
+		encoded.To = &change.To
+	}
+
+	return json.Marshal(encoded)
+}
+
+// Empty returns whether there are no differences in the ManifestDiff
+func (diff ManifestDiff) Empty() bool {
+	return len(diff.Header) == 0 && len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+// String implements the Stringer interface for ManifestDiff.
+// It renders the diff in a human-readable form with one change per line,
+// where each line is prefixed with '+' for additions, '-' for removals and '~' for changes.
+func (diff ManifestDiff) String() string {
+	if diff.Empty() {
+		return "no changes"
+	}
+
+	lines := make([]string, 0)
+
+	for _, change := range diff.Header {
+		lines = append(lines, fmt.Sprintf("~ %v: %v -> %v", change.Field, quoteEmpty(change.From), quoteEmpty(change.To)))
+	}
+
+	for _, element := range diff.Added {
+		lines = append(lines, fmt.Sprintf("+ element %v [kind: %v]", element.Ptr, element.Kind))
+	}
+
+	for _, element := range diff.Removed {
+		lines = append(lines, fmt.Sprintf("- element %v [kind: %v]", element.Ptr, element.Kind))
+	}
+
+	for _, change := range diff.Changed {
+		lines = append(lines, fmt.Sprintf("~ element %v [kind: %v]", change.Ptr, change.Kind))
+
+		if change.FromKind != change.ToKind {
+			lines = append(lines, fmt.Sprintf("    ~ kind: %v -> %v", change.FromKind, change.ToKind))
+		}
+
+		for _, dep := range change.AddedDeps {
+			lines = append(lines, fmt.Sprintf("    + dependency on %v", dep))
+		}
+
+		for _, dep := range change.RemovedDeps {
+			lines = append(lines, fmt.Sprintf("    - dependency on %v", dep))
+		}
+
+		for _, data := range change.Data {
+			switch {
+			case isMissing(data.From):
+				lines = append(lines, fmt.Sprintf("    + %v: %v", data.Path, renderValue(data.To)))
+			case isMissing(data.To):
+				lines = append(lines, fmt.Sprintf("    - %v: %v", data.Path, renderValue(data.From)))
+			default:
+				lines = append(lines, fmt.Sprintf("    ~ %v: %v -> %v", data.Path, renderValue(data.From), renderValue(data.To)))
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// DiffManifests returns the structural differences from Manifest a (the old Manifest) to
+// Manifest b (the new Manifest). Elements are matched between the Manifests by their pointer.
+// Returns an error if either Manifest has duplicate element pointers or if the data of an element
+// cannot be encoded to JSON for comparison.
+func DiffManifests(a, b *Manifest) (*ManifestDiff, error) {
+	diff := &ManifestDiff{
+		Header:  diffHeaders(a.Header(), b.Header()),
+		Added:   make([]ManifestElement, 0),
+		Removed: make([]ManifestElement, 0),
+		Changed: make([]ElementChange, 0),
+	}
+
+	older, err := indexElements(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid old manifest")
+	}
+
+	newer, err := indexElements(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid new manifest")
+	}
+
+	for _, element := range a.Elements {
+		if _, exists := newer[element.Ptr]; !exists {
+			diff.Removed = append(diff.Removed, element)
+		}
+	}
+
+	for _, element := range b.Elements {
+		previous, exists := older[element.Ptr]
+		if !exists {
+			diff.Added = append(diff.Added, element)
+
+			continue
+		}
+
+		change, err := diffElements(previous, element)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to compare element %v", element.Ptr)
+		}
+
+		if !change.empty() {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Ptr < diff.Added[j].Ptr })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Ptr < diff.Removed[j].Ptr })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Ptr < diff.Changed[j].Ptr })
+
+	return diff, nil
+}
+
+// diffHeaders returns the changes between two ManifestHeaders.
+// Engine kinds are compared in their normalized form.
+func diffHeaders(a, b ManifestHeader) []HeaderChange {
+	changes := make([]HeaderChange, 0)
+
+	if a.Syntax != b.Syntax {
+		changes = append(changes, HeaderChange{"syntax", a.Syntax, b.Syntax})
+	}
+
+	if a.LogicEngine() != b.LogicEngine() {
+		changes = append(changes, HeaderChange{"engine.kind", string(a.LogicEngine()), string(b.LogicEngine())})
+	}
+
+	if a.Engine.Version != b.Engine.Version {
+		changes = append(changes, HeaderChange{"engine.version", a.Engine.Version, b.Engine.Version})
+	}
+
+	if from, to := strings.Join(a.Engine.Flags, ","), strings.Join(b.Engine.Flags, ","); from != to {
+		changes = append(changes, HeaderChange{"engine.flags", from, to})
+	}
+
+	return changes
+}
+
+// indexElements returns the elements of the Manifest mapped to their pointer.
+// Returns an error if the Manifest has duplicate element pointers.
+func indexElements(manifest *Manifest) (map[ElementPtr]ManifestElement, error) {
+	elements := make(map[ElementPtr]ManifestElement, len(manifest.Elements))
+
+	for _, element := range manifest.Elements {
+		if _, exists := elements[element.Ptr]; exists {
+			return nil, errors.Errorf("duplicate element pointer %v", element.Ptr)
+		}
+
+		elements[element.Ptr] = element
+	}
+
+	return elements, nil
+}
+
+// diffElements returns the changes between two elements with the same pointer
+func diffElements(a, b ManifestElement) (ElementChange, error) {
+	change := ElementChange{Ptr: b.Ptr, Kind: b.Kind}
+
+	if a.Kind != b.Kind {
+		change.FromKind, change.ToKind = a.Kind, b.Kind
+	}
+
+	change.AddedDeps, change.RemovedDeps = diffDeps(a.Deps, b.Deps)
+
+	from, err := genericData(a.Data)
+	if err != nil {
+		return ElementChange{}, err
+	}
+
+	to, err := genericData(b.Data)
+	if err != nil {
+		return ElementChange{}, err
+	}
+
+	change.Data = diffValues("$", from, to, nil)
+
+	return change, nil
+}
+
+// empty returns whether there are no changes in the ElementChange
+func (change ElementChange) empty() bool {
+	return change.FromKind == "" && len(change.AddedDeps) == 0 && len(change.RemovedDeps) == 0 && len(change.Data) == 0
+}
+
+// diffDeps returns the sorted dependencies that were added and removed between two sets of dependencies
+func diffDeps(a, b []ElementPtr) (added, removed []ElementPtr) {
+	older := make(map[ElementPtr]struct{}, len(a))
+	for _, dep := range a {
+		older[dep] = struct{}{}
+	}
+
+	newer := make(map[ElementPtr]struct{}, len(b))
+
+	for _, dep := range b {
+		if _, seen := newer[dep]; seen {
+			continue
+		}
+
+		newer[dep] = struct{}{}
+
+		if _, exists := older[dep]; !exists {
+			added = append(added, dep)
+		}
+	}
+
+	for dep := range older {
+		if _, exists := newer[dep]; !exists {
+			removed = append(removed, dep)
+		}
+	}
+
+	sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+	sort.Slice(removed, func(i, j int) bool { return removed[i] < removed[j] })
+
+	return added, removed
+}
+
+// genericData returns the element data in its generic JSON form (maps, slices, strings, numbers, booleans and
+// nil for null). Numbers are kept as json.Number to preserve their exact representation. Returns Missing if
+// the element has no data.
+func genericData(data ManifestElementObject) (any, error) {
+	if data == nil {
+		return Missing, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	var generic any
+	if err = decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return generic, nil
+}
+
+// diffValues recursively compares two generic JSON values (or Missing) at a path and appends their changes.
+// Objects are compared by key, arrays of equal length are compared by index,
+// and all other values (including arrays of different lengths) are compared as a whole.
+func diffValues(path string, a, b any, changes []DataChange) []DataChange {
+	switch {
+	case isMissing(a) && isMissing(b):
+		return changes
+
+	case isMissing(a) || isMissing(b):
+		return append(changes, DataChange{path, a, b})
+	}
+
+	switch from := a.(type) {
+	case map[string]any:
+		to, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(from)+len(to))
+		for key := range from {
+			keys = append(keys, key)
+		}
+
+		for key := range to {
+			if _, exists := from[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			changes = diffValues(keyPath(path, key), lookupKey(from, key), lookupKey(to, key), changes)
+		}
+
+		return changes
+
+	case []any:
+		to, ok := b.([]any)
+		if !ok || len(from) != len(to) {
+			break
+		}
+
+		for idx := range from {
+			changes = diffValues(fmt.Sprintf("%v[%v]", path, idx), from[idx], to[idx], changes)
+		}
+
+		return changes
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		changes = append(changes, DataChange{path, a, b})
+	}
+
+	return changes
+}
+
+// keyPath returns the JSONPath of a key in the object at a path. The key is appended with the dot notation
+// if it is an identifier and is otherwise quoted in brackets, so that the paths of distinct keys never collide.
+func keyPath(path, key string) string {
+	for idx, char := range key {
+		if char != '_' && !isASCIILetter(char) && (idx == 0 || char < '0' || char > '9') {
+			return fmt.Sprintf("%v[%v]", path, strconv.Quote(key))
+		}
+	}
+
+	if key == "" {
+		return path + `[""]`
+	}
+
+	return path + "." + key
+}
+
+// isASCIILetter returns whether the character is an ASCII letter
+func isASCIILetter(char rune) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+// lookupKey returns the value of a key in a generic JSON object, or Missing if the key does not exist
+func lookupKey(object map[string]any, key string) any {
+	value, exists := object[key]
+	if !exists {
+		return Missing
+	}
+
+	return value
+}
+
+// renderValue renders a generic JSON value in its compact JSON form
+func renderValue(value any) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(encoded)
+}
+
+// quoteEmpty returns the string, or a quoted empty string if it is empty
+func quoteEmpty(str string) string {
+	if str == "" {
+		return `""`
+	}
+
+	return str
+}
@@ -0,0 +1,221 @@
+package engineio
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sarvalabs/go-polo"
+	"github.com/stretchr/testify/require"
+)
+
+// nestedElement is a ManifestElementObject with nested data for testing diffs
+type nestedElement struct {
+	Fields []string          `json:"fields"`
+	Labels map[string]string `json:"labels"`
+}
+
+func (element nestedElement) Polorize() (*polo.Polorizer, error) {
+	return polo.NewPolorizer(), nil
+}
+
+func (element *nestedElement) Depolorize(*polo.Depolorizer) error {
+	return nil
+}
+
+func TestDiffManifests(t *testing.T) {
+	older := newMockManifest("mock")
+	older.Elements = append(older.Elements, ManifestElement{
+		Ptr: 2, Deps: []ElementPtr{1}, Kind: "nested",
+		Data: &nestedElement{Fields: []string{"a", "b"}, Labels: map[string]string{"x": "1", "y": "2"}},
+	})
+
+	newer := Manifest{
+		Syntax: "0.1.0",
+		Engine: ManifestEngine{Kind: "MOCK", Flags: []string{"strict"}, Version: "^1.0.0"},
+		Elements: []ManifestElement{
+			{Ptr: 3, Deps: []ElementPtr{}, Kind: "mock", Data: &mockElement{Name: "qux", Value: 1}},
+			{Ptr: 1, Deps: []ElementPtr{3, 3}, Kind: "mock", Data: &mockElement{Name: "baz", Value: 10}},
+			{
+				Ptr: 2, Deps: []ElementPtr{1}, Kind: "nested",
+				Data: &nestedElement{Fields: []string{"a", "c"}, Labels: map[string]string{"x": "1", "z": "3"}},
+			},
+		},
+	}
+
+	diff, err := DiffManifests(&older, &newer)
+	require.NoError(t, err)
+	require.False(t, diff.Empty())
+
+	require.Equal(t, []HeaderChange{
+		{"engine.version", "", "^1.0.0"},
+		{"engine.flags", "", "strict"},
+	}, diff.Header)
+
+	require.Len(t, diff.Added, 1)
+	require.Equal(t, ElementPtr(3), diff.Added[0].Ptr)
+	require.Len(t, diff.Removed, 1)
+	require.Equal(t, ElementPtr(0), diff.Removed[0].Ptr)
+
+	require.Equal(t, []ElementChange{
+		{
+			Ptr: 1, Kind: "mock",
+			AddedDeps: []ElementPtr{3}, RemovedDeps: []ElementPtr{0},
+			Data: []DataChange{{"$.name", "bar", "baz"}},
+		},
+		{
+			Ptr: 2, Kind: "nested",
+			Data: []DataChange{
+				{"$.fields[1]", "b", "c"},
+				{"$.labels.y", "2", Missing},
+				{"$.labels.z", Missing, "3"},
+			},
+		},
+	}, diff.Changed)
+
+	require.Equal(t, `~ engine.version: "" -> ^1.0.0
+~ engine.flags: "" -> strict
++ element 3 [kind: mock]
+- element 0 [kind: mock]
+~ element 1 [kind: mock]
+    + dependency on 3
+    - dependency on 0
+    ~ $.name: "bar" -> "baz"
+~ element 2 [kind: nested]
+    ~ $.fields[1]: "b" -> "c"
+    - $.labels.y: "2"
+    + $.labels.z: "3"`, diff.String())
+
+	encoded, err := json.Marshal(diff.Changed[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"ptr": 1, "kind": "mock", "added_deps": [3], "removed_deps": [0],
+		"data": [{"path": "$.name", "from": "bar", "to": "baz"}]
+	}`, string(encoded))
+}
+
+func TestDiffManifests_Identical(t *testing.T) {
+	older, newer := newMockManifest("mock"), newMockManifest("MOCK")
+
+	// element order and repeated dependencies are not changes
+	newer.Elements[0], newer.Elements[1] = newer.Elements[1], newer.Elements[0]
+	newer.Elements[0].Deps = []ElementPtr{0, 0}
+
+	diff, err := DiffManifests(&older, &newer)
+	require.NoError(t, err)
+	require.True(t, diff.Empty())
+	require.Equal(t, "no changes", diff.String())
+
+	encoded, err := json.Marshal(diff)
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(encoded))
+}
+
+func TestDiffValues_Null(t *testing.T) {
+	decode := func(data string) any {
+		var generic any
+		require.NoError(t, json.Unmarshal([]byte(data), &generic))
+
+		return generic
+	}
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		changes []DataChange
+	}{
+		{"null to missing", `{"x": null}`, `{}`, []DataChange{{"$.x", nil, Missing}}},
+		{"missing to null", `{}`, `{"x": null}`, []DataChange{{"$.x", Missing, nil}}},
+		{"null to value", `{"x": null}`, `{"x": 1}`, []DataChange{{"$.x", nil, 1.0}}},
+		{"null to null", `{"x": null}`, `{"x": null}`, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.changes, diffValues("$", decode(test.from), decode(test.to), nil))
+		})
+	}
+
+	diff := ManifestDiff{Changed: []ElementChange{{
+		Ptr: 0, Kind: "mock",
+		Data: []DataChange{{"$.a", nil, Missing}, {"$.b", Missing, nil}, {"$.c", nil, "1"}},
+	}}}
+
+	require.Equal(t, "~ element 0 [kind: mock]\n    - $.a: null\n    + $.b: null\n    ~ $.c: null -> \"1\"", diff.String())
+
+	encoded, err := json.Marshal(diff.Changed[0].Data)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"path": "$.a", "from": null},
+		{"path": "$.b", "to": null},
+		{"path": "$.c", "from": null, "to": "1"}
+	]`, string(encoded))
+}
+
+func TestDiffValues_Keys(t *testing.T) {
+	var from, to any
+
+	require.NoError(t, json.Unmarshal([]byte(`{"a": {"b": 1}, "a.b": 1, "": 1, "0x": 1, "x_1": 1}`), &from))
+	require.NoError(t, json.Unmarshal([]byte(`{"a": {"b": 2}, "a.b": 2, "": 2, "0x": 2, "x_1": 2}`), &to))
+
+	// keys that are not identifiers are quoted, so that the paths of "a.b" and a -> b do not collide
+	require.Equal(t, []DataChange{
+		{`$[""]`, 1.0, 2.0},
+		{`$["0x"]`, 1.0, 2.0},
+		{"$.a.b", 1.0, 2.0},
+		{`$["a.b"]`, 1.0, 2.0},
+		{"$.x_1", 1.0, 2.0},
+	}, diffValues("$", from, to, nil))
+}
+
+func TestDiffManifests_Changes(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Manifest)
+		output string
+	}{
+		{
+			"syntax and engine",
+			func(manifest *Manifest) {
+				manifest.Syntax = "0.2.0"
+				manifest.Engine.Kind = "pisa"
+			},
+			"~ syntax: 0.1.0 -> 0.2.0\n~ engine.kind: MOCK -> PISA",
+		},
+		{
+			"element kind",
+			func(manifest *Manifest) {
+				manifest.Elements[0].Kind = "other"
+			},
+			"~ element 0 [kind: other]\n    ~ kind: mock -> other",
+		},
+		{
+			"element data",
+			func(manifest *Manifest) {
+				manifest.Elements[0].Data = nil
+				manifest.Elements[1].Data = &mockElement{Name: "bar", Value: 11}
+			},
+			"~ element 0 [kind: mock]\n    - $: {\"name\":\"foo\",\"value\":5}\n" +
+				"~ element 1 [kind: mock]\n    ~ $.value: 10 -> 11",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			older, newer := newMockManifest("mock"), newMockManifest("mock")
+			test.modify(&newer)
+
+			diff, err := DiffManifests(&older, &newer)
+			require.NoError(t, err)
+			require.Equal(t, test.output, diff.String())
+		})
+	}
+
+	duplicate := newMockManifest("mock")
+	duplicate.Elements[1].Ptr = 0
+
+	older := newMockManifest("mock")
+
+	_, err := DiffManifests(&older, &duplicate)
+	require.EqualError(t, err, "invalid new manifest: duplicate element pointer 0")
+}
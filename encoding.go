@@ -0,0 +1,273 @@
+package engineio
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+	"gopkg.in/yaml.v3"
+)
+
+// Encoding is an enum with variants that describe
+// encoding schemes supported for file objects.
+//
+// POLO, JSON and YAML are always supported, while other formats can be added with RegisterEncoding.
+// An Encoding is identified by its name in its text, JSON and YAML forms (such as "json"), since
+// the values of registered encodings depend on the order in which they were registered.
+type Encoding int
+
+const (
+	POLO Encoding = iota
+	JSON
+	YAML
+)
+
+// EncodingFormat describes an encoding format for Manifests that is registered with RegisterEncoding
+type EncodingFormat struct {
+	// Name is the unique name of the format (such as "toml"). It is case-insensitive and normalized to lowercase.
+	Name string
+	// Extensions are the file extensions of manifest files of the format, including the leading dot (such as
+	// ".toml"). They are used by ReadManifestFile and WriteManifestFile to determine the encoding of a file.
+	Extensions []string
+
+	// Encode encodes a Manifest into the format
+	Encode func(*Manifest) ([]byte, error)
+	// Decode decodes manifest data of the format into a Manifest, decoding its elements with the runtimes in
+	// the given Registry. Formats can decode their data by transcoding it into a built-in encoding and
+	// decoding that with the NewManifest method of the Registry.
+	Decode func([]byte, *Registry) (*Manifest, error)
+}
+
+// encodings is the registry of encoding formats, indexed by their Encoding
+var encodings = struct {
+	mutex   sync.RWMutex
+	formats []EncodingFormat
+}{
+	formats: []EncodingFormat{
+		{
+			Name:       "polo",
+			Extensions: []string{".polo"},
+			Encode:     func(manifest *Manifest) ([]byte, error) { return polo.Polorize(*manifest) },
+			Decode:     builtinDecoder(POLO),
+		},
+		{
+			Name:       "json",
+			Extensions: []string{".json"},
+			Encode:     func(manifest *Manifest) ([]byte, error) { return json.Marshal(*manifest) },
+			Decode:     builtinDecoder(JSON),
+		},
+		{
+			Name:       "yaml",
+			Extensions: []string{".yaml", ".yml"},
+			Encode:     func(manifest *Manifest) ([]byte, error) { return yaml.Marshal(*manifest) },
+			Decode:     builtinDecoder(YAML),
+		},
+	},
+}
+
+// RegisterEncoding registers an encoding format for Manifests and returns its Encoding, with which Manifests
+// can be decoded with NewManifest and encoded with Manifest.Encode. Manifest files with the extensions of the
+// format are decoded and encoded with it by ReadManifestFile and WriteManifestFile.
+//
+// Returns an error if the format has no name or encode and decode functions, if its name or any of its
+// extensions is already registered or if an extension is invalid. Registered encodings are only supported
+// by the functions that operate on decoded Manifests, while RawManifest, SignedManifest, ManifestDecoder
+// and DecodeManifestHeader only support the built-in POLO, JSON and YAML encodings.
+func RegisterEncoding(format EncodingFormat) (Encoding, error) {
+	format.Name = strings.ToLower(strings.TrimSpace(format.Name))
+	if format.Name == "" {
+		return 0, errors.New("encoding format has no name")
+	}
+
+	if format.Encode == nil || format.Decode == nil {
+		return 0, errors.Errorf("encoding format '%v' has no encode or decode function", format.Name)
+	}
+
+	extensions := make([]string, 0, len(format.Extensions))
+
+	for _, extension := range format.Extensions {
+		extension = strings.ToLower(extension)
+		if len(extension) < 2 || !strings.HasPrefix(extension, ".") || strings.Count(extension, ".") != 1 {
+			return 0, errors.Errorf("encoding format '%v' has invalid file extension '%v'", format.Name, extension)
+		}
+
+		if _, compression := compressionExtensions[extension]; compression {
+			return 0, errors.Errorf("file extension '%v' is reserved for compressed manifest files", extension)
+		}
+
+		extensions = append(extensions, extension)
+	}
+
+	format.Extensions = extensions
+
+	encodings.mutex.Lock()
+	defer encodings.mutex.Unlock()
+
+	for _, registered := range encodings.formats {
+		if registered.Name == format.Name {
+			return 0, errors.Errorf("encoding '%v' is already registered", format.Name)
+		}
+
+		for _, extension := range registered.Extensions {
+			for _, candidate := range format.Extensions {
+				if extension == candidate {
+					return 0, errors.Errorf(
+						"file extension '%v' is already registered for encoding '%v'", extension, registered.Name,
+					)
+				}
+			}
+		}
+	}
+
+	encodings.formats = append(encodings.formats, format)
+
+	return Encoding(len(encodings.formats) - 1), nil
+}
+
+// LookupEncoding returns the EncodingFormat of an Encoding. Returns false if the Encoding is not registered.
+func LookupEncoding(encoding Encoding) (EncodingFormat, bool) {
+	encodings.mutex.RLock()
+	defer encodings.mutex.RUnlock()
+
+	if encoding < 0 || int(encoding) >= len(encodings.formats) {
+		return EncodingFormat{}, false
+	}
+
+	return encodings.formats[encoding], true
+}
+
+// SupportedEncodings returns every supported Encoding, in the order in which they were registered
+func SupportedEncodings() []Encoding {
+	encodings.mutex.RLock()
+	defer encodings.mutex.RUnlock()
+
+	supported := make([]Encoding, 0, len(encodings.formats))
+	for idx := range encodings.formats {
+		supported = append(supported, Encoding(idx))
+	}
+
+	return supported
+}
+
+// ParseEncoding parses the name of an encoding (such as "yaml") into an Encoding.
+// The name is case-insensitive. Returns an error if no encoding is registered with the name.
+func ParseEncoding(str string) (Encoding, error) {
+	name := strings.ToLower(strings.TrimSpace(str))
+
+	encodings.mutex.RLock()
+	defer encodings.mutex.RUnlock()
+
+	for idx, format := range encodings.formats {
+		if format.Name == name {
+			return Encoding(idx), nil
+		}
+	}
+
+	return 0, errors.Errorf("unsupported manifest encoding '%v'", str)
+}
+
+// String implements the Stringer interface for Encoding.
+// Returns the name of the encoding, or its value for an unregistered encoding.
+func (encoding Encoding) String() string {
+	if format, ok := LookupEncoding(encoding); ok {
+		return format.Name
+	}
+
+	return fmt.Sprintf("Encoding(%d)", int(encoding))
+}
+
+// MarshalText implements the encoding.TextMarshaler interface for Encoding
+func (encoding Encoding) MarshalText() ([]byte, error) {
+	format, ok := LookupEncoding(encoding)
+	if !ok {
+		return nil, errors.Errorf("unsupported manifest encoding %d", int(encoding))
+	}
+
+	return []byte(format.Name), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface for Encoding
+func (encoding *Encoding) UnmarshalText(text []byte) (err error) {
+	*encoding, err = ParseEncoding(string(text))
+
+	return err
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Encoding.
+// It accepts the name of the encoding or, for compatibility, its numeric value.
+func (encoding *Encoding) UnmarshalJSON(data []byte) error {
+	if value, err := strconv.Atoi(string(data)); err == nil {
+		return encoding.setValue(value)
+	}
+
+	name := new(string)
+	if err := json.Unmarshal(data, name); err != nil {
+		return err
+	}
+
+	return encoding.UnmarshalText([]byte(*name))
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for Encoding.
+// It accepts the name of the encoding or, for compatibility, its numeric value.
+func (encoding *Encoding) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!int" {
+		value := new(int)
+		if err := node.Decode(value); err != nil {
+			return err
+		}
+
+		return encoding.setValue(*value)
+	}
+
+	name := new(string)
+	if err := node.Decode(name); err != nil {
+		return err
+	}
+
+	return encoding.UnmarshalText([]byte(*name))
+}
+
+// setValue sets the Encoding to a numeric value, which must be a supported encoding
+func (encoding *Encoding) setValue(value int) error {
+	if _, ok := LookupEncoding(Encoding(value)); !ok {
+		return errors.Errorf("unsupported manifest encoding %d", value)
+	}
+
+	*encoding = Encoding(value)
+
+	return nil
+}
+
+// extensionEncoding returns the Encoding registered for a (lowercase) file extension
+func extensionEncoding(extension string) (Encoding, bool) {
+	encodings.mutex.RLock()
+	defer encodings.mutex.RUnlock()
+
+	for idx, format := range encodings.formats {
+		for _, registered := range format.Extensions {
+			if registered == extension {
+				return Encoding(idx), true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// builtinDecoder returns the decode function of a built-in encoding, which decodes manifest data
+// of any supported syntax version and migrates it to the current syntax version
+func builtinDecoder(encoding Encoding) func([]byte, *Registry) (*Manifest, error) {
+	return func(data []byte, registry *Registry) (*Manifest, error) {
+		raw, err := decodeManifestData(data, encoding)
+		if err != nil {
+			return nil, err
+		}
+
+		return raw.resolve(registry)
+	}
+}
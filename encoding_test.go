@@ -0,0 +1,203 @@
+package engineio
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// withEncoding registers an encoding format for the duration of a test
+func withEncoding(t *testing.T, format EncodingFormat) Encoding {
+	t.Helper()
+
+	encodings.mutex.RLock()
+	previous := encodings.formats
+	encodings.mutex.RUnlock()
+
+	encoding, err := RegisterEncoding(format)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		encodings.mutex.Lock()
+		defer encodings.mutex.Unlock()
+
+		encodings.formats = previous
+	})
+
+	return encoding
+}
+
+// base64Format is an encoding format for testing that encodes Manifests as base64 encoded JSON
+var base64Format = EncodingFormat{
+	Name:       "Base64",
+	Extensions: []string{".B64"},
+	Encode: func(manifest *Manifest) ([]byte, error) {
+		encoded, err := manifest.Encode(JSON)
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(base64.StdEncoding.EncodeToString(encoded)), nil
+	},
+	Decode: func(data []byte, registry *Registry) (*Manifest, error) {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return nil, err
+		}
+
+		return registry.NewManifest(decoded, JSON)
+	},
+}
+
+func TestEncoding(t *testing.T) {
+	require.Equal(t, []Encoding{POLO, JSON, YAML}, SupportedEncodings())
+
+	for encoding, name := range map[Encoding]string{POLO: "polo", JSON: "json", YAML: "yaml"} {
+		require.Equal(t, name, encoding.String())
+
+		parsed, err := ParseEncoding(name)
+		require.NoError(t, err)
+		require.Equal(t, encoding, parsed)
+	}
+
+	parsed, err := ParseEncoding(" YAML ")
+	require.NoError(t, err)
+	require.Equal(t, YAML, parsed)
+
+	_, err = ParseEncoding("toml")
+	require.EqualError(t, err, "unsupported manifest encoding 'toml'")
+
+	require.Equal(t, "Encoding(10)", Encoding(10).String())
+
+	_, ok := LookupEncoding(Encoding(-1))
+	require.False(t, ok)
+
+	_, err = Encoding(10).MarshalText()
+	require.EqualError(t, err, "unsupported manifest encoding 10")
+}
+
+func TestEncoding_Marshal(t *testing.T) {
+	type object struct {
+		Encodings []Encoding `json:"encodings" yaml:"encodings"`
+	}
+
+	encoded, err := json.Marshal(object{[]Encoding{POLO, YAML}})
+	require.NoError(t, err)
+	require.Equal(t, `{"encodings":["polo","yaml"]}`, string(encoded))
+
+	decoded := new(object)
+	require.NoError(t, json.Unmarshal(encoded, decoded))
+	require.Equal(t, []Encoding{POLO, YAML}, decoded.Encodings)
+
+	// The numeric values of the encodings are accepted for compatibility
+	require.NoError(t, json.Unmarshal([]byte(`{"encodings":[1]}`), decoded))
+	require.Equal(t, []Encoding{JSON}, decoded.Encodings)
+
+	require.EqualError(t, json.Unmarshal([]byte(`{"encodings":[10]}`), decoded), "unsupported manifest encoding 10")
+	err = json.Unmarshal([]byte(`{"encodings":["toml"]}`), decoded)
+	require.EqualError(t, err, "unsupported manifest encoding 'toml'")
+
+	encoded, err = yaml.Marshal(object{[]Encoding{JSON}})
+	require.NoError(t, err)
+	require.Equal(t, "encodings:\n    - json\n", string(encoded))
+
+	require.NoError(t, yaml.Unmarshal(encoded, decoded))
+	require.Equal(t, []Encoding{JSON}, decoded.Encodings)
+
+	require.NoError(t, yaml.Unmarshal([]byte("encodings: [2, polo]"), decoded))
+	require.Equal(t, []Encoding{YAML, POLO}, decoded.Encodings)
+
+	require.EqualError(t, yaml.Unmarshal([]byte("encodings: [10]"), decoded), "unsupported manifest encoding 10")
+	err = yaml.Unmarshal([]byte("encodings: [toml]"), decoded)
+	require.EqualError(t, err, "unsupported manifest encoding 'toml'")
+}
+
+func TestRegisterEncoding(t *testing.T) {
+	encoding := withEncoding(t, base64Format)
+
+	require.Equal(t, Encoding(3), encoding)
+	require.Equal(t, "base64", encoding.String())
+	require.Equal(t, []Encoding{POLO, JSON, YAML, encoding}, SupportedEncodings())
+
+	format, ok := LookupEncoding(encoding)
+	require.True(t, ok)
+	require.Equal(t, []string{".b64"}, format.Extensions)
+
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+
+	encoded, err := manifest.Encode(encoding)
+	require.NoError(t, err)
+
+	decoded, err := registry.NewManifest(encoded, encoding)
+	require.NoError(t, err)
+	require.Equal(t, manifest, *decoded)
+
+	// Manifest files with the extensions of the format are encoded and decoded with it
+	for _, name := range []string{"manifest.b64", "manifest.B64.gz"} {
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, WriteManifestFile(path, &manifest))
+
+		decoded, err = registry.ReadManifestFile(path)
+		require.NoError(t, err)
+		require.Equal(t, manifest, *decoded)
+	}
+}
+
+func TestRegisterEncoding_Errors(t *testing.T) {
+	encode := func(*Manifest) ([]byte, error) { return nil, nil }
+	decode := func([]byte, *Registry) (*Manifest, error) { return nil, nil }
+
+	tests := []struct {
+		name   string
+		format EncodingFormat
+		err    string
+	}{
+		{"no name", EncodingFormat{Name: " ", Encode: encode, Decode: decode}, "encoding format has no name"},
+		{
+			"no functions",
+			EncodingFormat{Name: "toml", Encode: encode},
+			"encoding format 'toml' has no encode or decode function",
+		},
+		{
+			"duplicate name",
+			EncodingFormat{Name: "JSON", Encode: encode, Decode: decode},
+			"encoding 'json' is already registered",
+		},
+		{
+			"duplicate extension",
+			EncodingFormat{Name: "toml", Extensions: []string{".toml", ".YML"}, Encode: encode, Decode: decode},
+			"file extension '.yml' is already registered for encoding 'yaml'",
+		},
+		{
+			"invalid extension",
+			EncodingFormat{Name: "toml", Extensions: []string{"toml"}, Encode: encode, Decode: decode},
+			"encoding format 'toml' has invalid file extension 'toml'",
+		},
+		{
+			"nested extension",
+			EncodingFormat{Name: "toml", Extensions: []string{".toml.gz"}, Encode: encode, Decode: decode},
+			"encoding format 'toml' has invalid file extension '.toml.gz'",
+		},
+		{
+			"compression extension",
+			EncodingFormat{Name: "toml", Extensions: []string{".zst"}, Encode: encode, Decode: decode},
+			"file extension '.zst' is reserved for compressed manifest files",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := RegisterEncoding(test.format)
+			require.EqualError(t, err, test.err)
+		})
+	}
+
+	require.Equal(t, []Encoding{POLO, JSON, YAML}, SupportedEncodings())
+}
@@ -58,6 +58,8 @@ type EngineRuntime interface {
 
 	// CompileManifest generates a LogicDescriptor from a Manifest, which can then be used to generate
 	// a LogicDriver object. The fuel spent during compile is returned with any potential error.
+	// Runtimes that implement FlagDeclarer can parse the engine flags of the Manifest into
+	// EngineFlags with ManifestEngine.ParseFlags to obtain their typed values.
 	CompileManifest(EngineFuel, *Manifest) (*LogicDescriptor, EngineFuel, error)
 
 	// ValidateCalldata verifies the calldata and callsite in an IxnObject.
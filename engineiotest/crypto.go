@@ -0,0 +1,83 @@
+package engineiotest
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+// CryptoDriver is an implementation of engineio.CryptoDriver that uses Ed25519 signatures.
+// Signatures for it can be generated with a Signer. It is stateless and safe for concurrent use.
+type CryptoDriver struct{}
+
+// NewCryptoDriver returns a new CryptoDriver
+func NewCryptoDriver() CryptoDriver {
+	return CryptoDriver{}
+}
+
+// ValidateSignature returns whether the signature has the format of an Ed25519 signature.
+// Implements the engineio.CryptoDriver interface for CryptoDriver.
+func (CryptoDriver) ValidateSignature(sig []byte) bool {
+	return len(sig) == ed25519.SignatureSize
+}
+
+// VerifySignature verifies an Ed25519 signature over some data for a public key.
+// Returns an error if the signature or public key are malformed.
+// Implements the engineio.CryptoDriver interface for CryptoDriver.
+func (CryptoDriver) VerifySignature(data, sig, pub []byte) (bool, error) {
+	if len(sig) != ed25519.SignatureSize {
+		return false, errors.Errorf("invalid signature: expected %v bytes, got %v", ed25519.SignatureSize, len(sig))
+	}
+
+	if len(pub) != ed25519.PublicKeySize {
+		return false, errors.Errorf("invalid public key: expected %v bytes, got %v", ed25519.PublicKeySize, len(pub))
+	}
+
+	return ed25519.Verify(pub, data, sig), nil
+}
+
+// Signer generates Ed25519 signatures that can be verified with a CryptoDriver
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewSigner returns a Signer with a key pair that is deterministically derived from
+// the given seed, allowing tests to use stable keys and signatures across runs.
+func NewSigner(seed string) *Signer {
+	digest := sha256.Sum256([]byte(seed))
+
+	return &Signer{key: ed25519.NewKeyFromSeed(digest[:])}
+}
+
+// PublicKey returns the public key of the Signer
+func (signer *Signer) PublicKey() []byte {
+	// The public key of an Ed25519 private key is stored after its seed
+	return append([]byte(nil), signer.key[ed25519.SeedSize:]...)
+}
+
+// Sign returns the signature of the Signer over the given data
+func (signer *Signer) Sign(data []byte) []byte {
+	return ed25519.Sign(signer.key, data)
+}
+
+// ManifestSigner returns an engineio.ManifestSigner that signs with the key pair of the
+// Signer, for generating SignedManifests that can be verified with a CryptoDriver
+func (signer *Signer) ManifestSigner() engineio.ManifestSigner {
+	return manifestSigner{signer}
+}
+
+// manifestSigner adapts a Signer to the engineio.ManifestSigner interface
+type manifestSigner struct {
+	signer *Signer
+}
+
+func (adapter manifestSigner) PublicKey() []byte {
+	return adapter.signer.PublicKey()
+}
+
+func (adapter manifestSigner) Sign(data []byte) ([]byte, error) {
+	return adapter.signer.Sign(data), nil
+}
@@ -0,0 +1,62 @@
+package engineiotest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+var _ engineio.CryptoDriver = CryptoDriver{}
+
+func TestCryptoDriver(t *testing.T) {
+	crypto := NewCryptoDriver()
+	signer := NewSigner("alice")
+
+	data := []byte("message")
+	sig := signer.Sign(data)
+
+	require.True(t, crypto.ValidateSignature(sig))
+	require.False(t, crypto.ValidateSignature(sig[1:]))
+
+	ok, err := crypto.VerifySignature(data, sig, signer.PublicKey())
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = crypto.VerifySignature([]byte("forged"), sig, signer.PublicKey())
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = crypto.VerifySignature(data, sig, NewSigner("bob").PublicKey())
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = crypto.VerifySignature(data, sig[1:], signer.PublicKey())
+	require.EqualError(t, err, "invalid signature: expected 64 bytes, got 63")
+
+	_, err = crypto.VerifySignature(data, sig, []byte{1})
+	require.EqualError(t, err, "invalid public key: expected 32 bytes, got 1")
+
+	// signers are deterministic for a seed
+	require.Equal(t, signer.PublicKey(), NewSigner("alice").PublicKey())
+	require.Equal(t, sig, NewSigner("alice").Sign(data))
+}
+
+func TestSigner_ManifestSigner(t *testing.T) {
+	manifest := &engineio.Manifest{
+		Syntax:   engineio.CurrentSyntax(),
+		Engine:   engineio.ManifestEngine{Kind: "TEST", Flags: []string{}},
+		Elements: []engineio.ManifestElement{},
+	}
+
+	signed, err := engineio.SignManifest(manifest, NewSigner("alice").ManifestSigner())
+	require.NoError(t, err)
+	require.Equal(t, NewSigner("alice").PublicKey(), signed.PublicKey)
+
+	data := append([]byte(engineio.ManifestSignatureDomain), signed.Hash[:]...)
+
+	ok, err := NewCryptoDriver().VerifySignature(data, signed.Signature, signed.PublicKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,242 @@
+package engineiotest
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/sarvalabs/go-moi-identifiers"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+// CtxDriver is an in-memory implementation of engineio.CtxDriver.
+// Its storage is a map of keys to values and is safe for concurrent use.
+// Values are copied when they are written and read from the storage.
+type CtxDriver struct {
+	address identifiers.Address
+	logic   identifiers.LogicID
+
+	mutex    sync.RWMutex
+	storage  map[string][]byte
+	readonly bool
+}
+
+// NewCtxDriver returns a new CtxDriver with empty storage for the
+// context of the given address within the namespace of the given logic
+func NewCtxDriver(address identifiers.Address, logic identifiers.LogicID) *CtxDriver {
+	return &CtxDriver{address: address, logic: logic, storage: make(map[string][]byte)}
+}
+
+// SetReadOnly sets whether the CtxDriver rejects writes to its storage.
+// SetStorageEntry returns false for every write while the driver is read-only.
+func (ctx *CtxDriver) SetReadOnly(readonly bool) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.readonly = readonly
+}
+
+// Entries returns a snapshot of all the storage entries of the CtxDriver
+func (ctx *CtxDriver) Entries() map[string][]byte {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+
+	entries := make(map[string][]byte, len(ctx.storage))
+	for key, value := range ctx.storage {
+		entries[key] = clone(value)
+	}
+
+	return entries
+}
+
+// Keys returns the keys of all the storage entries of the CtxDriver, sorted lexicographically
+func (ctx *CtxDriver) Keys() [][]byte {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+
+	keys := make([]string, 0, len(ctx.storage))
+	for key := range ctx.storage {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	raw := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		raw = append(raw, []byte(key))
+	}
+
+	return raw
+}
+
+// Address returns the address of the context.
+// Implements the engineio.CtxDriver interface for CtxDriver.
+func (ctx *CtxDriver) Address() identifiers.Address {
+	return ctx.address
+}
+
+// LogicID returns the LogicID of the namespace of the context.
+// Implements the engineio.CtxDriver interface for CtxDriver.
+func (ctx *CtxDriver) LogicID() identifiers.LogicID {
+	return ctx.logic
+}
+
+// GetStorageEntry returns the value for a storage key with confirmation of its existence.
+// Implements the engineio.CtxDriver interface for CtxDriver.
+func (ctx *CtxDriver) GetStorageEntry(key []byte) ([]byte, bool) {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+
+	value, ok := ctx.storage[string(key)]
+	if !ok {
+		return nil, false
+	}
+
+	return clone(value), true
+}
+
+// SetStorageEntry sets the value for a storage key. A nil value deletes the entry.
+// Returns false if the CtxDriver is read-only.
+// Implements the engineio.CtxDriver interface for CtxDriver.
+func (ctx *CtxDriver) SetStorageEntry(key, value []byte) bool {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.readonly {
+		return false
+	}
+
+	if value == nil {
+		delete(ctx.storage, string(key))
+
+		return true
+	}
+
+	ctx.storage[string(key)] = clone(value)
+
+	return true
+}
+
+// EnvDriver is an in-memory implementation of engineio.EnvDriver
+type EnvDriver struct {
+	timestamp int64
+	cluster   string
+}
+
+// NewEnvDriver returns a new EnvDriver with the given execution timestamp and cluster ID
+func NewEnvDriver(timestamp int64, cluster string) *EnvDriver {
+	return &EnvDriver{timestamp: timestamp, cluster: cluster}
+}
+
+// Timestamp returns the execution timestamp of the environment.
+// Implements the engineio.EnvDriver interface for EnvDriver.
+func (env *EnvDriver) Timestamp() int64 {
+	return env.timestamp
+}
+
+// ClusterID returns the consensus cluster ID of the environment.
+// Implements the engineio.EnvDriver interface for EnvDriver.
+func (env *EnvDriver) ClusterID() string {
+	return env.cluster
+}
+
+// IxnType is an implementation of engineio.IxnType with an arbitrary ID and name.
+// It does not enforce the interaction types of any particular protocol implementation.
+type IxnType struct {
+	ID   int
+	Name string
+}
+
+// IxnID returns the unique ID of the IxnType.
+// Implements the engineio.IxnType interface for IxnType.
+func (ixn IxnType) IxnID() int {
+	return ixn.ID
+}
+
+// String returns the name of the IxnType.
+// Implements the engineio.IxnType interface for IxnType.
+func (ixn IxnType) String() string {
+	return ixn.Name
+}
+
+// IxnDriver is an in-memory implementation of engineio.IxnDriver.
+// It is created with NewIxnDriver and can be configured with its With methods.
+type IxnDriver struct {
+	kind engineio.IxnType
+
+	price *big.Int
+	limit uint64
+
+	callsite string
+	calldata []byte
+}
+
+// NewIxnDriver returns a new IxnDriver for the given interaction type, callsite and calldata.
+// The fuel price and limit default to 1 and 10000 respectively.
+func NewIxnDriver(kind engineio.IxnType, callsite string, calldata []byte) *IxnDriver {
+	return &IxnDriver{
+		kind:     kind,
+		price:    big.NewInt(1),
+		limit:    10000,
+		callsite: callsite,
+		calldata: calldata,
+	}
+}
+
+// WithFuel sets the fuel price and limit of the IxnDriver and returns it
+func (ixn *IxnDriver) WithFuel(price *big.Int, limit uint64) *IxnDriver {
+	ixn.price, ixn.limit = price, limit
+
+	return ixn
+}
+
+// IxnType returns the type of the interaction.
+// Implements the engineio.IxnDriver interface for IxnDriver.
+func (ixn *IxnDriver) IxnType() engineio.IxnType {
+	return ixn.kind
+}
+
+// FuelPrice returns the fuel price of the interaction.
+// Implements the engineio.IxnDriver interface for IxnDriver.
+func (ixn *IxnDriver) FuelPrice() *big.Int {
+	return new(big.Int).Set(ixn.price)
+}
+
+// FuelLimit returns the fuel limit of the interaction.
+// Implements the engineio.IxnDriver interface for IxnDriver.
+func (ixn *IxnDriver) FuelLimit() uint64 {
+	return ixn.limit
+}
+
+// Callsite returns the name of the callsite of the interaction.
+// Implements the engineio.IxnDriver interface for IxnDriver.
+func (ixn *IxnDriver) Callsite() string {
+	return ixn.callsite
+}
+
+// Calldata returns the input calldata of the interaction.
+// Implements the engineio.IxnDriver interface for IxnDriver.
+func (ixn *IxnDriver) Calldata() []byte {
+	return ixn.calldata
+}
+
+// References is an implementation of engineio.ReferenceProvider backed by a map
+type References map[engineio.ReferenceVal]any
+
+// GetReference resolves a ReferenceVal with confirmation of its existence.
+// Implements the engineio.ReferenceProvider interface for References.
+func (refs References) GetReference(ref engineio.ReferenceVal) (any, bool) {
+	value, ok := refs[ref]
+
+	return value, ok
+}
+
+// clone returns a copy of a byte slice, preserving nil
+func clone(data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+
+	return append(make([]byte, 0, len(data)), data...)
+}
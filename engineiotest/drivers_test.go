@@ -0,0 +1,124 @@
+package engineiotest
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/sarvalabs/go-moi-identifiers"
+	"github.com/stretchr/testify/require"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+var (
+	_ engineio.CtxDriver         = (*CtxDriver)(nil)
+	_ engineio.EnvDriver         = (*EnvDriver)(nil)
+	_ engineio.IxnType           = IxnType{}
+	_ engineio.IxnDriver         = (*IxnDriver)(nil)
+	_ engineio.ReferenceProvider = References{}
+)
+
+func TestCtxDriver(t *testing.T) {
+	address := identifiers.NewAddressFromBytes([]byte{0xbb})
+	ctx := NewCtxDriver(address, "0a")
+
+	require.Equal(t, address, ctx.Address())
+	require.Equal(t, identifiers.LogicID("0a"), ctx.LogicID())
+
+	_, ok := ctx.GetStorageEntry([]byte("key"))
+	require.False(t, ok)
+
+	value := []byte("value")
+	require.True(t, ctx.SetStorageEntry([]byte("key"), value))
+
+	// mutating the written value must not affect the storage
+	value[0] = 'V'
+
+	stored, ok := ctx.GetStorageEntry([]byte("key"))
+	require.True(t, ok)
+	require.Equal(t, []byte("value"), stored)
+
+	// mutating the read value must not affect the storage
+	stored[0] = 'V'
+
+	stored, _ = ctx.GetStorageEntry([]byte("key"))
+	require.Equal(t, []byte("value"), stored)
+
+	require.True(t, ctx.SetStorageEntry([]byte("another"), []byte{}))
+	require.Equal(t, [][]byte{[]byte("another"), []byte("key")}, ctx.Keys())
+	require.Equal(t, map[string][]byte{"key": []byte("value"), "another": {}}, ctx.Entries())
+
+	ctx.SetReadOnly(true)
+	require.False(t, ctx.SetStorageEntry([]byte("key"), []byte("updated")))
+
+	ctx.SetReadOnly(false)
+	require.True(t, ctx.SetStorageEntry([]byte("key"), nil))
+
+	_, ok = ctx.GetStorageEntry([]byte("key"))
+	require.False(t, ok)
+}
+
+func TestCtxDriver_Concurrency(t *testing.T) {
+	ctx := NewCtxDriver(identifiers.NilAddress, "0a")
+
+	var group sync.WaitGroup
+
+	for i := 0; i < 16; i++ {
+		group.Add(1)
+
+		go func(i int) {
+			defer group.Done()
+
+			key := []byte{byte(i)}
+			ctx.SetStorageEntry(key, key)
+			ctx.GetStorageEntry(key)
+			ctx.Entries()
+		}(i)
+	}
+
+	group.Wait()
+	require.Len(t, ctx.Keys(), 16)
+}
+
+func TestEnvDriver(t *testing.T) {
+	env := NewEnvDriver(1700000000, "cluster-1")
+
+	require.Equal(t, int64(1700000000), env.Timestamp())
+	require.Equal(t, "cluster-1", env.ClusterID())
+}
+
+func TestIxnDriver(t *testing.T) {
+	kind := IxnType{ID: 3, Name: "IxLogicInvoke"}
+	require.Equal(t, 3, kind.IxnID())
+	require.Equal(t, "IxLogicInvoke", kind.String())
+
+	ixn := NewIxnDriver(kind, "Transfer", []byte{0x0d})
+	require.Equal(t, kind, ixn.IxnType())
+	require.Equal(t, "Transfer", ixn.Callsite())
+	require.Equal(t, []byte{0x0d}, ixn.Calldata())
+	require.Equal(t, big.NewInt(1), ixn.FuelPrice())
+	require.Equal(t, uint64(10000), ixn.FuelLimit())
+
+	// the returned fuel price must not alias the driver's
+	ixn.FuelPrice().SetInt64(100)
+	require.Equal(t, big.NewInt(1), ixn.FuelPrice())
+
+	ixn = ixn.WithFuel(big.NewInt(5), 200)
+	require.Equal(t, big.NewInt(5), ixn.FuelPrice())
+	require.Equal(t, uint64(200), ixn.FuelLimit())
+}
+
+func TestReferences(t *testing.T) {
+	refs := References{"amount": uint64(300)}
+
+	encoded, err := engineio.EncodeValues(engineio.ReferenceVal("amount"), refs)
+	require.NoError(t, err)
+
+	expected, err := engineio.EncodeValues(uint64(300), nil)
+	require.NoError(t, err)
+	require.Equal(t, expected, encoded)
+
+	_, ok := refs.GetReference("missing")
+	require.False(t, ok)
+}
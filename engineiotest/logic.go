@@ -0,0 +1,191 @@
+// Package engineiotest provides in-memory implementations of the driver interfaces of engineio.
+//
+// The implementations are fully functional and configurable, and are intended to be shared
+// as test fixtures by engine runtime authors (who need drivers to execute their engines with)
+// and protocol implementations (who need results and crypto to validate their integrations).
+package engineiotest
+
+import (
+	"sort"
+
+	"github.com/sarvalabs/go-moi-identifiers"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+// Logic is an in-memory implementation of engineio.Logic that is generated from a LogicDescriptor.
+// Its LogicID is derived from the properties of the descriptor, such as its context state and
+// whether it is interactive, in the same manner as a protocol implementation would for a deployment.
+type Logic struct {
+	id         identifiers.LogicID
+	descriptor *engineio.LogicDescriptor
+
+	sealed bool
+	asset  bool
+}
+
+// LogicOption is a functional option for configuring a Logic
+type LogicOption func(*logicConfig)
+
+type logicConfig struct {
+	sealed  bool
+	asset   bool
+	edition uint16
+}
+
+// SealedLogic configures the Logic to report that its state has been sealed
+func SealedLogic() LogicOption {
+	return func(config *logicConfig) { config.sealed = true }
+}
+
+// AssetLogic configures the Logic as one that regulates an Asset
+func AssetLogic() LogicOption {
+	return func(config *logicConfig) { config.asset = true }
+}
+
+// LogicEdition configures the edition of the Logic that is encoded into its LogicID
+func LogicEdition(edition uint16) LogicOption {
+	return func(config *logicConfig) { config.edition = edition }
+}
+
+// NewLogic generates a new Logic deployed at the given address from a LogicDescriptor.
+// The descriptor is used as is (not copied) and must not be modified while the Logic is in use.
+func NewLogic(address identifiers.Address, descriptor *engineio.LogicDescriptor, opts ...LogicOption) *Logic {
+	config := new(logicConfig)
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	id := identifiers.NewLogicIDv0(
+		descriptor.CtxState.Persistent(),
+		descriptor.CtxState.Ephemeral(),
+		descriptor.Interactive,
+		config.asset,
+		config.edition,
+		address,
+	)
+
+	return &Logic{id: id, descriptor: descriptor, sealed: config.sealed, asset: config.asset}
+}
+
+// Descriptor returns the LogicDescriptor that the Logic was generated from
+func (logic *Logic) Descriptor() *engineio.LogicDescriptor {
+	return logic.descriptor
+}
+
+// LogicID returns the LogicID of the Logic.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) LogicID() identifiers.LogicID {
+	return logic.id
+}
+
+// Engine returns the EngineKind of the Logic.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) Engine() engineio.EngineKind {
+	return logic.descriptor.Engine
+}
+
+// Manifest returns the hash of the Manifest of the Logic.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) Manifest() engineio.Hash {
+	return logic.descriptor.ManifestHash
+}
+
+// IsSealed returns whether the state of the Logic has been sealed.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) IsSealed() bool {
+	return logic.sealed
+}
+
+// IsAssetLogic returns whether the Logic regulates an Asset.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) IsAssetLogic() bool {
+	return logic.asset
+}
+
+// IsInteractive returns whether the Logic supports Interactable Callsites.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) IsInteractive() bool {
+	return logic.descriptor.Interactive
+}
+
+// PersistentState returns the pointer to the persistent state element of the Logic.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) PersistentState() (engineio.ElementPtr, bool) {
+	ptr, ok := logic.descriptor.CtxState[engineio.PersistentState]
+
+	return ptr, ok
+}
+
+// EphemeralState returns the pointer to the ephemeral state element of the Logic.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) EphemeralState() (engineio.ElementPtr, bool) {
+	ptr, ok := logic.descriptor.CtxState[engineio.EphemeralState]
+
+	return ptr, ok
+}
+
+// GetElementDeps returns the aggregated dependencies of an element pointer.
+// If the descriptor has a DependencyDriver, it is used to resolve the dependencies.
+// Otherwise, they are resolved from the dependencies of the elements in the descriptor.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) GetElementDeps(ptr engineio.ElementPtr) []engineio.ElementPtr {
+	if logic.descriptor.Dependency != nil {
+		return logic.descriptor.Dependency.Dependencies(ptr)
+	}
+
+	visited := make(map[engineio.ElementPtr]struct{})
+	pending := []engineio.ElementPtr{ptr}
+
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		element, ok := logic.descriptor.Elements[current]
+		if !ok {
+			continue
+		}
+
+		for _, dep := range element.Deps {
+			if _, seen := visited[dep]; seen || dep == ptr {
+				continue
+			}
+
+			visited[dep] = struct{}{}
+			pending = append(pending, dep)
+		}
+	}
+
+	deps := make([]engineio.ElementPtr, 0, len(visited))
+	for dep := range visited {
+		deps = append(deps, dep)
+	}
+
+	sort.Slice(deps, func(i, j int) bool { return deps[i] < deps[j] })
+
+	return deps
+}
+
+// GetElement returns the LogicElement for a given element pointer.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) GetElement(ptr engineio.ElementPtr) (*engineio.LogicElement, bool) {
+	element, ok := logic.descriptor.Elements[ptr]
+
+	return element, ok
+}
+
+// GetCallsite returns the Callsite for a given name.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) GetCallsite(name string) (*engineio.Callsite, bool) {
+	callsite, ok := logic.descriptor.Callsites[name]
+
+	return callsite, ok
+}
+
+// GetClassdef returns the Classdef for a given name.
+// Implements the engineio.Logic interface for Logic.
+func (logic *Logic) GetClassdef(name string) (*engineio.Classdef, bool) {
+	classdef, ok := logic.descriptor.Classdefs[name]
+
+	return classdef, ok
+}
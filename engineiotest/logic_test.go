@@ -0,0 +1,115 @@
+package engineiotest
+
+import (
+	"testing"
+
+	"github.com/sarvalabs/go-moi-identifiers"
+	"github.com/stretchr/testify/require"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+var _ engineio.Logic = (*Logic)(nil)
+
+func newTestDescriptor() *engineio.LogicDescriptor {
+	return &engineio.LogicDescriptor{
+		Engine:       engineio.PISA,
+		ManifestRaw:  []byte{1, 2, 3},
+		ManifestHash: engineio.Hash{1},
+		Interactive:  true,
+		Elements: engineio.LogicElementTable{
+			0: {Kind: "state", Deps: []engineio.ElementPtr{}},
+			1: {Kind: "constant", Deps: []engineio.ElementPtr{}},
+			2: {Kind: "routine", Deps: []engineio.ElementPtr{0, 1}},
+			3: {Kind: "routine", Deps: []engineio.ElementPtr{2}},
+		},
+		CtxState: engineio.ContextStateMatrix{engineio.PersistentState: 0},
+		Callsites: map[string]*engineio.Callsite{
+			"Transfer": {Ptr: 3, Kind: engineio.InvokableCallsite},
+		},
+		Classdefs: map[string]*engineio.Classdef{
+			"Person": {Ptr: 1},
+		},
+	}
+}
+
+func TestNewLogic(t *testing.T) {
+	address := identifiers.NewAddressFromBytes([]byte{0xaa})
+	descriptor := newTestDescriptor()
+
+	logic := NewLogic(address, descriptor)
+	require.Equal(t, descriptor, logic.Descriptor())
+	require.Equal(t, engineio.PISA, logic.Engine())
+	require.Equal(t, engineio.Hash{1}, logic.Manifest())
+	require.False(t, logic.IsSealed())
+	require.False(t, logic.IsAssetLogic())
+	require.True(t, logic.IsInteractive())
+
+	identifier, err := logic.LogicID().Identifier()
+	require.NoError(t, err)
+	require.Equal(t, address, identifier.Address())
+	require.True(t, identifier.HasPersistentState())
+	require.False(t, identifier.HasEphemeralState())
+	require.True(t, identifier.HasInteractableSites())
+	require.False(t, identifier.AssetLogic())
+	require.Equal(t, uint64(0), identifier.Edition())
+
+	ptr, ok := logic.PersistentState()
+	require.True(t, ok)
+	require.Equal(t, engineio.ElementPtr(0), ptr)
+
+	_, ok = logic.EphemeralState()
+	require.False(t, ok)
+
+	asset := NewLogic(address, descriptor, AssetLogic(), SealedLogic(), LogicEdition(4))
+	require.True(t, asset.IsSealed())
+	require.True(t, asset.IsAssetLogic())
+
+	identifier, err = asset.LogicID().Identifier()
+	require.NoError(t, err)
+	require.True(t, identifier.AssetLogic())
+	require.Equal(t, uint64(4), identifier.Edition())
+}
+
+func TestLogic_Lookups(t *testing.T) {
+	logic := NewLogic(identifiers.NilAddress, newTestDescriptor())
+
+	element, ok := logic.GetElement(2)
+	require.True(t, ok)
+	require.Equal(t, engineio.ElementKind("routine"), element.Kind)
+
+	_, ok = logic.GetElement(10)
+	require.False(t, ok)
+
+	callsite, ok := logic.GetCallsite("Transfer")
+	require.True(t, ok)
+	require.Equal(t, &engineio.Callsite{Ptr: 3, Kind: engineio.InvokableCallsite}, callsite)
+
+	_, ok = logic.GetCallsite("Mint")
+	require.False(t, ok)
+
+	classdef, ok := logic.GetClassdef("Person")
+	require.True(t, ok)
+	require.Equal(t, &engineio.Classdef{Ptr: 1}, classdef)
+
+	_, ok = logic.GetClassdef("Animal")
+	require.False(t, ok)
+}
+
+func TestLogic_GetElementDeps(t *testing.T) {
+	logic := NewLogic(identifiers.NilAddress, newTestDescriptor())
+
+	tests := []struct {
+		ptr  engineio.ElementPtr
+		deps []engineio.ElementPtr
+	}{
+		{0, []engineio.ElementPtr{}},
+		{2, []engineio.ElementPtr{0, 1}},
+		{3, []engineio.ElementPtr{0, 1, 2}},
+		{10, []engineio.ElementPtr{}},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.deps, logic.GetElementDeps(test.ptr), "ptr %v", test.ptr)
+	}
+}
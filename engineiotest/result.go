@@ -0,0 +1,106 @@
+package engineiotest
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+// CallResult is an in-memory implementation of engineio.CallResult.
+// It is created with either NewCallResult or NewErrorCallResult, which
+// guarantees that exactly one of its Outputs and Error are set.
+type CallResult struct {
+	fuel    engineio.EngineFuel
+	outputs []byte
+	err     []byte
+}
+
+// NewCallResult returns a successful CallResult with the consumed fuel and the encoded outputs
+func NewCallResult(fuel engineio.EngineFuel, outputs []byte) *CallResult {
+	return &CallResult{fuel: fuel, outputs: outputs}
+}
+
+// NewErrorCallResult returns a failed CallResult with the consumed fuel and an ErrorResult.
+// The ErrorResult is encoded into the CallResult and can be decoded with DecodeErrorResult.
+func NewErrorCallResult(fuel engineio.EngineFuel, result *ErrorResult) *CallResult {
+	return &CallResult{fuel: fuel, err: result.Bytes()}
+}
+
+// Ok returns whether the call was successful.
+// Implements the engineio.CallResult interface for CallResult.
+func (result *CallResult) Ok() bool {
+	return result.err == nil
+}
+
+// Fuel returns the amount of fuel consumed by the call.
+// Implements the engineio.CallResult interface for CallResult.
+func (result *CallResult) Fuel() engineio.EngineFuel {
+	return result.fuel
+}
+
+// Outputs returns the encoded outputs of the call.
+// Implements the engineio.CallResult interface for CallResult.
+func (result *CallResult) Outputs() []byte {
+	return result.outputs
+}
+
+// Error returns the encoded ErrorResult of the call, if it failed.
+// Implements the engineio.CallResult interface for CallResult.
+func (result *CallResult) Error() []byte {
+	return result.err
+}
+
+// ErrorResult is an in-memory implementation of engineio.ErrorResult.
+// It is encoded with POLO and can be decoded with DecodeErrorResult.
+type ErrorResult struct {
+	Kind    engineio.EngineKind
+	Message string
+	Revert  bool
+}
+
+// NewErrorResult returns a new ErrorResult for an engine with a message and whether the call was reverted
+func NewErrorResult(kind engineio.EngineKind, message string, revert bool) *ErrorResult {
+	return &ErrorResult{Kind: kind, Message: message, Revert: revert}
+}
+
+// DecodeErrorResult decodes an ErrorResult from the bytes returned by its Bytes method.
+// It can be used as the implementation of DecodeErrorResult for an EngineRuntime.
+func DecodeErrorResult(data []byte) (*ErrorResult, error) {
+	result := new(ErrorResult)
+	if err := polo.Depolorize(result, data); err != nil {
+		return nil, errors.Wrap(err, "invalid error result")
+	}
+
+	return result, nil
+}
+
+// Engine returns the EngineKind of the engine that produced the error.
+// Implements the engineio.ErrorResult interface for ErrorResult.
+func (result *ErrorResult) Engine() engineio.EngineKind {
+	return result.Kind
+}
+
+// String returns the error message.
+// Implements the engineio.ErrorResult interface for ErrorResult.
+func (result *ErrorResult) String() string {
+	return result.Message
+}
+
+// Bytes returns the POLO encoded form of the ErrorResult.
+// Implements the engineio.ErrorResult interface for ErrorResult.
+func (result *ErrorResult) Bytes() []byte {
+	encoded, err := polo.Polorize(result)
+	if err != nil {
+		// The ErrorResult only contains strings and booleans which cannot fail to encode
+		panic(errors.Wrap(err, "failed to encode error result"))
+	}
+
+	return encoded
+}
+
+// Reverted returns whether the call was reverted.
+// Implements the engineio.ErrorResult interface for ErrorResult.
+func (result *ErrorResult) Reverted() bool {
+	return result.Revert
+}
@@ -0,0 +1,49 @@
+package engineiotest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+var (
+	_ engineio.CallResult  = (*CallResult)(nil)
+	_ engineio.ErrorResult = (*ErrorResult)(nil)
+)
+
+func TestCallResult(t *testing.T) {
+	result := NewCallResult(100, []byte{1, 2})
+	require.True(t, result.Ok())
+	require.Equal(t, engineio.EngineFuel(100), result.Fuel())
+	require.Equal(t, []byte{1, 2}, result.Outputs())
+	require.Nil(t, result.Error())
+
+	// a successful result without outputs is still ok
+	result = NewCallResult(10, nil)
+	require.True(t, result.Ok())
+	require.Nil(t, result.Outputs())
+
+	failure := NewErrorResult(engineio.PISA, "out of fuel", true)
+
+	result = NewErrorCallResult(50, failure)
+	require.False(t, result.Ok())
+	require.Equal(t, engineio.EngineFuel(50), result.Fuel())
+	require.Nil(t, result.Outputs())
+	require.Equal(t, failure.Bytes(), result.Error())
+}
+
+func TestErrorResult(t *testing.T) {
+	result := NewErrorResult(engineio.PISA, "division by zero", false)
+	require.Equal(t, engineio.PISA, result.Engine())
+	require.Equal(t, "division by zero", result.String())
+	require.False(t, result.Reverted())
+
+	decoded, err := DecodeErrorResult(result.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, result, decoded)
+
+	_, err = DecodeErrorResult([]byte{0xff})
+	require.Error(t, err)
+}
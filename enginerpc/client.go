@@ -0,0 +1,841 @@
+package enginerpc
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+	"gopkg.in/yaml.v3"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+// HandshakeTimeout is the time that NewClient (and StartProcess) wait for the
+// runtime to describe itself before the connection is closed and they fail
+var HandshakeTimeout = 10 * time.Second
+
+// Client is an EngineRuntime that is backed by a runtime served in another process with Serve.
+// It can be registered with an engineio.Registry like any other runtime implementation.
+//
+// Drivers passed to the Client (such as Logic, CtxDriver and EnvDriver) are not copied to the runtime
+// process but are referenced with handles, and any method called on them by the runtime is proxied back
+// to the host over the connection. The CryptoDriver given to the Client is exposed to the runtime similarly.
+type Client struct {
+	peer   *peer
+	crypto engineio.CryptoDriver
+
+	kind    engineio.EngineKind
+	version string
+
+	process *exec.Cmd
+	done    chan struct{}
+}
+
+// NewClient returns a new Client that communicates with a runtime served over the given connection.
+// The crypto driver is exposed to the runtime for signature validation and verification (can be nil).
+// Fails if the runtime cannot be described over the connection within the HandshakeTimeout.
+func NewClient(conn io.ReadWriteCloser, crypto engineio.CryptoDriver) (*Client, error) {
+	client := &Client{crypto: crypto, done: make(chan struct{})}
+	client.peer = newPeer(conn, client.handlers())
+
+	go func() {
+		defer close(client.done)
+
+		_ = client.peer.run()
+	}()
+
+	// The describe call is awaited in its own goroutine, since writing the
+	// request can block if the runtime never reads from the connection
+	described := new(describeResult)
+	response := make(chan error, 1)
+
+	go func() {
+		response <- client.peer.call(context.Background(), methodDescribe, struct{}{}, described)
+	}()
+
+	timeout := time.NewTimer(HandshakeTimeout)
+	defer timeout.Stop()
+
+	var err error
+
+	select {
+	case err = <-response:
+	case <-timeout.C:
+		err = errors.Errorf("no response within %v", HandshakeTimeout)
+	}
+
+	if err != nil {
+		_ = client.peer.close()
+		<-client.done
+
+		return nil, errors.Wrap(err, "enginerpc: could not describe runtime")
+	}
+
+	client.kind, client.version = described.Kind, described.Version
+
+	return client, nil
+}
+
+// StartProcess starts the given command (which must serve a runtime with ServeStdio)
+// and returns a Client that communicates with it over the process's stdin and stdout.
+// The process is terminated when the Client is closed.
+func StartProcess(cmd *exec.Cmd, crypto engineio.CryptoDriver) (*Client, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "enginerpc: could not start runtime process")
+	}
+
+	client, err := NewClient(processConn{stdout, stdin}, crypto)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+
+		return nil, err
+	}
+
+	client.process = cmd
+
+	return client, nil
+}
+
+// processConn joins the stdout and stdin pipes of a process into an io.ReadWriteCloser
+type processConn struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (conn processConn) Close() error {
+	err := conn.WriteCloser.Close()
+	if rerr := conn.ReadCloser.Close(); err == nil {
+		err = rerr
+	}
+
+	return err
+}
+
+// Close closes the connection to the runtime. If the runtime was started with StartProcess,
+// the process is expected to exit once its stdin is closed and is waited upon.
+// All engines, encoders and pending calls of the Client fail after it is closed.
+func (client *Client) Close() error {
+	err := client.peer.close()
+	<-client.done
+
+	if client.process != nil {
+		if werr := client.process.Wait(); err == nil {
+			err = werr
+		}
+	}
+
+	return err
+}
+
+// Err returns the error that closed the connection to the runtime (nil if it is still open)
+func (client *Client) Err() error {
+	return client.peer.Err()
+}
+
+// Kind returns the kind of engine of the remote runtime
+func (client *Client) Kind() engineio.EngineKind {
+	return client.kind
+}
+
+// Version returns the semver version string of the remote runtime
+func (client *Client) Version() string {
+	return client.version
+}
+
+// SpawnEngine implements the engineio.EngineRuntime interface for Client.
+// The returned Engine is a *RemoteEngine which should be released once it is no longer in use.
+func (client *Client) SpawnEngine(
+	fuel engineio.EngineFuel,
+	logic engineio.Logic,
+	ctx engineio.CtxDriver,
+	env engineio.EnvDriver,
+) (engineio.Engine, error) {
+	handles := client.peer.handles
+	owned := []uint64{handles.store(logic), handles.store(ctx), handles.store(env)}
+
+	spawned := new(handleParams)
+	if err := client.peer.call(context.Background(), methodSpawn, spawnParams{
+		Fuel: fuel, Logic: owned[0], Ctx: owned[1], Env: owned[2],
+	}, spawned); err != nil {
+		handles.release(owned...)
+
+		return nil, err
+	}
+
+	engine := &RemoteEngine{client: client, handle: spawned.Handle, owned: owned}
+	runtime.SetFinalizer(engine, (*RemoteEngine).finalize)
+
+	return engine, nil
+}
+
+// CompileManifest implements the engineio.EngineRuntime interface for Client.
+// The Manifest is transported to the runtime in its POLO encoded form.
+func (client *Client) CompileManifest(
+	fuel engineio.EngineFuel,
+	manifest *engineio.Manifest,
+) (*engineio.LogicDescriptor, engineio.EngineFuel, error) {
+	encoded, err := manifest.Encode(engineio.POLO)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	compiled := new(compileResult)
+	if err = client.peer.call(context.Background(), methodCompile, compileParams{fuel, encoded}, compiled); err != nil {
+		return nil, 0, err
+	}
+
+	descriptor := client.descriptor(compiled.Descriptor)
+	if compiled.Error != "" {
+		return descriptor, compiled.Fuel, errors.New(compiled.Error)
+	}
+
+	return descriptor, compiled.Fuel, nil
+}
+
+// ValidateCalldata implements the engineio.EngineRuntime interface for Client
+func (client *Client) ValidateCalldata(logic engineio.Logic, ixn engineio.IxnDriver) error {
+	handle := client.peer.handles.store(logic)
+	defer client.peer.handles.release(handle)
+
+	validated := new(errorResult)
+	if err := client.peer.call(context.Background(), methodValidateCalldata, validateParams{
+		Logic: handle, Ixn: newWireIxn(ixn),
+	}, validated); err != nil {
+		return err
+	}
+
+	if validated.Error != "" {
+		return errors.New(validated.Error)
+	}
+
+	return nil
+}
+
+// GetElementGenerator implements the engineio.EngineRuntime interface for Client.
+//
+// The generated objects hold the element data in the encoded form they are decoded from and transcode it
+// with the remote runtime when encoded into a different form. Returns false if the runtime does not support
+// the element kind or if the runtime cannot be reached.
+func (client *Client) GetElementGenerator(kind engineio.ElementKind) (engineio.ManifestElementGenerator, bool) {
+	supported := new(okResult)
+	if err := client.peer.call(context.Background(), methodHasElement, elementParams{kind}, supported); err != nil {
+		return nil, false
+	}
+
+	if !supported.Ok {
+		return nil, false
+	}
+
+	return func() engineio.ManifestElementObject {
+		return &remoteElement{client: client, kind: kind}
+	}, true
+}
+
+// GetCallEncoder implements the engineio.EngineRuntime interface for Client.
+// The returned CallEncoder is a *RemoteCallEncoder which should be released once it is no longer in use.
+func (client *Client) GetCallEncoder(callsite *engineio.Callsite, logic engineio.Logic) (engineio.CallEncoder, error) {
+	if callsite == nil {
+		return nil, errors.New("enginerpc: nil callsite")
+	}
+
+	handle := client.peer.handles.store(logic)
+
+	encoder := new(handleParams)
+	if err := client.peer.call(context.Background(), methodCallEncoder, callEncoderParams{
+		Callsite: *callsite, Logic: handle,
+	}, encoder); err != nil {
+		client.peer.handles.release(handle)
+
+		return nil, err
+	}
+
+	remote := &RemoteCallEncoder{client: client, handle: encoder.Handle, owned: []uint64{handle}}
+	runtime.SetFinalizer(remote, (*RemoteCallEncoder).finalize)
+
+	return remote, nil
+}
+
+// DecodeDependencyDriver implements the engineio.EngineRuntime interface for Client.
+//
+// The returned DependencyDriver is a local snapshot of the driver decoded by the remote runtime.
+// Queries are answered locally, while mutations are applied by the remote runtime.
+func (client *Client) DecodeDependencyDriver(
+	data []byte,
+	encoding engineio.Encoding,
+) (engineio.DependencyDriver, error) {
+	snapshot := new(wireDependency)
+	if err := client.peer.call(
+		context.Background(), methodDecodeDependency, decodeParams{data, encoding}, snapshot,
+	); err != nil {
+		return nil, err
+	}
+
+	return newRemoteDependency(client, snapshot), nil
+}
+
+// DecodeErrorResult implements the engineio.EngineRuntime interface for Client
+func (client *Client) DecodeErrorResult(data []byte) (engineio.ErrorResult, error) {
+	decoded := new(wireErrorResult)
+	if err := client.peer.call(context.Background(), methodDecodeError, dataPayload{data}, decoded); err != nil {
+		return nil, err
+	}
+
+	return *decoded, nil
+}
+
+func (client *Client) descriptor(wire *wireDescriptor) *engineio.LogicDescriptor {
+	if wire == nil {
+		return nil
+	}
+
+	descriptor := &engineio.LogicDescriptor{
+		Engine:       wire.Engine,
+		ManifestRaw:  wire.ManifestRaw,
+		ManifestHash: wire.ManifestHash,
+		Interactive:  wire.Interactive,
+		Elements:     wire.Elements,
+		CtxState:     wire.CtxState,
+		Callsites:    wire.Callsites,
+		Classdefs:    wire.Classdefs,
+	}
+
+	if wire.Dependency != nil {
+		descriptor.Dependency = newRemoteDependency(client, wire.Dependency)
+	}
+
+	return descriptor
+}
+
+// RemoteEngine is an Engine spawned by a remote runtime with the SpawnEngine method of Client.
+// The drivers it was spawned with are retained by the Client until the engine is released.
+type RemoteEngine struct {
+	client *Client
+	handle uint64
+	owned  []uint64
+	once   sync.Once
+}
+
+// Kind implements the engineio.Engine interface for RemoteEngine
+func (engine *RemoteEngine) Kind() engineio.EngineKind {
+	return engine.client.kind
+}
+
+// Call implements the engineio.Engine interface for RemoteEngine.
+// Cancelling the context aborts the call and notifies the remote runtime of the cancellation.
+func (engine *RemoteEngine) Call(
+	ctx context.Context,
+	ixn engineio.IxnDriver,
+	participants ...engineio.CtxDriver,
+) (engineio.CallResult, error) {
+	handles := make([]uint64, 0, len(participants))
+	for _, participant := range participants {
+		handles = append(handles, engine.client.peer.handles.store(participant))
+	}
+
+	defer engine.client.peer.handles.release(handles...)
+
+	result := new(wireCallResult)
+	if err := engine.client.peer.call(ctx, methodEngineCall, callParams{
+		Engine: engine.handle, Ixn: newWireIxn(ixn), Ctx: handles,
+	}, result); err != nil {
+		return nil, err
+	}
+
+	return *result, nil
+}
+
+// Release releases the engine on the remote runtime along with the drivers it was spawned with.
+// It is safe to call multiple times. If the engine is garbage collected without being released,
+// it is released without waiting for the remote runtime to acknowledge it.
+func (engine *RemoteEngine) Release() error {
+	var err error
+
+	engine.once.Do(func() {
+		engine.client.peer.handles.release(engine.owned...)
+		err = engine.client.peer.call(context.Background(), methodEngineRelease, handleParams{engine.handle}, nil)
+	})
+
+	return err
+}
+
+// finalize releases the engine when it is garbage collected. Since it runs on the finalizer goroutine,
+// it must never block on the runtime and only notifies it of the release after releasing the local handles.
+func (engine *RemoteEngine) finalize() {
+	engine.once.Do(func() {
+		engine.client.peer.handles.release(engine.owned...)
+		engine.client.peer.notifyAsync(methodEngineRelease, handleParams{engine.handle})
+	})
+}
+
+// RemoteCallEncoder is a CallEncoder obtained from a remote runtime with the GetCallEncoder method of Client.
+// The Logic it was obtained for is retained by the Client until the encoder is released.
+type RemoteCallEncoder struct {
+	client *Client
+	handle uint64
+	owned  []uint64
+	once   sync.Once
+}
+
+// EncodeInputs implements the engineio.CallEncoder interface for RemoteCallEncoder.
+// Reference values in the inputs are resolved by the remote runtime with callbacks to the ReferenceProvider.
+func (encoder *RemoteCallEncoder) EncodeInputs(inputs map[string]any, refs engineio.ReferenceProvider) ([]byte, error) {
+	values, err := encodeWireValues(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	handle := encoder.client.peer.handles.store(refs)
+	defer encoder.client.peer.handles.release(handle)
+
+	encoded := new(dataPayload)
+	if err = encoder.client.peer.call(context.Background(), methodEncodeInputs, encodeInputsParams{
+		Encoder: encoder.handle, Inputs: values, Refs: handle,
+	}, encoded); err != nil {
+		return nil, err
+	}
+
+	return encoded.Data, nil
+}
+
+// DecodeOutputs implements the engineio.CallEncoder interface for RemoteCallEncoder
+func (encoder *RemoteCallEncoder) DecodeOutputs(data []byte) (map[string]any, error) {
+	decoded := new(outputsResult)
+	if err := encoder.client.peer.call(context.Background(), methodDecodeOutputs, decodeOutputsParams{
+		Encoder: encoder.handle, Data: data,
+	}, decoded); err != nil {
+		return nil, err
+	}
+
+	return decodeWireValues(decoded.Outputs)
+}
+
+// Release releases the encoder on the remote runtime along with the Logic it was obtained for.
+// It is safe to call multiple times. If the encoder is garbage collected without being released,
+// it is released without waiting for the remote runtime to acknowledge it.
+func (encoder *RemoteCallEncoder) Release() error {
+	var err error
+
+	encoder.once.Do(func() {
+		encoder.client.peer.handles.release(encoder.owned...)
+		err = encoder.client.peer.call(context.Background(), methodEncoderRelease, handleParams{encoder.handle}, nil)
+	})
+
+	return err
+}
+
+// finalize releases the encoder when it is garbage collected. Since it runs on the finalizer goroutine,
+// it must never block on the runtime and only notifies it of the release after releasing the local handles.
+func (encoder *RemoteCallEncoder) finalize() {
+	encoder.once.Do(func() {
+		encoder.client.peer.handles.release(encoder.owned...)
+		encoder.client.peer.notifyAsync(methodEncoderRelease, handleParams{encoder.handle})
+	})
+}
+
+// remoteDependency is a DependencyDriver backed by a snapshot of a driver of the remote runtime.
+// Queries and encoding are served from the snapshot while mutations are applied by the remote runtime
+// (which returns a new snapshot). Since mutations cannot return errors, the error of a failed mutation
+// is recorded and returned when the driver is encoded, and any further mutations are discarded until
+// the driver is decoded again.
+type remoteDependency struct {
+	client   *Client
+	snapshot *wireDependency
+	vertices map[engineio.ElementPtr]dependencyVertex
+	err      error
+}
+
+func newRemoteDependency(client *Client, snapshot *wireDependency) *remoteDependency {
+	dependency := &remoteDependency{client: client}
+	dependency.load(snapshot)
+
+	return dependency
+}
+
+func (dependency *remoteDependency) load(snapshot *wireDependency) {
+	dependency.snapshot = snapshot
+	dependency.err = nil
+	dependency.vertices = make(map[engineio.ElementPtr]dependencyVertex, len(snapshot.Vertices))
+
+	for _, vertex := range snapshot.Vertices {
+		dependency.vertices[vertex.Ptr] = vertex
+	}
+}
+
+func (dependency *remoteDependency) decode(data []byte, encoding engineio.Encoding) error {
+	snapshot := new(wireDependency)
+	if err := dependency.client.peer.call(
+		context.Background(), methodDecodeDependency, decodeParams{data, encoding}, snapshot,
+	); err != nil {
+		return err
+	}
+
+	dependency.load(snapshot)
+
+	return nil
+}
+
+// apply applies a mutation to the driver with the remote runtime. If the mutation
+// fails (such as when the runtime process has crashed), the error is recorded.
+func (dependency *remoteDependency) apply(op dependencyOp) {
+	if dependency.err != nil {
+		return
+	}
+
+	snapshot := new(wireDependency)
+	if err := dependency.client.peer.call(context.Background(), methodApplyDependency, applyDependencyParams{
+		Data: dependency.snapshot.POLO, Ops: []dependencyOp{op},
+	}, snapshot); err != nil {
+		dependency.err = errors.Wrap(err, "enginerpc: could not update dependency driver")
+
+		return
+	}
+
+	dependency.load(snapshot)
+}
+
+func (dependency *remoteDependency) String() string {
+	return dependency.snapshot.String
+}
+
+func (dependency *remoteDependency) MarshalJSON() ([]byte, error) {
+	if dependency.err != nil {
+		return nil, dependency.err
+	}
+
+	return dependency.snapshot.JSON, nil
+}
+
+func (dependency *remoteDependency) UnmarshalJSON(data []byte) error {
+	return dependency.decode(data, engineio.JSON)
+}
+
+func (dependency *remoteDependency) Polorize() (*polo.Polorizer, error) {
+	if dependency.err != nil {
+		return nil, dependency.err
+	}
+
+	polorizer := polo.NewPolorizer()
+	if err := polorizer.PolorizeAny(dependency.snapshot.POLO); err != nil {
+		return nil, err
+	}
+
+	return polorizer, nil
+}
+
+func (dependency *remoteDependency) Depolorize(depolorizer *polo.Depolorizer) error {
+	data, err := depolorizer.DepolorizeAny()
+	if err != nil {
+		return err
+	}
+
+	return dependency.decode(data, engineio.POLO)
+}
+
+func (dependency *remoteDependency) Insert(ptr engineio.ElementPtr, deps ...engineio.ElementPtr) {
+	dependency.apply(dependencyOp{Ptr: ptr, Deps: deps})
+}
+
+func (dependency *remoteDependency) Remove(ptr engineio.ElementPtr) {
+	dependency.apply(dependencyOp{Remove: true, Ptr: ptr})
+}
+
+func (dependency *remoteDependency) Size() uint64 {
+	return uint64(len(dependency.snapshot.Vertices))
+}
+
+func (dependency *remoteDependency) Iter() <-chan engineio.ElementPtr {
+	iter := make(chan engineio.ElementPtr, len(dependency.snapshot.Vertices))
+	for _, vertex := range dependency.snapshot.Vertices {
+		iter <- vertex.Ptr
+	}
+
+	close(iter)
+
+	return iter
+}
+
+func (dependency *remoteDependency) Contains(ptr engineio.ElementPtr) bool {
+	_, exists := dependency.vertices[ptr]
+
+	return exists
+}
+
+func (dependency *remoteDependency) Edges(ptr engineio.ElementPtr) []engineio.ElementPtr {
+	return dependency.vertices[ptr].Edges
+}
+
+func (dependency *remoteDependency) Dependencies(ptr engineio.ElementPtr) []engineio.ElementPtr {
+	return dependency.vertices[ptr].Dependencies
+}
+
+// remoteElement is a ManifestElementObject for an element kind of a remote runtime.
+// It holds the element data in the encoded form it was decoded from, and has the
+// remote runtime transcode it when it needs to be encoded into a different form.
+type remoteElement struct {
+	client *Client
+	kind   engineio.ElementKind
+
+	encoding engineio.Encoding
+	data     []byte
+}
+
+func (element *remoteElement) transcode(encoding engineio.Encoding) ([]byte, error) {
+	if element.data == nil || element.encoding == encoding {
+		return element.data, nil
+	}
+
+	transcoded := new(dataPayload)
+	if err := element.client.peer.call(context.Background(), methodTranscodeElement, transcodeParams{
+		Kind: element.kind, From: element.encoding, To: encoding, Data: element.data,
+	}, transcoded); err != nil {
+		return nil, err
+	}
+
+	return transcoded.Data, nil
+}
+
+func (element *remoteElement) Polorize() (*polo.Polorizer, error) {
+	data, err := element.transcode(engineio.POLO)
+	if err != nil {
+		return nil, err
+	}
+
+	polorizer := polo.NewPolorizer()
+	if err = polorizer.PolorizeAny(data); err != nil {
+		return nil, err
+	}
+
+	return polorizer, nil
+}
+
+func (element *remoteElement) Depolorize(depolorizer *polo.Depolorizer) (err error) {
+	element.encoding = engineio.POLO
+	element.data, err = depolorizer.DepolorizeAny()
+
+	return err
+}
+
+func (element *remoteElement) MarshalJSON() ([]byte, error) {
+	data, err := element.transcode(engineio.JSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if data == nil {
+		return []byte("null"), nil
+	}
+
+	return data, nil
+}
+
+func (element *remoteElement) UnmarshalJSON(data []byte) error {
+	element.encoding = engineio.JSON
+	element.data = append([]byte(nil), data...)
+
+	return nil
+}
+
+func (element *remoteElement) MarshalYAML() (interface{}, error) {
+	data, err := element.transcode(engineio.YAML)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	node := new(yaml.Node)
+	if err = yaml.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+
+	// Unwrap the document node, so that the content is embedded directly
+	if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		return node.Content[0], nil
+	}
+
+	return node, nil
+}
+
+func (element *remoteElement) UnmarshalYAML(node *yaml.Node) (err error) {
+	element.encoding = engineio.YAML
+	element.data, err = yaml.Marshal(node)
+
+	return err
+}
+
+// handlers returns the handlers for the driver callbacks that are served by the host
+func (client *Client) handlers() map[string]handlerFunc {
+	handles := func() *handleTable { return client.peer.handles }
+
+	return map[string]handlerFunc{
+		methodLogicInfo: handler(func(_ context.Context, params handleParams) (any, error) {
+			logic, err := loadHandle[engineio.Logic](handles(), params.Handle)
+			if err != nil || logic == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			info := logicInfo{
+				ID:          string(logic.LogicID()),
+				Engine:      logic.Engine(),
+				Manifest:    logic.Manifest(),
+				Sealed:      logic.IsSealed(),
+				Asset:       logic.IsAssetLogic(),
+				Interactive: logic.IsInteractive(),
+			}
+
+			if ptr, ok := logic.PersistentState(); ok {
+				info.Persistent = &ptr
+			}
+
+			if ptr, ok := logic.EphemeralState(); ok {
+				info.Ephemeral = &ptr
+			}
+
+			return info, nil
+		}),
+
+		methodLogicElementDeps: handler(func(_ context.Context, params logicPtrParams) (any, error) {
+			logic, err := loadHandle[engineio.Logic](handles(), params.Logic)
+			if err != nil || logic == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			return ptrsResult{logic.GetElementDeps(params.Ptr)}, nil
+		}),
+
+		methodLogicElement: handler(func(_ context.Context, params logicPtrParams) (any, error) {
+			logic, err := loadHandle[engineio.Logic](handles(), params.Logic)
+			if err != nil || logic == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			element, _ := logic.GetElement(params.Ptr)
+
+			return elementResult{element}, nil
+		}),
+
+		methodLogicCallsite: handler(func(_ context.Context, params logicNameParams) (any, error) {
+			logic, err := loadHandle[engineio.Logic](handles(), params.Logic)
+			if err != nil || logic == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			callsite, _ := logic.GetCallsite(params.Name)
+
+			return callsiteResult{callsite}, nil
+		}),
+
+		methodLogicClassdef: handler(func(_ context.Context, params logicNameParams) (any, error) {
+			logic, err := loadHandle[engineio.Logic](handles(), params.Logic)
+			if err != nil || logic == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			classdef, _ := logic.GetClassdef(params.Name)
+
+			return classdefResult{classdef}, nil
+		}),
+
+		methodCtxInfo: handler(func(_ context.Context, params handleParams) (any, error) {
+			driver, err := loadHandle[engineio.CtxDriver](handles(), params.Handle)
+			if err != nil || driver == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			return ctxInfo{Address: driver.Address(), LogicID: string(driver.LogicID())}, nil
+		}),
+
+		methodCtxGetStorage: handler(func(_ context.Context, params storageParams) (any, error) {
+			driver, err := loadHandle[engineio.CtxDriver](handles(), params.Ctx)
+			if err != nil || driver == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			value, ok := driver.GetStorageEntry(params.Key)
+
+			return storageResult{Value: value, Ok: ok}, nil
+		}),
+
+		methodCtxSetStorage: handler(func(_ context.Context, params storageParams) (any, error) {
+			driver, err := loadHandle[engineio.CtxDriver](handles(), params.Ctx)
+			if err != nil || driver == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			return storageResult{Ok: driver.SetStorageEntry(params.Key, params.Value)}, nil
+		}),
+
+		methodEnvInfo: handler(func(_ context.Context, params handleParams) (any, error) {
+			driver, err := loadHandle[engineio.EnvDriver](handles(), params.Handle)
+			if err != nil || driver == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			return envInfo{Timestamp: driver.Timestamp(), ClusterID: driver.ClusterID()}, nil
+		}),
+
+		methodRefsGet: handler(func(_ context.Context, params refsParams) (any, error) {
+			refs, err := loadHandle[engineio.ReferenceProvider](handles(), params.Refs)
+			if err != nil || refs == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			value, ok := refs.GetReference(params.Ref)
+			if !ok {
+				return refsResult{}, nil
+			}
+
+			encoded, err := encodeWireValue(value)
+			if err != nil {
+				return nil, err
+			}
+
+			return refsResult{Value: &encoded, Ok: true}, nil
+		}),
+
+		methodCryptoValidate: handler(func(_ context.Context, params signatureParams) (any, error) {
+			if client.crypto == nil {
+				return nil, errors.New("no crypto driver available")
+			}
+
+			return signatureResult{Ok: client.crypto.ValidateSignature(params.Signature)}, nil
+		}),
+
+		methodCryptoVerify: handler(func(_ context.Context, params signatureParams) (any, error) {
+			if client.crypto == nil {
+				return nil, errors.New("no crypto driver available")
+			}
+
+			ok, err := client.crypto.VerifySignature(params.Data, params.Signature, params.PublicKey)
+			if err != nil {
+				return signatureResult{Ok: ok, Error: err.Error()}, nil
+			}
+
+			return signatureResult{Ok: ok}, nil
+		}),
+	}
+}
+
+// nonNilHandle returns the given handle lookup error or an
+// error for a nil handle, if the lookup was successful
+func nonNilHandle(err error) error {
+	if err != nil {
+		return err
+	}
+
+	return &Error{Code: CodeInvalidParams, Message: "nil handle"}
+}
@@ -0,0 +1,485 @@
+package enginerpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MaxFrameSize is the maximum size of a single protocol frame (excluding its length prefix)
+const MaxFrameSize = 64 << 20
+
+// Error codes used in the error object of a response message.
+// They follow the JSON-RPC 2.0 reserved error code ranges.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeCallFailed     = -32000
+)
+
+// Error is the error object of a response message.
+// It is returned by client operations when the remote peer responds with an error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface for Error
+func (err *Error) Error() string {
+	return err.Message
+}
+
+// ErrClosed is returned for operations on a connection that has been closed
+var ErrClosed = errors.New("enginerpc: connection closed")
+
+// message is a single protocol message. It is a request if Method is set, a notification if Method
+// is set without an ID and a response otherwise. Responses carry either a Result or an Error.
+type message struct {
+	Version string          `json:"jsonrpc"`
+	ID      *uint64         `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// handlerFunc handles an incoming request and returns a JSON encodable result
+type handlerFunc func(ctx context.Context, params json.RawMessage) (any, error)
+
+// peer is one end of a bidirectional protocol connection. Both ends can issue requests to each other
+// concurrently. Incoming requests are each handled in their own goroutine so that a handler can issue
+// requests back to the remote end (driver callbacks) without blocking the delivery of its responses.
+type peer struct {
+	reader *bufio.Reader
+	writer io.Writer
+	closer io.Closer
+
+	handlers map[string]handlerFunc
+	handles  *handleTable
+
+	wmutex sync.Mutex // serializes frame writes
+
+	mutex   sync.Mutex // guards the fields below
+	nextID  uint64
+	pending map[uint64]chan *message
+	cancels map[uint64]context.CancelFunc
+	closed  bool
+	err     error
+
+	failures uint64 // the number of recorded callback failures
+	failure  error  // the last recorded callback failure
+
+	done chan struct{}
+}
+
+func newPeer(conn io.ReadWriteCloser, handlers map[string]handlerFunc) *peer {
+	return &peer{
+		reader:   bufio.NewReader(conn),
+		writer:   conn,
+		closer:   conn,
+		handlers: handlers,
+		handles:  newHandleTable(),
+		pending:  make(map[uint64]chan *message),
+		cancels:  make(map[uint64]context.CancelFunc),
+		done:     make(chan struct{}),
+	}
+}
+
+// run reads and dispatches incoming messages until the connection fails or is closed.
+// It returns the error that terminated the connection (nil if it was closed locally).
+func (p *peer) run() error {
+	for {
+		msg, err := p.readMessage()
+		if err != nil {
+			p.shutdown(err)
+
+			return p.Err()
+		}
+
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			go p.handleRequest(*msg)
+		case msg.Method != "":
+			p.handleNotification(*msg)
+		case msg.ID != nil:
+			p.mutex.Lock()
+			response, ok := p.pending[*msg.ID]
+			delete(p.pending, *msg.ID)
+			p.mutex.Unlock()
+
+			if ok {
+				response <- msg
+			}
+		}
+	}
+}
+
+// call issues a request to the remote peer and decodes its result into the given object (if not nil).
+// If the context is cancelled before a response is received, a cancellation notice is sent to the peer.
+func (p *peer) call(ctx context.Context, method string, params, result any) error {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return errors.Wrapf(err, "enginerpc: could not encode %v params", method)
+	}
+
+	response := make(chan *message, 1)
+
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+
+		return p.Err()
+	}
+
+	p.nextID++
+	id := p.nextID
+	p.pending[id] = response
+	p.mutex.Unlock()
+
+	if err = p.writeMessage(&message{ID: &id, Method: method, Params: encoded}); err != nil {
+		p.forget(id)
+
+		return err
+	}
+
+	select {
+	case msg := <-response:
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		if result == nil {
+			return nil
+		}
+
+		if err = json.Unmarshal(msg.Result, result); err != nil {
+			return errors.Wrapf(err, "enginerpc: could not decode %v result", method)
+		}
+
+		return nil
+
+	case <-ctx.Done():
+		p.forget(id)
+		_ = p.notify(methodCancel, cancelParams{ID: id})
+
+		return ctx.Err()
+
+	case <-p.done:
+		return p.Err()
+	}
+}
+
+// notify sends a notification (a request without an ID that expects no response) to the remote peer
+func (p *peer) notify(method string, params any) error {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return errors.Wrapf(err, "enginerpc: could not encode %v params", method)
+	}
+
+	return p.writeMessage(&message{Method: method, Params: encoded})
+}
+
+// notifyAsync sends a notification to the remote peer from its own goroutine, without
+// waiting for it to be written. Failures are ignored, since no response is expected.
+func (p *peer) notifyAsync(method string, params any) {
+	go func() { _ = p.notify(method, params) }()
+}
+
+func (p *peer) forget(id uint64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.pending, id)
+}
+
+func (p *peer) handleRequest(msg message) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p.mutex.Lock()
+	p.cancels[*msg.ID] = cancel
+	p.mutex.Unlock()
+
+	defer func() {
+		p.mutex.Lock()
+		delete(p.cancels, *msg.ID)
+		p.mutex.Unlock()
+	}()
+
+	response := &message{ID: msg.ID}
+
+	result, err := p.dispatch(ctx, msg.Method, msg.Params)
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeCallFailed, Message: err.Error()}
+		}
+
+		response.Error = rpcErr
+	} else if response.Result, err = json.Marshal(result); err != nil {
+		response.Error = &Error{Code: CodeInternalError, Message: "could not encode result: " + err.Error()}
+	}
+
+	_ = p.writeMessage(response)
+}
+
+// dispatch invokes the handler for a method, converting any panics within the handler into errors.
+// If a callback failure is recorded while the handler runs (see recordFailure), the handler fails with it,
+// since the driver proxies that issue callbacks return zero values when they fail.
+func (p *peer) dispatch(ctx context.Context, method string, params json.RawMessage) (result any, err error) {
+	handler, ok := p.handlers[method]
+	if !ok {
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %v", method)}
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = &Error{Code: CodeInternalError, Message: fmt.Sprintf("%v handler panicked: %v", method, recovered)}
+		}
+	}()
+
+	failures, _ := p.failed()
+
+	result, err = handler(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if count, failure := p.failed(); count != failures {
+		return nil, failure
+	}
+
+	return result, nil
+}
+
+// recordFailure records the failure of a callback to the remote peer that could not be returned to
+// its caller (such as from a driver method without an error result), so that it is not silently lost.
+// The failure is reported by any request that is being served when it is recorded.
+func (p *peer) recordFailure(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.failures++
+	p.failure = err
+}
+
+// failed returns the number of recorded callback failures and the last recorded failure
+func (p *peer) failed() (uint64, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.failures, p.failure
+}
+
+// handleNotification handles an incoming notification. Cancellation notices are handled immediately, while
+// other notifications are dispatched to the handler for their method in their own goroutine (like requests),
+// and the result of the handler is discarded.
+func (p *peer) handleNotification(msg message) {
+	if msg.Method != methodCancel {
+		go func() { _, _ = p.dispatch(context.Background(), msg.Method, msg.Params) }()
+
+		return
+	}
+
+	params := new(cancelParams)
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		return
+	}
+
+	p.mutex.Lock()
+	cancel, ok := p.cancels[params.ID]
+	p.mutex.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// readMessage reads the next length-prefixed frame from the connection and decodes it
+func (p *peer) readMessage() (*message, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(p.reader, prefix[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(prefix[:])
+	if size > MaxFrameSize {
+		return nil, errors.Errorf("enginerpc: frame size %v exceeds limit", size)
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(p.reader, frame); err != nil {
+		return nil, err
+	}
+
+	msg := new(message)
+	if err := json.Unmarshal(frame, msg); err != nil {
+		return nil, errors.Wrap(err, "enginerpc: malformed frame")
+	}
+
+	return msg, nil
+}
+
+// writeMessage encodes a message and writes it to the connection as a length-prefixed frame
+func (p *peer) writeMessage(msg *message) error {
+	msg.Version = "2.0"
+
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "enginerpc: could not encode message")
+	}
+
+	if len(encoded) > MaxFrameSize {
+		return errors.Errorf("enginerpc: frame size %v exceeds limit", len(encoded))
+	}
+
+	frame := make([]byte, 4+len(encoded))
+	binary.BigEndian.PutUint32(frame, uint32(len(encoded)))
+	copy(frame[4:], encoded)
+
+	p.wmutex.Lock()
+	defer p.wmutex.Unlock()
+
+	if _, err = p.writer.Write(frame); err != nil {
+		p.shutdown(err)
+
+		return p.Err()
+	}
+
+	return nil
+}
+
+// close closes the underlying connection and fails all pending requests
+func (p *peer) close() error {
+	p.shutdown(ErrClosed)
+
+	return p.closer.Close()
+}
+
+func (p *peer) shutdown(cause error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	if errors.Is(cause, io.EOF) {
+		cause = ErrClosed
+	}
+
+	p.closed, p.err = true, cause
+	p.pending = make(map[uint64]chan *message)
+
+	close(p.done)
+}
+
+// Err returns the error that closed the connection (nil if it is still open)
+func (p *peer) Err() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.err
+}
+
+// handleTable is a table of local objects that are referenced by the remote peer with numeric handles.
+// A zero handle is reserved for nil objects and is never allocated.
+type handleTable struct {
+	mutex   sync.Mutex
+	nextID  uint64
+	objects map[uint64]any
+}
+
+func newHandleTable() *handleTable {
+	return &handleTable{objects: make(map[uint64]any)}
+}
+
+// store adds an object to the table and returns its handle. Nil objects are not stored and return 0.
+func (table *handleTable) store(object any) uint64 {
+	if isNil(object) {
+		return 0
+	}
+
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	table.nextID++
+	table.objects[table.nextID] = object
+
+	return table.nextID
+}
+
+// load retrieves an object from the table.
+// Returns an error if the handle is unknown.
+func (table *handleTable) load(handle uint64) (any, error) {
+	if handle == 0 {
+		return nil, nil
+	}
+
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	object, ok := table.objects[handle]
+	if !ok {
+		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown handle: %v", handle)}
+	}
+
+	return object, nil
+}
+
+// release removes the objects with the given handles from the table
+func (table *handleTable) release(handles ...uint64) {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	for _, handle := range handles {
+		delete(table.objects, handle)
+	}
+}
+
+// size returns the number of objects in the table
+func (table *handleTable) size() int {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	return len(table.objects)
+}
+
+// handler returns a handlerFunc that decodes the request params into P before invoking fn
+func handler[P any](fn func(context.Context, P) (any, error)) handlerFunc {
+	return func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params := new(P)
+		if err := json.Unmarshal(raw, params); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: "malformed params: " + err.Error()}
+		}
+
+		return fn(ctx, *params)
+	}
+}
+
+// loadHandle retrieves an object of type T from the handle table.
+// Returns the zero value of T for a zero handle.
+func loadHandle[T any](table *handleTable, handle uint64) (T, error) {
+	var zero T
+
+	object, err := table.load(handle)
+	if err != nil || object == nil {
+		return zero, err
+	}
+
+	typed, ok := object.(T)
+	if !ok {
+		return zero, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("handle %v has unexpected type %T", handle, object)}
+	}
+
+	return typed, nil
+}
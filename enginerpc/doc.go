@@ -0,0 +1,63 @@
+// Package enginerpc implements an out-of-process adapter for engine runtimes. It allows an EngineRuntime to
+// be served from a separate process (for crash isolation or to host runtimes not implemented in Go) while
+// the host uses it like any other in-process runtime.
+//
+// The runtime process serves its EngineRuntime with Serve (or ServeStdio), and the host connects to it with
+// a Client (created with NewClient or StartProcess), which implements the engineio.EngineRuntime interface.
+// The served engines obtain a CryptoDriver that proxies to the host with CryptoDriverFromContext.
+//
+// # Protocol
+//
+// The protocol is a bidirectional variant of JSON-RPC 2.0 over a byte stream (such as the stdin and stdout
+// of the runtime process). Each message is a frame made up of a 4-byte big-endian unsigned length prefix
+// followed by that many bytes of a JSON encoded JSON-RPC 2.0 request, notification or response object.
+// Frames may not exceed MaxFrameSize bytes. Binary data ([]byte values) is encoded as base64 strings.
+//
+// Both peers may issue requests at any time and must be able to serve incoming requests while waiting for
+// the responses to their own. This allows the runtime to call back into the drivers of the host while serving
+// a request from it. Requests may be cancelled with a "$/cancel" notification whose params are {"id": <id>}.
+// Errors are reported with standard JSON-RPC error objects, with application errors using the code -32000.
+//
+// Objects that cannot be copied across processes (engines, call encoders and drivers) are referenced by
+// numeric handles allocated by the peer that owns the object. The handle 0 represents a nil object.
+//
+// The following methods are served by the runtime process:
+//
+//	runtime.describe           {}                                  -> {kind, version}
+//	runtime.spawn              {fuel, logic, ctx, env}             -> {handle}
+//	runtime.compile            {fuel, manifest}                    -> {descriptor?, fuel, error?}
+//	runtime.validate_calldata  {logic, ixn}                        -> {error?}
+//	runtime.has_element        {kind}                              -> {ok}
+//	runtime.transcode_element  {kind, from, to, data}              -> {data}
+//	runtime.call_encoder       {callsite, logic}                   -> {handle}
+//	runtime.decode_dependency  {data, encoding}                    -> dependency snapshot
+//	runtime.apply_dependency   {data, ops}                         -> dependency snapshot
+//	runtime.decode_error       {data}                              -> {engine, string, bytes, reverted}
+//	engine.call                {engine, ixn, ctx: [handles]}       -> {ok, fuel, outputs, error}
+//	engine.release             {handle}                            -> {}
+//	encoder.encode_inputs      {encoder, inputs, refs}             -> {data}
+//	encoder.decode_outputs     {encoder, data}                     -> {outputs}
+//	encoder.release            {handle}                            -> {}
+//
+// The following driver callbacks are served by the host process:
+//
+//	logic.info                 {handle}                            -> {id, engine, manifest, sealed, asset, ...}
+//	logic.element_deps         {logic, ptr}                        -> {ptrs}
+//	logic.element              {logic, ptr}                        -> {element?}
+//	logic.callsite             {logic, name}                       -> {callsite?}
+//	logic.classdef             {logic, name}                       -> {classdef?}
+//	ctx.info                   {handle}                            -> {address, logic_id}
+//	ctx.get_storage            {ctx, key}                          -> {value, ok}
+//	ctx.set_storage            {ctx, key, value}                   -> {ok}
+//	env.info                   {handle}                            -> {timestamp, cluster_id}
+//	refs.get                   {refs, ref}                         -> {value?, ok}
+//	crypto.validate_signature  {signature}                         -> {ok}
+//	crypto.verify_signature    {data, signature, public_key}       -> {ok, error?}
+//
+// Manifests are transported in their POLO encoded form, and manifest element data is transcoded between
+// encodings by the runtime with runtime.transcode_element. Interaction drivers, call results and error
+// results are immutable and are copied rather than referenced. Values accepted and returned by call
+// encoders are transported as type tagged values to preserve their Go types (see the wireValue type).
+// Dependency drivers are transported as snapshots that contain their encoded forms along with the edges
+// and aggregated dependencies of each element pointer, and are updated with runtime.apply_dependency.
+package enginerpc
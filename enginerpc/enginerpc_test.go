@@ -0,0 +1,822 @@
+package enginerpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-moi-identifiers"
+	"github.com/sarvalabs/go-polo"
+	"github.com/stretchr/testify/require"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+	"github.com/sarvalabs/go-moi-engineio/engineiotest"
+)
+
+const testKind = engineio.EngineKind("REMOTE")
+
+func TestMain(m *testing.M) {
+	// When re-executed by TestStartProcess, the test binary serves the test runtime over stdio
+	if os.Getenv("ENGINERPC_TEST_SERVE") == "1" {
+		if err := ServeStdio(testRuntime{}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// connect serves the test runtime over an in-memory connection and returns a Client for it
+func connect(t *testing.T, crypto engineio.CryptoDriver) *Client {
+	t.Helper()
+
+	hostConn, runtimeConn := net.Pipe()
+	served := make(chan error, 1)
+
+	go func() { served <- Serve(runtimeConn, testRuntime{}) }()
+
+	client, err := NewClient(hostConn, crypto)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+		require.NoError(t, <-served)
+	})
+
+	return client
+}
+
+func TestClient_Describe(t *testing.T) {
+	client := connect(t, nil)
+
+	require.Equal(t, testKind, client.Kind())
+	require.Equal(t, "1.2.3", client.Version())
+	require.NoError(t, client.Err())
+}
+
+func TestClient_Manifest(t *testing.T) {
+	client := connect(t, nil)
+
+	registry := engineio.NewRegistry()
+	registry.Register(client, nil)
+
+	_, ok := client.GetElementGenerator("unknown")
+	require.False(t, ok)
+
+	// the same manifest encoded with the concrete element type of the runtime
+	local := engineio.NewRegistry()
+	local.Register(testRuntime{}, nil)
+
+	manifest := engineio.Manifest{
+		Syntax: "0.1.0",
+		Engine: engineio.ManifestEngine{Kind: "REMOTE", Flags: []string{}},
+		Elements: []engineio.ManifestElement{
+			{Ptr: 0, Deps: []engineio.ElementPtr{}, Kind: "value", Data: &testElement{Value: 10}},
+			{Ptr: 1, Deps: []engineio.ElementPtr{0}, Kind: "value", Data: &testElement{Value: 20}},
+		},
+	}
+
+	expected, err := manifest.Encode(engineio.POLO)
+	require.NoError(t, err)
+
+	for _, encoding := range []engineio.Encoding{engineio.JSON, engineio.YAML, engineio.POLO} {
+		encoded, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		decoded, err := registry.NewManifest(encoded, encoding)
+		require.NoError(t, err)
+
+		// the elements are transcoded by the runtime when re-encoded into other forms
+		for _, target := range []engineio.Encoding{engineio.JSON, engineio.YAML, engineio.POLO} {
+			reencoded, err := decoded.Encode(target)
+			require.NoError(t, err)
+
+			roundtrip, err := local.NewManifest(reencoded, target)
+			require.NoError(t, err)
+			require.Equal(t, manifest, *roundtrip)
+		}
+
+		hash, err := decoded.Hash()
+		require.NoError(t, err)
+
+		expectedHash, err := manifest.Hash()
+		require.NoError(t, err)
+		require.Equal(t, expectedHash, hash)
+	}
+
+	decoded, err := registry.NewManifest(expected, engineio.POLO)
+	require.NoError(t, err)
+
+	descriptor, fuel, err := client.CompileManifest(100, decoded)
+	require.NoError(t, err)
+	require.Equal(t, engineio.EngineFuel(2), fuel)
+	require.Equal(t, testKind, descriptor.Engine)
+	require.Equal(t, expected, descriptor.ManifestRaw)
+	require.Equal(t, &engineio.Callsite{Ptr: 1, Kind: engineio.InvokableCallsite}, descriptor.Callsites["run"])
+	require.Equal(t, []byte{20}, descriptor.Elements[1].Data)
+
+	// the dependency driver is queried locally and mutated remotely
+	dependency := descriptor.Dependency
+	require.Equal(t, uint64(2), dependency.Size())
+	require.True(t, dependency.Contains(1))
+	require.Equal(t, []engineio.ElementPtr{0}, dependency.Edges(1))
+
+	dependency.Insert(2, 1)
+	require.Equal(t, uint64(3), dependency.Size())
+	require.Equal(t, []engineio.ElementPtr{0, 1}, dependency.Dependencies(2))
+	require.Equal(t, "graphmap[0:[] 1:[0] 2:[1]]", dependency.String())
+
+	dependency.Remove(0)
+	require.False(t, dependency.Contains(0))
+
+	encoded, err := dependency.MarshalJSON()
+	require.NoError(t, err)
+
+	decodedDependency, err := client.DecodeDependencyDriver(encoded, engineio.JSON)
+	require.NoError(t, err)
+	require.Equal(t, dependency.String(), decodedDependency.String())
+
+	// compile errors are returned with the fuel consumed
+	decoded.Elements = nil
+
+	_, fuel, err = client.CompileManifest(100, decoded)
+	require.EqualError(t, err, "manifest has no elements")
+	require.Equal(t, engineio.EngineFuel(1), fuel)
+
+	_, _, err = client.CompileManifest(0, decoded)
+	require.EqualError(t, err, "insufficient fuel")
+}
+
+func TestClient_Engine(t *testing.T) {
+	client := connect(t, nil)
+
+	logic := newTestLogic()
+	ctx := engineiotest.NewCtxDriver(identifiers.NilAddress, logic.LogicID())
+	env := engineiotest.NewEnvDriver(1000, "cluster")
+
+	_, err := client.SpawnEngine(100, logic, engineiotest.NewCtxDriver(identifiers.NilAddress, "0b"), env)
+	require.EqualError(t, err, "logic and ctx driver mismatch")
+	require.Equal(t, 0, client.peer.handles.size(), "handles must be released after a failed spawn")
+
+	engine, err := client.SpawnEngine(100, logic, ctx, env)
+	require.NoError(t, err)
+	require.Equal(t, testKind, engine.Kind())
+
+	participant := engineiotest.NewCtxDriver(identifiers.NilAddress, logic.LogicID())
+
+	for i := 1; i <= 3; i++ {
+		result, err := engine.Call(context.Background(), newTestIxn("run", nil), participant)
+		require.NoError(t, err)
+		require.True(t, result.Ok())
+		require.Nil(t, result.Error())
+		require.Equal(t, engineio.EngineFuel(10), result.Fuel())
+		require.Equal(t, []byte{byte(i), 0xe8}, result.Outputs())
+	}
+
+	require.Equal(t, map[string][]byte{"counter": {3}}, ctx.Entries())
+	require.Equal(t, map[string][]byte{"participant": []byte("visited")}, participant.Entries())
+
+	// failed calls return an error result that can be decoded by the runtime
+	result, err := engine.Call(context.Background(), newTestIxn("fail", nil))
+	require.NoError(t, err)
+	require.False(t, result.Ok())
+
+	decoded, err := client.DecodeErrorResult(result.Error())
+	require.NoError(t, err)
+	require.Equal(t, testKind, decoded.Engine())
+	require.Equal(t, "failed: fail", decoded.String())
+	require.True(t, decoded.Reverted())
+
+	// callback failures (such as a panicking driver) are returned as call errors
+	_, err = engine.Call(context.Background(), newTestIxn("unknown", nil))
+	require.ErrorContains(t, err, "unknown callsite")
+
+	// cancellation of a call propagates to the runtime
+	cancelled, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = engine.Call(cancelled, newTestIxn("block", nil))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, engine.(*RemoteEngine).Release()) //nolint:forcetypeassert
+	require.NoError(t, engine.(*RemoteEngine).Release()) //nolint:forcetypeassert
+	require.Equal(t, 0, client.peer.handles.size(), "handles must be released with the engine")
+
+	_, err = engine.Call(context.Background(), newTestIxn("run", nil))
+	require.ErrorContains(t, err, "unknown handle")
+}
+
+func TestClient_ValidateCalldata(t *testing.T) {
+	client := connect(t, nil)
+	logic := newTestLogic()
+
+	require.NoError(t, client.ValidateCalldata(logic, newTestIxn("run", nil)))
+	require.EqualError(t, client.ValidateCalldata(logic, newTestIxn("walk", nil)), "callsite 'walk' not found")
+}
+
+func TestClient_CallEncoder(t *testing.T) {
+	client := connect(t, nil)
+
+	encoder, err := client.GetCallEncoder(&engineio.Callsite{Ptr: 1}, newTestLogic())
+	require.NoError(t, err)
+
+	inputs := map[string]any{
+		"amount": uint64(300),
+		"name":   "moi",
+		"ref":    engineio.ReferenceVal("target"),
+	}
+
+	expected, err := engineio.EncodeValues(map[string]any{
+		"amount": uint64(300),
+		"name":   "moi",
+		"ref":    []byte{1, 2, 3},
+	}, nil)
+	require.NoError(t, err)
+
+	encoded, err := encoder.EncodeInputs(inputs, engineiotest.References{"target": []byte{1, 2, 3}})
+	require.NoError(t, err)
+	require.Equal(t, expected, encoded)
+
+	_, err = encoder.EncodeInputs(inputs, engineiotest.References{})
+	require.ErrorContains(t, err, "unable to resolve reference 'ref<target>'")
+
+	outputs, err := encoder.DecodeOutputs([]byte{1, 2})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"raw":   []byte{1, 2},
+		"size":  int64(2),
+		"big":   big.NewInt(1 << 40),
+		"pairs": map[string]any{"a": uint64(1)},
+		"list":  []any{"x", nil},
+	}, outputs)
+
+	require.Equal(t, 1, client.peer.handles.size())
+	require.NoError(t, encoder.(*RemoteCallEncoder).Release()) //nolint:forcetypeassert
+	require.Equal(t, 0, client.peer.handles.size())
+}
+
+func TestClient_Crypto(t *testing.T) {
+	client := connect(t, engineiotest.NewCryptoDriver())
+
+	engine, err := client.SpawnEngine(100, newTestLogic(), nil, engineiotest.NewEnvDriver(0, ""))
+	require.NoError(t, err)
+
+	// the calldata of the verify callsite is the public key and signature followed by the message
+	signer := engineiotest.NewSigner("alice")
+	calldata := append(append(signer.PublicKey(), signer.Sign([]byte("signed"))...), "signed"...)
+
+	result, err := engine.Call(context.Background(), newTestIxn("verify", calldata))
+	require.NoError(t, err)
+	require.True(t, result.Ok())
+
+	calldata = append(calldata[:96], "forged"...)
+
+	result, err = engine.Call(context.Background(), newTestIxn("verify", calldata))
+	require.NoError(t, err)
+	require.False(t, result.Ok())
+}
+
+func TestServe_RegisterDefault(t *testing.T) {
+	// runtimes are not registered with the default registry unless opted in
+	client := connect(t, nil)
+	require.Equal(t, testKind, client.Kind())
+
+	_, ok := engineio.FetchEngineRuntime(testKind)
+	require.False(t, ok)
+
+	serve := func() (*Client, chan error) {
+		hostConn, runtimeConn := net.Pipe()
+		served := make(chan error, 1)
+
+		go func() { served <- Serve(runtimeConn, testRuntime{}, RegisterDefault()) }()
+
+		client, err := NewClient(hostConn, engineiotest.NewCryptoDriver())
+		require.NoError(t, err)
+
+		return client, served
+	}
+
+	first, firstServed := serve()
+	second, secondServed := serve()
+
+	_, ok = engineio.FetchEngineRuntime(testKind)
+	require.True(t, ok)
+
+	// the registration is shared by the connections and released with the last one
+	require.NoError(t, first.Close())
+	require.NoError(t, <-firstServed)
+
+	crypto, ok := engineio.FetchCryptoDriver(testKind)
+	require.True(t, ok)
+
+	signer := engineiotest.NewSigner("alice")
+	verified, err := crypto.VerifySignature([]byte("signed"), signer.Sign([]byte("signed")), signer.PublicKey())
+	require.NoError(t, err)
+	require.True(t, verified)
+
+	require.NoError(t, second.Close())
+	require.NoError(t, <-secondServed)
+
+	_, ok = engineio.FetchEngineRuntime(testKind)
+	require.False(t, ok)
+
+	// runtimes registered with the default registry by other means are not replaced
+	require.NoError(t, engineio.RegisterRuntime(testRuntime{}, engineiotest.NewCryptoDriver()))
+	t.Cleanup(func() { engineio.UnregisterRuntime(testKind) })
+
+	hostConn, runtimeConn := net.Pipe()
+	defer hostConn.Close()
+
+	err = Serve(runtimeConn, testRuntime{}, RegisterDefault())
+	require.EqualError(t, err, "enginerpc: runtime REMOTE@1.2.3 is already registered with the default registry")
+}
+
+func TestClient_Closed(t *testing.T) {
+	hostConn, runtimeConn := net.Pipe()
+
+	go func() { _ = Serve(runtimeConn, testRuntime{}) }()
+
+	client, err := NewClient(hostConn, nil)
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+
+	require.ErrorIs(t, client.Err(), ErrClosed)
+
+	_, err = client.SpawnEngine(100, newTestLogic(), nil, engineiotest.NewEnvDriver(0, ""))
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+func TestNewClient_Timeout(t *testing.T) {
+	previous := HandshakeTimeout
+	HandshakeTimeout = 50 * time.Millisecond
+
+	t.Cleanup(func() { HandshakeTimeout = previous })
+
+	// the runtime end of the connection never reads or responds
+	hostConn, runtimeConn := net.Pipe()
+	defer runtimeConn.Close()
+
+	_, err := NewClient(hostConn, nil)
+	require.EqualError(t, err, "enginerpc: could not describe runtime: no response within 50ms")
+}
+
+func TestClient_DependencyFailure(t *testing.T) {
+	hostConn, runtimeConn := net.Pipe()
+
+	go func() { _ = Serve(runtimeConn, testRuntime{}) }()
+
+	client, err := NewClient(hostConn, nil)
+	require.NoError(t, err)
+
+	dependency, err := client.DecodeDependencyDriver([]byte(`{"0":[]}`), engineio.JSON)
+	require.NoError(t, err)
+
+	// mutations after the runtime is unreachable do not panic in the host,
+	// but their error is returned when the driver is encoded
+	require.NoError(t, client.Close())
+	require.NotPanics(t, func() { dependency.Insert(1, 0) })
+	require.NotPanics(t, func() { dependency.Remove(0) })
+
+	require.True(t, dependency.Contains(0))
+	require.False(t, dependency.Contains(1))
+
+	_, err = dependency.MarshalJSON()
+	require.ErrorIs(t, err, ErrClosed)
+	require.ErrorContains(t, err, "enginerpc: could not update dependency driver")
+
+	_, err = polo.Polorize(dependency)
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+func TestClient_Finalize(t *testing.T) {
+	client := connect(t, nil)
+
+	logic := newTestLogic()
+	ctx := engineiotest.NewCtxDriver(identifiers.NilAddress, logic.LogicID())
+
+	spawned, err := client.SpawnEngine(100, logic, ctx, engineiotest.NewEnvDriver(0, ""))
+	require.NoError(t, err)
+
+	// finalizing releases the local handles immediately and the remote engine asynchronously
+	engine := spawned.(*RemoteEngine) //nolint:forcetypeassert
+	engine.finalize()
+	require.Equal(t, 0, client.peer.handles.size())
+
+	require.Eventually(t, func() bool {
+		_, err := engine.Call(context.Background(), newTestIxn("run", nil))
+
+		return err != nil && strings.Contains(err.Error(), "unknown handle")
+	}, time.Second, 10*time.Millisecond)
+
+	// the engine is already released
+	require.NoError(t, engine.Release())
+}
+
+func TestStartProcess(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^$") //nolint:gosec
+	cmd.Env = append(os.Environ(), "ENGINERPC_TEST_SERVE=1")
+	cmd.Stderr = os.Stderr
+
+	client, err := StartProcess(cmd, nil)
+	require.NoError(t, err)
+	require.Equal(t, testKind, client.Kind())
+
+	logic := newTestLogic()
+	ctx := engineiotest.NewCtxDriver(identifiers.NilAddress, logic.LogicID())
+
+	engine, err := client.SpawnEngine(100, logic, ctx, engineiotest.NewEnvDriver(0, ""))
+	require.NoError(t, err)
+
+	result, err := engine.Call(context.Background(), newTestIxn("run", nil))
+	require.NoError(t, err)
+	require.True(t, result.Ok())
+
+	require.NoError(t, client.Close())
+}
+
+func TestWireValue(t *testing.T) {
+	values := map[string]any{
+		"nil":    nil,
+		"bool":   true,
+		"string": "hello",
+		"bytes":  []byte{1, 2},
+		"int":    int64(-5),
+		"uint":   uint64(1 << 63),
+		"f32":    float32(1.5),
+		"f64":    2.5,
+		"big":    new(big.Int).Lsh(big.NewInt(1), 100),
+		"ref":    engineio.ReferenceVal("r"),
+		"object": map[string]any{"nested": []any{uint64(1), "two"}},
+		"map":    map[any]any{int64(1): "one", "two": uint64(2)},
+	}
+
+	encoded, err := encodeWireValues(values)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(encoded)
+	require.NoError(t, err)
+
+	var wire map[string]wireValue
+	require.NoError(t, json.Unmarshal(raw, &wire))
+
+	decoded, err := decodeWireValues(wire)
+	require.NoError(t, err)
+	require.Equal(t, values, decoded)
+
+	// typed values are converted into their generic forms
+	typed, err := encodeWireValue(map[string][]uint32{"a": {1, 2}})
+	require.NoError(t, err)
+
+	generic, err := decodeWireValue(typed)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"a": []any{uint64(1), uint64(2)}}, generic)
+
+	_, err = encodeWireValue(struct{}{})
+	require.EqualError(t, err, "unsupported value type: struct {}")
+}
+
+// testRuntime is an EngineRuntime that exercises the driver callbacks
+type testRuntime struct{}
+
+func (testRuntime) Kind() engineio.EngineKind { return testKind }
+func (testRuntime) Version() string           { return "1.2.3" }
+
+func (testRuntime) SpawnEngine(
+	fuel engineio.EngineFuel,
+	logic engineio.Logic,
+	ctx engineio.CtxDriver,
+	env engineio.EnvDriver,
+) (engineio.Engine, error) {
+	if ctx != nil && ctx.LogicID() != logic.LogicID() {
+		return nil, errors.New("logic and ctx driver mismatch")
+	}
+
+	return &testEngine{logic: logic, ctx: ctx, env: env}, nil
+}
+
+func (testRuntime) CompileManifest(
+	fuel engineio.EngineFuel,
+	manifest *engineio.Manifest,
+) (*engineio.LogicDescriptor, engineio.EngineFuel, error) {
+	if fuel == 0 {
+		return nil, 0, errors.New("insufficient fuel")
+	}
+
+	if len(manifest.Elements) == 0 {
+		return nil, 1, errors.New("manifest has no elements")
+	}
+
+	raw, err := manifest.Encode(engineio.POLO)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	descriptor := &engineio.LogicDescriptor{
+		Engine:      testKind,
+		ManifestRaw: raw,
+		Dependency:  testGraph{},
+		Elements:    make(engineio.LogicElementTable),
+		CtxState:    engineio.ContextStateMatrix{engineio.PersistentState: 0},
+		Callsites:   map[string]*engineio.Callsite{"run": {Ptr: 1, Kind: engineio.InvokableCallsite}},
+		Classdefs:   map[string]*engineio.Classdef{},
+	}
+
+	for _, element := range manifest.Elements {
+		value, _ := element.Data.(*testElement)
+
+		descriptor.Dependency.Insert(element.Ptr, element.Deps...)
+		descriptor.Elements[element.Ptr] = &engineio.LogicElement{
+			Kind: element.Kind,
+			Deps: element.Deps,
+			Data: []byte{byte(value.Value)},
+		}
+	}
+
+	return descriptor, engineio.EngineFuel(len(manifest.Elements)), nil
+}
+
+func (testRuntime) ValidateCalldata(logic engineio.Logic, ixn engineio.IxnDriver) error {
+	if _, ok := logic.GetCallsite(ixn.Callsite()); !ok {
+		return errors.Errorf("callsite '%v' not found", ixn.Callsite())
+	}
+
+	return nil
+}
+
+func (testRuntime) GetElementGenerator(kind engineio.ElementKind) (engineio.ManifestElementGenerator, bool) {
+	if kind != "value" {
+		return nil, false
+	}
+
+	return func() engineio.ManifestElementObject { return new(testElement) }, true
+}
+
+func (testRuntime) GetCallEncoder(*engineio.Callsite, engineio.Logic) (engineio.CallEncoder, error) {
+	return testEncoder{}, nil
+}
+
+func (testRuntime) DecodeDependencyDriver(data []byte, encoding engineio.Encoding) (engineio.DependencyDriver, error) {
+	graph := testGraph{}
+
+	switch encoding {
+	case engineio.POLO:
+		depolorizer, err := polo.NewDepolorizer(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return graph, graph.Depolorize(depolorizer)
+	case engineio.JSON:
+		return graph, graph.UnmarshalJSON(data)
+	default:
+		return nil, errors.New("unsupported encoding")
+	}
+}
+
+func (testRuntime) DecodeErrorResult(data []byte) (engineio.ErrorResult, error) {
+	return wireErrorResult{Kind: testKind, Message: "failed: " + string(data), Raw: data, Revert: true}, nil
+}
+
+type testEngine struct {
+	logic engineio.Logic
+	ctx   engineio.CtxDriver
+	env   engineio.EnvDriver
+}
+
+func (engine *testEngine) Kind() engineio.EngineKind { return testKind }
+
+func (engine *testEngine) Call(
+	ctx context.Context,
+	ixn engineio.IxnDriver,
+	participants ...engineio.CtxDriver,
+) (engineio.CallResult, error) {
+	switch ixn.Callsite() {
+	case "run":
+		counter, _ := engine.ctx.GetStorageEntry([]byte("counter"))
+		next := []byte{1}
+
+		if len(counter) == 1 {
+			next[0] = counter[0] + 1
+		}
+
+		engine.ctx.SetStorageEntry([]byte("counter"), next)
+
+		for _, participant := range participants {
+			participant.SetStorageEntry([]byte("participant"), []byte("visited"))
+		}
+
+		return wireCallResult{Success: true, Spent: 10, Output: []byte{next[0], byte(engine.env.Timestamp())}}, nil
+
+	case "fail":
+		return wireCallResult{Spent: 5, Failure: []byte("fail")}, nil
+
+	case "verify":
+		crypto, _ := CryptoDriverFromContext(ctx)
+
+		calldata := ixn.Calldata()
+		if len(calldata) < 96 {
+			return wireCallResult{Spent: 1, Failure: []byte("malformed calldata")}, nil
+		}
+
+		ok, err := crypto.VerifySignature(calldata[96:], calldata[32:96], calldata[:32])
+		if err != nil || !ok {
+			return wireCallResult{Spent: 1, Failure: []byte("invalid signature")}, nil
+		}
+
+		return wireCallResult{Success: true, Spent: 1}, nil
+
+	case "block":
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+
+	default:
+		if _, ok := engine.logic.GetCallsite(ixn.Callsite()); !ok {
+			return nil, errors.New("unknown callsite")
+		}
+
+		return wireCallResult{Success: true}, nil
+	}
+}
+
+type testEncoder struct{}
+
+func (testEncoder) EncodeInputs(inputs map[string]any, refs engineio.ReferenceProvider) ([]byte, error) {
+	return engineio.EncodeValues(inputs, refs)
+}
+
+func (testEncoder) DecodeOutputs(data []byte) (map[string]any, error) {
+	return map[string]any{
+		"raw":   data,
+		"size":  len(data),
+		"big":   big.NewInt(1 << 40),
+		"pairs": map[string]uint8{"a": 1},
+		"list":  []any{"x", nil},
+	}, nil
+}
+
+// testElement is a ManifestElementObject of the test runtime
+type testElement struct {
+	Value uint64 `json:"value" yaml:"value"`
+}
+
+func (element testElement) Polorize() (*polo.Polorizer, error) {
+	polorizer := polo.NewPolorizer()
+	polorizer.PolorizeUint(element.Value)
+
+	return polorizer, nil
+}
+
+func (element *testElement) Depolorize(depolorizer *polo.Depolorizer) (err error) {
+	element.Value, err = depolorizer.DepolorizeUint()
+
+	return err
+}
+
+// testGraph is a DependencyDriver of the test runtime
+type testGraph map[engineio.ElementPtr][]engineio.ElementPtr
+
+func (graph testGraph) String() string {
+	return fmt.Sprintf("graph%v", map[engineio.ElementPtr][]engineio.ElementPtr(graph))
+}
+
+func (graph testGraph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[engineio.ElementPtr][]engineio.ElementPtr(graph))
+}
+
+func (graph testGraph) UnmarshalJSON(data []byte) error {
+	decoded := make(map[engineio.ElementPtr][]engineio.ElementPtr)
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	for ptr, deps := range decoded {
+		graph[ptr] = deps
+	}
+
+	return nil
+}
+
+func (graph testGraph) Polorize() (*polo.Polorizer, error) {
+	polorizer := polo.NewPolorizer()
+	if err := polorizer.Polorize(map[engineio.ElementPtr][]engineio.ElementPtr(graph)); err != nil {
+		return nil, err
+	}
+
+	return polorizer, nil
+}
+
+func (graph testGraph) Depolorize(depolorizer *polo.Depolorizer) error {
+	decoded := make(map[engineio.ElementPtr][]engineio.ElementPtr)
+	if err := depolorizer.Depolorize(&decoded); err != nil {
+		return err
+	}
+
+	for ptr, deps := range decoded {
+		graph[ptr] = deps
+	}
+
+	return nil
+}
+
+func (graph testGraph) Insert(ptr engineio.ElementPtr, deps ...engineio.ElementPtr) {
+	graph[ptr] = append([]engineio.ElementPtr{}, deps...)
+}
+
+func (graph testGraph) Remove(ptr engineio.ElementPtr) { delete(graph, ptr) }
+func (graph testGraph) Size() uint64                   { return uint64(len(graph)) }
+
+func (graph testGraph) Iter() <-chan engineio.ElementPtr {
+	ptrs := make([]engineio.ElementPtr, 0, len(graph))
+	for ptr := range graph {
+		ptrs = append(ptrs, ptr)
+	}
+
+	sort.Slice(ptrs, func(i, j int) bool { return ptrs[i] < ptrs[j] })
+
+	iter := make(chan engineio.ElementPtr, len(ptrs))
+	for _, ptr := range ptrs {
+		iter <- ptr
+	}
+
+	close(iter)
+
+	return iter
+}
+
+func (graph testGraph) Contains(ptr engineio.ElementPtr) bool {
+	_, ok := graph[ptr]
+
+	return ok
+}
+
+func (graph testGraph) Edges(ptr engineio.ElementPtr) []engineio.ElementPtr { return graph[ptr] }
+
+func (graph testGraph) Dependencies(ptr engineio.ElementPtr) []engineio.ElementPtr {
+	visited := make(map[engineio.ElementPtr]bool)
+
+	var visit func(engineio.ElementPtr)
+	visit = func(ptr engineio.ElementPtr) {
+		for _, dep := range graph[ptr] {
+			if !visited[dep] {
+				visited[dep] = true
+				visit(dep)
+			}
+		}
+	}
+
+	visit(ptr)
+
+	deps := make([]engineio.ElementPtr, 0, len(visited))
+	for dep := range visited {
+		deps = append(deps, dep)
+	}
+
+	sort.Slice(deps, func(i, j int) bool { return deps[i] < deps[j] })
+
+	return deps
+}
+
+// host side test drivers
+
+// newTestLogic returns a Logic of the test runtime with a single "run" callsite
+func newTestLogic() *panickingLogic {
+	descriptor := &engineio.LogicDescriptor{
+		Engine:       testKind,
+		ManifestHash: engineio.Hash{1},
+		Elements:     engineio.LogicElementTable{},
+		CtxState:     engineio.ContextStateMatrix{engineio.PersistentState: 0},
+		Callsites:    map[string]*engineio.Callsite{"run": {Ptr: 1, Kind: engineio.InvokableCallsite}},
+	}
+
+	return &panickingLogic{engineiotest.NewLogic(identifiers.NewAddressFromBytes([]byte{0x0a}), descriptor)}
+}
+
+// panickingLogic is a Logic that panics when the "unknown" callsite is looked up
+type panickingLogic struct {
+	*engineiotest.Logic
+}
+
+func (logic *panickingLogic) GetCallsite(name string) (*engineio.Callsite, bool) {
+	if name == "unknown" {
+		panic("unknown callsite")
+	}
+
+	return logic.Logic.GetCallsite(name)
+}
+
+func newTestIxn(callsite string, calldata []byte) *engineiotest.IxnDriver {
+	return engineiotest.NewIxnDriver(engineiotest.IxnType{ID: 1, Name: "IxLogicInvoke"}, callsite, calldata)
+}
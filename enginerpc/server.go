@@ -0,0 +1,711 @@
+package enginerpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-moi-identifiers"
+	"github.com/sarvalabs/go-polo"
+	"gopkg.in/yaml.v3"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+// Serve serves an EngineRuntime over the given connection to a host that uses a Client.
+// It blocks until the connection is closed by the host (returning nil) or fails (returning the error).
+//
+// The runtime is only registered with a Registry of the connection (which decodes the manifests sent
+// by the host), along with a CryptoDriver that proxies to the crypto driver of the host. Engines can obtain
+// that driver in Engine.Call with CryptoDriverFromContext. Runtime implementations that look up their
+// CryptoDriver with engineio.FetchCryptoDriver must opt in with the RegisterDefault option.
+func Serve(conn io.ReadWriteCloser, runtime engineio.EngineRuntime, opts ...ServeOption) error {
+	config := new(serveConfig)
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	server := &server{runtime: runtime, registry: engineio.NewRegistry()}
+	server.peer = newPeer(conn, server.handlers())
+	server.crypto = &remoteCrypto{server.peer}
+
+	if err := server.registry.Register(runtime, server.crypto); err != nil {
+		return err
+	}
+
+	if config.registerDefault {
+		release, err := registerDefault(runtime, server.crypto)
+		if err != nil {
+			_ = conn.Close()
+
+			return err
+		}
+
+		defer release()
+	}
+
+	if err := server.peer.run(); !errors.Is(err, ErrClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// ServeStdio serves an EngineRuntime over the standard input and output of the process.
+// It is intended to be called from the main function of a runtime process that is
+// started by the host with StartProcess. See Serve for more details.
+func ServeStdio(runtime engineio.EngineRuntime, opts ...ServeOption) error {
+	return Serve(stdioConn{}, runtime, opts...)
+}
+
+// ServeOption is a functional option for configuring how Serve serves an EngineRuntime
+type ServeOption func(*serveConfig)
+
+type serveConfig struct {
+	registerDefault bool
+}
+
+// RegisterDefault configures Serve to also register the runtime with the default engineio Registry of the
+// process while it is served, along with a CryptoDriver that proxies to the crypto driver of the host, so that
+// runtime implementations can look it up with engineio.FetchCryptoDriver.
+//
+// Serve fails if the same kind and version of the runtime is already registered with the default Registry by
+// other means. Connections that serve the same runtime with this option share its registration, which proxies
+// to the host of the oldest connection that is still open, and it is unregistered when the last one closes.
+// It is intended for runtime processes that serve a single host (such as with ServeStdio).
+func RegisterDefault() ServeOption {
+	return func(config *serveConfig) { config.registerDefault = true }
+}
+
+// cryptoContextKey is the context key for the CryptoDriver of the host in Engine.Call
+type cryptoContextKey struct{}
+
+// CryptoDriverFromContext returns the CryptoDriver that proxies to the crypto driver of the host,
+// from the context passed by Serve to Engine.Call. Returns false if the context has no driver.
+func CryptoDriverFromContext(ctx context.Context) (engineio.CryptoDriver, bool) {
+	crypto, ok := ctx.Value(cryptoContextKey{}).(engineio.CryptoDriver)
+
+	return crypto, ok
+}
+
+// defaultRegistrations are the runtimes registered with the default engineio Registry by Serve
+var defaultRegistrations = struct {
+	mutex   sync.Mutex
+	entries map[defaultKey]*sharedCrypto
+}{entries: make(map[defaultKey]*sharedCrypto)}
+
+// defaultKey identifies a runtime registered with the default engineio Registry by Serve
+type defaultKey struct {
+	kind    engineio.EngineKind
+	version string
+}
+
+// registerDefault registers the runtime with the default engineio Registry with a CryptoDriver that is shared
+// by the connections that serve it, and returns a function that releases the registration of the connection.
+// Returns an error if the runtime is already registered with the default Registry by other means.
+func registerDefault(runtime engineio.EngineRuntime, crypto *remoteCrypto) (func(), error) {
+	defaultRegistrations.mutex.Lock()
+	defer defaultRegistrations.mutex.Unlock()
+
+	key := defaultKey{runtime.Kind(), runtime.Version()}
+
+	shared, exists := defaultRegistrations.entries[key]
+	if !exists {
+		if _, err := engineio.ResolveEngineRuntime(key.kind, "="+key.version); err == nil {
+			return nil, errors.Errorf(
+				"enginerpc: runtime %v@%v is already registered with the default registry", key.kind, key.version,
+			)
+		}
+
+		shared = new(sharedCrypto)
+		if err := engineio.RegisterRuntime(runtime, shared); err != nil {
+			return nil, err
+		}
+
+		defaultRegistrations.entries[key] = shared
+	}
+
+	shared.add(crypto)
+
+	return func() {
+		defaultRegistrations.mutex.Lock()
+		defer defaultRegistrations.mutex.Unlock()
+
+		if shared.remove(crypto) == 0 {
+			delete(defaultRegistrations.entries, key)
+			engineio.UnregisterRuntimeVersion(key.kind, key.version)
+		}
+	}, nil
+}
+
+// stdioConn joins the standard input and output of the process into an io.ReadWriteCloser
+type stdioConn struct{}
+
+func (stdioConn) Read(p []byte) (int, error)  { return os.Stdin.Read(p) }
+func (stdioConn) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (stdioConn) Close() error                { return os.Stdin.Close() }
+
+// server serves the methods of an EngineRuntime (and the engines
+// and encoders obtained from it) to the host over a peer connection
+type server struct {
+	peer     *peer
+	crypto   *remoteCrypto
+	runtime  engineio.EngineRuntime
+	registry *engineio.Registry
+}
+
+func (server *server) handlers() map[string]handlerFunc {
+	handles := func() *handleTable { return server.peer.handles }
+
+	return map[string]handlerFunc{
+		methodDescribe: handler(func(_ context.Context, _ struct{}) (any, error) {
+			return describeResult{Kind: server.runtime.Kind(), Version: server.runtime.Version()}, nil
+		}),
+
+		methodSpawn: handler(func(_ context.Context, params spawnParams) (any, error) {
+			engine, err := server.runtime.SpawnEngine(
+				params.Fuel,
+				server.logic(params.Logic),
+				server.ctx(params.Ctx),
+				server.env(params.Env),
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			return handleParams{handles().store(engine)}, nil
+		}),
+
+		methodCompile: handler(func(_ context.Context, params compileParams) (any, error) {
+			manifest, err := server.registry.NewManifest(params.Manifest, engineio.POLO)
+			if err != nil {
+				return compileResult{Error: err.Error()}, nil
+			}
+
+			descriptor, fuel, err := server.runtime.CompileManifest(params.Fuel, manifest)
+
+			result := compileResult{Fuel: fuel}
+			if err != nil {
+				result.Error = err.Error()
+			}
+
+			if result.Descriptor, err = newWireDescriptor(descriptor); err != nil {
+				return nil, err
+			}
+
+			return result, nil
+		}),
+
+		methodValidateCalldata: handler(func(_ context.Context, params validateParams) (any, error) {
+			if err := server.runtime.ValidateCalldata(server.logic(params.Logic), params.Ixn); err != nil {
+				return errorResult{Error: err.Error()}, nil
+			}
+
+			return errorResult{}, nil
+		}),
+
+		methodHasElement: handler(func(_ context.Context, params elementParams) (any, error) {
+			_, ok := server.runtime.GetElementGenerator(params.Kind)
+
+			return okResult{ok}, nil
+		}),
+
+		methodTranscodeElement: handler(func(_ context.Context, params transcodeParams) (any, error) {
+			generator, ok := server.runtime.GetElementGenerator(params.Kind)
+			if !ok {
+				return nil, errors.Errorf("unrecognized element kind: '%v'", params.Kind)
+			}
+
+			object := generator()
+			if err := decodeElement(object, params.Data, params.From); err != nil {
+				return nil, err
+			}
+
+			encoded, err := encodeElement(object, params.To)
+			if err != nil {
+				return nil, err
+			}
+
+			return dataPayload{encoded}, nil
+		}),
+
+		methodCallEncoder: handler(func(_ context.Context, params callEncoderParams) (any, error) {
+			callsite := params.Callsite
+
+			encoder, err := server.runtime.GetCallEncoder(&callsite, server.logic(params.Logic))
+			if err != nil {
+				return nil, err
+			}
+
+			return handleParams{handles().store(encoder)}, nil
+		}),
+
+		methodDecodeDependency: handler(func(_ context.Context, params decodeParams) (any, error) {
+			driver, err := server.runtime.DecodeDependencyDriver(params.Data, params.Encoding)
+			if err != nil {
+				return nil, err
+			}
+
+			return newWireDependency(driver)
+		}),
+
+		methodApplyDependency: handler(func(_ context.Context, params applyDependencyParams) (any, error) {
+			driver, err := server.runtime.DecodeDependencyDriver(params.Data, engineio.POLO)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, op := range params.Ops {
+				if op.Remove {
+					driver.Remove(op.Ptr)
+				} else {
+					driver.Insert(op.Ptr, op.Deps...)
+				}
+			}
+
+			return newWireDependency(driver)
+		}),
+
+		methodDecodeError: handler(func(_ context.Context, params dataPayload) (any, error) {
+			decoded, err := server.runtime.DecodeErrorResult(params.Data)
+			if err != nil {
+				return nil, err
+			}
+
+			return wireErrorResult{
+				Kind:    decoded.Engine(),
+				Message: decoded.String(),
+				Raw:     decoded.Bytes(),
+				Revert:  decoded.Reverted(),
+			}, nil
+		}),
+
+		methodEngineCall: handler(func(ctx context.Context, params callParams) (any, error) {
+			engine, err := loadHandle[engineio.Engine](handles(), params.Engine)
+			if err != nil || engine == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			participants := make([]engineio.CtxDriver, 0, len(params.Ctx))
+			for _, handle := range params.Ctx {
+				participants = append(participants, server.ctx(handle))
+			}
+
+			ctx = context.WithValue(ctx, cryptoContextKey{}, engineio.CryptoDriver(server.crypto))
+
+			result, err := engine.Call(ctx, params.Ixn, participants...)
+			if err != nil {
+				return nil, err
+			}
+
+			if result == nil {
+				return nil, errors.New("engine returned a nil call result")
+			}
+
+			return wireCallResult{
+				Success: result.Ok(),
+				Spent:   result.Fuel(),
+				Output:  result.Outputs(),
+				Failure: result.Error(),
+			}, nil
+		}),
+
+		methodEngineRelease: handler(func(_ context.Context, params handleParams) (any, error) {
+			handles().release(params.Handle)
+
+			return struct{}{}, nil
+		}),
+
+		methodEncodeInputs: handler(func(_ context.Context, params encodeInputsParams) (any, error) {
+			encoder, err := loadHandle[engineio.CallEncoder](handles(), params.Encoder)
+			if err != nil || encoder == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			inputs, err := decodeWireValues(params.Inputs)
+			if err != nil {
+				return nil, err
+			}
+
+			var refs engineio.ReferenceProvider
+			if params.Refs != 0 {
+				refs = &remoteRefs{server.peer, params.Refs}
+			}
+
+			encoded, err := encoder.EncodeInputs(inputs, refs)
+			if err != nil {
+				return nil, err
+			}
+
+			return dataPayload{encoded}, nil
+		}),
+
+		methodDecodeOutputs: handler(func(_ context.Context, params decodeOutputsParams) (any, error) {
+			encoder, err := loadHandle[engineio.CallEncoder](handles(), params.Encoder)
+			if err != nil || encoder == nil {
+				return nil, nonNilHandle(err)
+			}
+
+			outputs, err := encoder.DecodeOutputs(params.Data)
+			if err != nil {
+				return nil, err
+			}
+
+			encoded, err := encodeWireValues(outputs)
+			if err != nil {
+				return nil, err
+			}
+
+			return outputsResult{encoded}, nil
+		}),
+
+		methodEncoderRelease: handler(func(_ context.Context, params handleParams) (any, error) {
+			handles().release(params.Handle)
+
+			return struct{}{}, nil
+		}),
+	}
+}
+
+// logic returns a Logic proxy for a handle of the host (nil for a zero handle)
+func (server *server) logic(handle uint64) engineio.Logic {
+	if handle == 0 {
+		return nil
+	}
+
+	return &remoteLogic{server.peer, handle}
+}
+
+// ctx returns a CtxDriver proxy for a handle of the host (nil for a zero handle)
+func (server *server) ctx(handle uint64) engineio.CtxDriver {
+	if handle == 0 {
+		return nil
+	}
+
+	return &remoteCtx{server.peer, handle}
+}
+
+// env returns an EnvDriver proxy for a handle of the host (nil for a zero handle)
+func (server *server) env(handle uint64) engineio.EnvDriver {
+	if handle == 0 {
+		return nil
+	}
+
+	return &remoteEnv{server.peer, handle}
+}
+
+func newWireDescriptor(descriptor *engineio.LogicDescriptor) (*wireDescriptor, error) {
+	if descriptor == nil {
+		return nil, nil
+	}
+
+	dependency, err := newWireDependency(descriptor.Dependency)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode dependency driver")
+	}
+
+	return &wireDescriptor{
+		Engine:       descriptor.Engine,
+		ManifestRaw:  descriptor.ManifestRaw,
+		ManifestHash: descriptor.ManifestHash,
+		Interactive:  descriptor.Interactive,
+		Dependency:   dependency,
+		Elements:     descriptor.Elements,
+		CtxState:     descriptor.CtxState,
+		Callsites:    descriptor.Callsites,
+		Classdefs:    descriptor.Classdefs,
+	}, nil
+}
+
+func decodeElement(object engineio.ManifestElementObject, data []byte, encoding engineio.Encoding) error {
+	switch encoding {
+	case engineio.POLO:
+		return polo.Depolorize(object, data)
+	case engineio.JSON:
+		return json.Unmarshal(data, object)
+	case engineio.YAML:
+		return yaml.Unmarshal(data, object)
+	default:
+		return errors.New("unsupported element encoding")
+	}
+}
+
+func encodeElement(object engineio.ManifestElementObject, encoding engineio.Encoding) ([]byte, error) {
+	switch encoding {
+	case engineio.POLO:
+		return polo.Polorize(object)
+	case engineio.JSON:
+		return json.Marshal(object)
+	case engineio.YAML:
+		return yaml.Marshal(object)
+	default:
+		return nil, errors.New("unsupported element encoding")
+	}
+}
+
+// callback issues a driver callback to the host and returns whether it succeeded. Most driver methods
+// cannot return an error, so a failure is recorded on the peer (and reported by the requests that are
+// being served) and the caller returns zero values, since the driver may be called from any goroutine.
+func callback(p *peer, method string, params, result any) bool {
+	if err := p.call(context.Background(), method, params, result); err != nil {
+		p.recordFailure(errors.Wrapf(err, "%v callback failed", method))
+
+		return false
+	}
+
+	return true
+}
+
+// remoteLogic is a Logic proxy for a Logic of the host
+type remoteLogic struct {
+	peer   *peer
+	handle uint64
+}
+
+func (logic *remoteLogic) info() logicInfo {
+	info := new(logicInfo)
+	if !callback(logic.peer, methodLogicInfo, handleParams{logic.handle}, info) {
+		return logicInfo{}
+	}
+
+	return *info
+}
+
+func (logic *remoteLogic) LogicID() identifiers.LogicID {
+	return identifiers.LogicID(logic.info().ID)
+}
+
+func (logic *remoteLogic) Engine() engineio.EngineKind { return logic.info().Engine }
+func (logic *remoteLogic) Manifest() engineio.Hash     { return logic.info().Manifest }
+func (logic *remoteLogic) IsSealed() bool              { return logic.info().Sealed }
+func (logic *remoteLogic) IsAssetLogic() bool          { return logic.info().Asset }
+func (logic *remoteLogic) IsInteractive() bool         { return logic.info().Interactive }
+
+func (logic *remoteLogic) PersistentState() (engineio.ElementPtr, bool) {
+	if ptr := logic.info().Persistent; ptr != nil {
+		return *ptr, true
+	}
+
+	return 0, false
+}
+
+func (logic *remoteLogic) EphemeralState() (engineio.ElementPtr, bool) {
+	if ptr := logic.info().Ephemeral; ptr != nil {
+		return *ptr, true
+	}
+
+	return 0, false
+}
+
+func (logic *remoteLogic) GetElementDeps(ptr engineio.ElementPtr) []engineio.ElementPtr {
+	result := new(ptrsResult)
+	if !callback(logic.peer, methodLogicElementDeps, logicPtrParams{logic.handle, ptr}, result) {
+		return nil
+	}
+
+	return result.Ptrs
+}
+
+func (logic *remoteLogic) GetElement(ptr engineio.ElementPtr) (*engineio.LogicElement, bool) {
+	result := new(elementResult)
+	if !callback(logic.peer, methodLogicElement, logicPtrParams{logic.handle, ptr}, result) {
+		return nil, false
+	}
+
+	return result.Element, result.Element != nil
+}
+
+func (logic *remoteLogic) GetCallsite(name string) (*engineio.Callsite, bool) {
+	result := new(callsiteResult)
+	if !callback(logic.peer, methodLogicCallsite, logicNameParams{logic.handle, name}, result) {
+		return nil, false
+	}
+
+	return result.Callsite, result.Callsite != nil
+}
+
+func (logic *remoteLogic) GetClassdef(name string) (*engineio.Classdef, bool) {
+	result := new(classdefResult)
+	if !callback(logic.peer, methodLogicClassdef, logicNameParams{logic.handle, name}, result) {
+		return nil, false
+	}
+
+	return result.Classdef, result.Classdef != nil
+}
+
+// remoteCtx is a CtxDriver proxy for a CtxDriver of the host
+type remoteCtx struct {
+	peer   *peer
+	handle uint64
+}
+
+func (driver *remoteCtx) info() ctxInfo {
+	info := new(ctxInfo)
+	if !callback(driver.peer, methodCtxInfo, handleParams{driver.handle}, info) {
+		return ctxInfo{}
+	}
+
+	return *info
+}
+
+func (driver *remoteCtx) Address() identifiers.Address { return driver.info().Address }
+func (driver *remoteCtx) LogicID() identifiers.LogicID {
+	return identifiers.LogicID(driver.info().LogicID)
+}
+
+func (driver *remoteCtx) GetStorageEntry(key []byte) ([]byte, bool) {
+	result := new(storageResult)
+	if !callback(driver.peer, methodCtxGetStorage, storageParams{Ctx: driver.handle, Key: key}, result) {
+		return nil, false
+	}
+
+	return result.Value, result.Ok
+}
+
+func (driver *remoteCtx) SetStorageEntry(key, value []byte) bool {
+	result := new(storageResult)
+	if !callback(driver.peer, methodCtxSetStorage, storageParams{Ctx: driver.handle, Key: key, Value: value}, result) {
+		return false
+	}
+
+	return result.Ok
+}
+
+// remoteEnv is an EnvDriver proxy for an EnvDriver of the host
+type remoteEnv struct {
+	peer   *peer
+	handle uint64
+}
+
+func (driver *remoteEnv) info() envInfo {
+	info := new(envInfo)
+	if !callback(driver.peer, methodEnvInfo, handleParams{driver.handle}, info) {
+		return envInfo{}
+	}
+
+	return *info
+}
+
+func (driver *remoteEnv) Timestamp() int64  { return driver.info().Timestamp }
+func (driver *remoteEnv) ClusterID() string { return driver.info().ClusterID }
+
+// remoteRefs is a ReferenceProvider proxy for a ReferenceProvider of the host
+type remoteRefs struct {
+	peer   *peer
+	handle uint64
+}
+
+func (refs *remoteRefs) GetReference(ref engineio.ReferenceVal) (any, bool) {
+	result := new(refsResult)
+	if !callback(refs.peer, methodRefsGet, refsParams{refs.handle, ref}, result) {
+		return nil, false
+	}
+
+	if !result.Ok || result.Value == nil {
+		return nil, false
+	}
+
+	value, err := decodeWireValue(*result.Value)
+	if err != nil {
+		refs.peer.recordFailure(errors.Wrapf(err, "%v callback failed: malformed reference value", methodRefsGet))
+
+		return nil, false
+	}
+
+	return value, true
+}
+
+// remoteCrypto is a CryptoDriver proxy for the CryptoDriver of the host
+type remoteCrypto struct {
+	peer *peer
+}
+
+func (crypto *remoteCrypto) ValidateSignature(sig []byte) bool {
+	result := new(signatureResult)
+	if err := crypto.peer.call(
+		context.Background(), methodCryptoValidate, signatureParams{Signature: sig}, result,
+	); err != nil {
+		return false
+	}
+
+	return result.Ok
+}
+
+func (crypto *remoteCrypto) VerifySignature(data, sig, pub []byte) (bool, error) {
+	result := new(signatureResult)
+	if err := crypto.peer.call(context.Background(), methodCryptoVerify, signatureParams{
+		Data: data, Signature: sig, PublicKey: pub,
+	}, result); err != nil {
+		return false, err
+	}
+
+	if result.Error != "" {
+		return result.Ok, errors.New(result.Error)
+	}
+
+	return result.Ok, nil
+}
+
+// sharedCrypto is a CryptoDriver that proxies to the crypto driver of the host
+// of the oldest connection that shares a registration with the default Registry
+type sharedCrypto struct {
+	mutex   sync.Mutex
+	proxies []*remoteCrypto
+}
+
+// add adds the crypto proxy of a connection to the shared driver
+func (shared *sharedCrypto) add(crypto *remoteCrypto) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+
+	shared.proxies = append(shared.proxies, crypto)
+}
+
+// remove removes the crypto proxy of a connection from the shared driver and returns the number of remaining proxies
+func (shared *sharedCrypto) remove(crypto *remoteCrypto) int {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+
+	for idx, proxy := range shared.proxies {
+		if proxy == crypto {
+			shared.proxies = append(shared.proxies[:idx:idx], shared.proxies[idx+1:]...)
+
+			break
+		}
+	}
+
+	return len(shared.proxies)
+}
+
+// current returns the crypto proxy of the oldest connection (nil if there are none)
+func (shared *sharedCrypto) current() *remoteCrypto {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+
+	if len(shared.proxies) == 0 {
+		return nil
+	}
+
+	return shared.proxies[0]
+}
+
+func (shared *sharedCrypto) ValidateSignature(sig []byte) bool {
+	crypto := shared.current()
+	if crypto == nil {
+		return false
+	}
+
+	return crypto.ValidateSignature(sig)
+}
+
+func (shared *sharedCrypto) VerifySignature(data, sig, pub []byte) (bool, error) {
+	crypto := shared.current()
+	if crypto == nil {
+		return false, ErrClosed
+	}
+
+	return crypto.VerifySignature(data, sig, pub)
+}
@@ -0,0 +1,698 @@
+package enginerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-moi-identifiers"
+
+	engineio "github.com/sarvalabs/go-moi-engineio"
+)
+
+// Method names of the protocol. Methods prefixed with "runtime.", "engine." and "encoder." are served by the
+// runtime process, while the rest are driver callbacks that are served by the host process.
+const (
+	methodCancel = "$/cancel"
+
+	methodDescribe         = "runtime.describe"
+	methodSpawn            = "runtime.spawn"
+	methodCompile          = "runtime.compile"
+	methodValidateCalldata = "runtime.validate_calldata"
+	methodHasElement       = "runtime.has_element"
+	methodTranscodeElement = "runtime.transcode_element"
+	methodCallEncoder      = "runtime.call_encoder"
+	methodDecodeDependency = "runtime.decode_dependency"
+	methodApplyDependency  = "runtime.apply_dependency"
+	methodDecodeError      = "runtime.decode_error"
+
+	methodEngineCall    = "engine.call"
+	methodEngineRelease = "engine.release"
+
+	methodEncodeInputs   = "encoder.encode_inputs"
+	methodDecodeOutputs  = "encoder.decode_outputs"
+	methodEncoderRelease = "encoder.release"
+
+	methodLogicInfo        = "logic.info"
+	methodLogicElementDeps = "logic.element_deps"
+	methodLogicElement     = "logic.element"
+	methodLogicCallsite    = "logic.callsite"
+	methodLogicClassdef    = "logic.classdef"
+
+	methodCtxInfo       = "ctx.info"
+	methodCtxGetStorage = "ctx.get_storage"
+	methodCtxSetStorage = "ctx.set_storage"
+
+	methodEnvInfo = "env.info"
+
+	methodRefsGet = "refs.get"
+
+	methodCryptoValidate = "crypto.validate_signature"
+	methodCryptoVerify   = "crypto.verify_signature"
+)
+
+type cancelParams struct {
+	ID uint64 `json:"id"`
+}
+
+type handleParams struct {
+	Handle uint64 `json:"handle"`
+}
+
+type describeResult struct {
+	Kind    engineio.EngineKind `json:"kind"`
+	Version string              `json:"version"`
+}
+
+type spawnParams struct {
+	Fuel  engineio.EngineFuel `json:"fuel"`
+	Logic uint64              `json:"logic"`
+	Ctx   uint64              `json:"ctx"`
+	Env   uint64              `json:"env"`
+}
+
+type compileParams struct {
+	Fuel     engineio.EngineFuel `json:"fuel"`
+	Manifest []byte              `json:"manifest"`
+}
+
+type compileResult struct {
+	Descriptor *wireDescriptor     `json:"descriptor,omitempty"`
+	Fuel       engineio.EngineFuel `json:"fuel"`
+	Error      string              `json:"error,omitempty"`
+}
+
+type validateParams struct {
+	Logic uint64  `json:"logic"`
+	Ixn   wireIxn `json:"ixn"`
+}
+
+type errorResult struct {
+	Error string `json:"error,omitempty"`
+}
+
+type elementParams struct {
+	Kind engineio.ElementKind `json:"kind"`
+}
+
+type transcodeParams struct {
+	Kind engineio.ElementKind `json:"kind"`
+	From engineio.Encoding    `json:"from"`
+	To   engineio.Encoding    `json:"to"`
+	Data []byte               `json:"data"`
+}
+
+type dataPayload struct {
+	Data []byte `json:"data"`
+}
+
+type callEncoderParams struct {
+	Callsite engineio.Callsite `json:"callsite"`
+	Logic    uint64            `json:"logic"`
+}
+
+type decodeParams struct {
+	Data     []byte            `json:"data"`
+	Encoding engineio.Encoding `json:"encoding"`
+}
+
+type dependencyOp struct {
+	Remove bool                  `json:"remove,omitempty"`
+	Ptr    engineio.ElementPtr   `json:"ptr"`
+	Deps   []engineio.ElementPtr `json:"deps,omitempty"`
+}
+
+type applyDependencyParams struct {
+	Data []byte         `json:"data"`
+	Ops  []dependencyOp `json:"ops"`
+}
+
+type callParams struct {
+	Engine uint64   `json:"engine"`
+	Ixn    wireIxn  `json:"ixn"`
+	Ctx    []uint64 `json:"ctx"`
+}
+
+type encodeInputsParams struct {
+	Encoder uint64               `json:"encoder"`
+	Inputs  map[string]wireValue `json:"inputs"`
+	Refs    uint64               `json:"refs"`
+}
+
+type decodeOutputsParams struct {
+	Encoder uint64 `json:"encoder"`
+	Data    []byte `json:"data"`
+}
+
+type outputsResult struct {
+	Outputs map[string]wireValue `json:"outputs"`
+}
+
+type logicInfo struct {
+	ID          string               `json:"id"`
+	Engine      engineio.EngineKind  `json:"engine"`
+	Manifest    engineio.Hash        `json:"manifest"`
+	Sealed      bool                 `json:"sealed"`
+	Asset       bool                 `json:"asset"`
+	Interactive bool                 `json:"interactive"`
+	Persistent  *engineio.ElementPtr `json:"persistent,omitempty"`
+	Ephemeral   *engineio.ElementPtr `json:"ephemeral,omitempty"`
+}
+
+type logicPtrParams struct {
+	Logic uint64              `json:"logic"`
+	Ptr   engineio.ElementPtr `json:"ptr"`
+}
+
+type logicNameParams struct {
+	Logic uint64 `json:"logic"`
+	Name  string `json:"name"`
+}
+
+type ptrsResult struct {
+	Ptrs []engineio.ElementPtr `json:"ptrs"`
+}
+
+type elementResult struct {
+	Element *engineio.LogicElement `json:"element,omitempty"`
+}
+
+type callsiteResult struct {
+	Callsite *engineio.Callsite `json:"callsite,omitempty"`
+}
+
+type classdefResult struct {
+	Classdef *engineio.Classdef `json:"classdef,omitempty"`
+}
+
+type ctxInfo struct {
+	Address identifiers.Address `json:"address"`
+	LogicID string              `json:"logic_id"`
+}
+
+type storageParams struct {
+	Ctx   uint64 `json:"ctx"`
+	Key   []byte `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+type storageResult struct {
+	Value []byte `json:"value,omitempty"`
+	Ok    bool   `json:"ok"`
+}
+
+type envInfo struct {
+	Timestamp int64  `json:"timestamp"`
+	ClusterID string `json:"cluster_id"`
+}
+
+type refsParams struct {
+	Refs uint64                `json:"refs"`
+	Ref  engineio.ReferenceVal `json:"ref"`
+}
+
+type refsResult struct {
+	Value *wireValue `json:"value,omitempty"`
+	Ok    bool       `json:"ok"`
+}
+
+type signatureParams struct {
+	Data      []byte `json:"data,omitempty"`
+	Signature []byte `json:"signature"`
+	PublicKey []byte `json:"public_key,omitempty"`
+}
+
+type signatureResult struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// wireIxn is the snapshot of an IxnDriver. It implements both IxnDriver and IxnType.
+// Interaction drivers are immutable and are therefore copied rather than proxied.
+type wireIxn struct {
+	TypeID    int      `json:"type_id"`
+	TypeName  string   `json:"type_name"`
+	Price     *big.Int `json:"fuel_price"`
+	Limit     uint64   `json:"fuel_limit"`
+	Site      string   `json:"callsite"`
+	Data      []byte   `json:"calldata"`
+	HasIxType bool     `json:"has_type"`
+}
+
+func newWireIxn(ixn engineio.IxnDriver) wireIxn {
+	if ixn == nil {
+		return wireIxn{}
+	}
+
+	snapshot := wireIxn{
+		Price: ixn.FuelPrice(),
+		Limit: ixn.FuelLimit(),
+		Site:  ixn.Callsite(),
+		Data:  ixn.Calldata(),
+	}
+
+	if kind := ixn.IxnType(); kind != nil {
+		snapshot.TypeID, snapshot.TypeName, snapshot.HasIxType = kind.IxnID(), kind.String(), true
+	}
+
+	return snapshot
+}
+
+func (ixn wireIxn) IxnType() engineio.IxnType {
+	if !ixn.HasIxType {
+		return nil
+	}
+
+	return ixn
+}
+
+func (ixn wireIxn) IxnID() int          { return ixn.TypeID }
+func (ixn wireIxn) String() string      { return ixn.TypeName }
+func (ixn wireIxn) FuelPrice() *big.Int { return ixn.Price }
+func (ixn wireIxn) FuelLimit() uint64   { return ixn.Limit }
+func (ixn wireIxn) Callsite() string    { return ixn.Site }
+func (ixn wireIxn) Calldata() []byte    { return ixn.Data }
+
+// wireCallResult is the snapshot of a CallResult. It implements the CallResult interface.
+type wireCallResult struct {
+	Success bool                `json:"ok"`
+	Spent   engineio.EngineFuel `json:"fuel"`
+	Output  []byte              `json:"outputs,omitempty"`
+	Failure []byte              `json:"error,omitempty"`
+}
+
+func (result wireCallResult) Ok() bool                  { return result.Success }
+func (result wireCallResult) Fuel() engineio.EngineFuel { return result.Spent }
+func (result wireCallResult) Outputs() []byte           { return result.Output }
+func (result wireCallResult) Error() []byte             { return result.Failure }
+
+// wireErrorResult is the snapshot of an ErrorResult. It implements the ErrorResult interface.
+type wireErrorResult struct {
+	Kind    engineio.EngineKind `json:"engine"`
+	Message string              `json:"string"`
+	Raw     []byte              `json:"bytes"`
+	Revert  bool                `json:"reverted"`
+}
+
+func (result wireErrorResult) Engine() engineio.EngineKind { return result.Kind }
+func (result wireErrorResult) String() string              { return result.Message }
+func (result wireErrorResult) Bytes() []byte               { return result.Raw }
+func (result wireErrorResult) Reverted() bool              { return result.Revert }
+
+// wireDependency is the snapshot of a DependencyDriver. It contains the encoded forms
+// of the driver along with the direct edges and aggregated dependencies of each vertex.
+type wireDependency struct {
+	POLO     []byte             `json:"polo"`
+	JSON     json.RawMessage    `json:"json"`
+	String   string             `json:"string"`
+	Vertices []dependencyVertex `json:"vertices"`
+}
+
+type dependencyVertex struct {
+	Ptr          engineio.ElementPtr   `json:"ptr"`
+	Edges        []engineio.ElementPtr `json:"edges"`
+	Dependencies []engineio.ElementPtr `json:"dependencies"`
+}
+
+func newWireDependency(driver engineio.DependencyDriver) (*wireDependency, error) {
+	if driver == nil {
+		return nil, nil
+	}
+
+	encodedPOLO, err := driver.Polorize()
+	if err != nil {
+		return nil, err
+	}
+
+	encodedJSON, err := driver.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &wireDependency{
+		POLO:     encodedPOLO.Bytes(),
+		JSON:     encodedJSON,
+		String:   driver.String(),
+		Vertices: make([]dependencyVertex, 0, driver.Size()),
+	}
+
+	for ptr := range driver.Iter() {
+		snapshot.Vertices = append(snapshot.Vertices, dependencyVertex{
+			Ptr:          ptr,
+			Edges:        driver.Edges(ptr),
+			Dependencies: driver.Dependencies(ptr),
+		})
+	}
+
+	return snapshot, nil
+}
+
+// wireDescriptor is the wire form of a LogicDescriptor
+type wireDescriptor struct {
+	Engine       engineio.EngineKind           `json:"engine"`
+	ManifestRaw  []byte                        `json:"manifest_raw"`
+	ManifestHash engineio.Hash                 `json:"manifest_hash"`
+	Interactive  bool                          `json:"interactive"`
+	Dependency   *wireDependency               `json:"dependency,omitempty"`
+	Elements     engineio.LogicElementTable    `json:"elements"`
+	CtxState     engineio.ContextStateMatrix   `json:"ctx_state"`
+	Callsites    map[string]*engineio.Callsite `json:"callsites"`
+	Classdefs    map[string]*engineio.Classdef `json:"classdefs"`
+}
+
+// wireValue is a type tagged value, used to transport the arbitrary values accepted by
+// a CallEncoder without losing their Go types to the JSON representation.
+//
+// The supported types are "null", "bool", "string", "bytes", "int" and "uint" (as decimal strings),
+// "float32", "float64", "bigint" (decimal string), "ref" (a ReferenceVal), "list" (an array of values),
+// "object" (an object of values, for map[string]any) and "map" (an array of [key, value] pairs, for map[any]any).
+// Typed slices, arrays and maps are transported as "list", "object" or "map" values respectively,
+// while signed and unsigned integers of any size are transported (and decoded) as int64 and uint64.
+type wireValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+func encodeWireValues(values map[string]any) (map[string]wireValue, error) {
+	if values == nil {
+		return nil, nil
+	}
+
+	encoded := make(map[string]wireValue, len(values))
+
+	for key, value := range values {
+		wire, err := encodeWireValue(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "value for '%v'", key)
+		}
+
+		encoded[key] = wire
+	}
+
+	return encoded, nil
+}
+
+func decodeWireValues(values map[string]wireValue) (map[string]any, error) {
+	if values == nil {
+		return nil, nil
+	}
+
+	decoded := make(map[string]any, len(values))
+
+	for key, wire := range values {
+		value, err := decodeWireValue(wire)
+		if err != nil {
+			return nil, errors.Wrapf(err, "value for '%v'", key)
+		}
+
+		decoded[key] = value
+	}
+
+	return decoded, nil
+}
+
+func encodeWireValue(value any) (wireValue, error) {
+	tagged := func(kind string, value any) (wireValue, error) {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return wireValue{}, err
+		}
+
+		return wireValue{Type: kind, Value: encoded}, nil
+	}
+
+	switch val := value.(type) {
+	case nil:
+		return wireValue{Type: "null"}, nil
+	case bool:
+		return tagged("bool", val)
+	case string:
+		return tagged("string", val)
+	case engineio.ReferenceVal:
+		return tagged("ref", string(val))
+	case []byte:
+		return tagged("bytes", val)
+	case float32:
+		return tagged("float32", val)
+	case float64:
+		return tagged("float64", val)
+	case *big.Int:
+		if val == nil {
+			return wireValue{Type: "null"}, nil
+		}
+
+		return tagged("bigint", val.String())
+
+	case map[string]any:
+		encoded, err := encodeWireValues(val)
+		if err != nil {
+			return wireValue{}, err
+		}
+
+		return tagged("object", encoded)
+
+	case map[any]any:
+		pairs := make([][2]wireValue, 0, len(val))
+
+		for key, elem := range val {
+			encodedKey, err := encodeWireValue(key)
+			if err != nil {
+				return wireValue{}, err
+			}
+
+			encodedElem, err := encodeWireValue(elem)
+			if err != nil {
+				return wireValue{}, err
+			}
+
+			pairs = append(pairs, [2]wireValue{encodedKey, encodedElem})
+		}
+
+		// Sort the pairs for a deterministic encoding
+		sort.Slice(pairs, func(i, j int) bool {
+			return string(pairs[i][0].Value) < string(pairs[j][0].Value)
+		})
+
+		return tagged("map", pairs)
+
+	case []any:
+		list := make([]wireValue, 0, len(val))
+
+		for _, elem := range val {
+			encoded, err := encodeWireValue(elem)
+			if err != nil {
+				return wireValue{}, err
+			}
+
+			list = append(list, encoded)
+		}
+
+		return tagged("list", list)
+	}
+
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return tagged("int", strconv.FormatInt(reflected.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return tagged("uint", strconv.FormatUint(reflected.Uint(), 10))
+	case reflect.String:
+		return tagged("string", reflected.String())
+	case reflect.Bool:
+		return tagged("bool", reflected.Bool())
+
+	case reflect.Slice, reflect.Array:
+		if reflected.Type().Elem().Kind() == reflect.Uint8 {
+			raw := make([]byte, reflected.Len())
+			reflect.Copy(reflect.ValueOf(raw), reflected)
+
+			return tagged("bytes", raw)
+		}
+
+		list := make([]any, reflected.Len())
+		for idx := range list {
+			list[idx] = reflected.Index(idx).Interface()
+		}
+
+		return encodeWireValue(list)
+
+	case reflect.Map:
+		if reflected.Type().Key().Kind() == reflect.String {
+			object := make(map[string]any, reflected.Len())
+			for iter := reflected.MapRange(); iter.Next(); {
+				object[iter.Key().String()] = iter.Value().Interface()
+			}
+
+			return encodeWireValue(object)
+		}
+
+		mapping := make(map[any]any, reflected.Len())
+		for iter := reflected.MapRange(); iter.Next(); {
+			mapping[iter.Key().Interface()] = iter.Value().Interface()
+		}
+
+		return encodeWireValue(mapping)
+
+	default:
+		return wireValue{}, errors.Errorf("unsupported value type: %T", value)
+	}
+}
+
+func decodeWireValue(wire wireValue) (any, error) {
+	switch wire.Type {
+	case "null":
+		return nil, nil
+
+	case "bool":
+		var val bool
+		err := json.Unmarshal(wire.Value, &val)
+
+		return val, err
+
+	case "string":
+		var val string
+		err := json.Unmarshal(wire.Value, &val)
+
+		return val, err
+
+	case "ref":
+		var val string
+		err := json.Unmarshal(wire.Value, &val)
+
+		return engineio.ReferenceVal(val), err
+
+	case "bytes":
+		var val []byte
+		err := json.Unmarshal(wire.Value, &val)
+
+		return val, err
+
+	case "float32":
+		var val float32
+		err := json.Unmarshal(wire.Value, &val)
+
+		return val, err
+
+	case "float64":
+		var val float64
+		err := json.Unmarshal(wire.Value, &val)
+
+		return val, err
+
+	case "int":
+		var val string
+		if err := json.Unmarshal(wire.Value, &val); err != nil {
+			return nil, err
+		}
+
+		return strconv.ParseInt(val, 10, 64)
+
+	case "uint":
+		var val string
+		if err := json.Unmarshal(wire.Value, &val); err != nil {
+			return nil, err
+		}
+
+		return strconv.ParseUint(val, 10, 64)
+
+	case "bigint":
+		var val string
+		if err := json.Unmarshal(wire.Value, &val); err != nil {
+			return nil, err
+		}
+
+		number, ok := new(big.Int).SetString(val, 10)
+		if !ok {
+			return nil, errors.Errorf("malformed bigint value: %v", val)
+		}
+
+		return number, nil
+
+	case "object":
+		var val map[string]wireValue
+		if err := json.Unmarshal(wire.Value, &val); err != nil {
+			return nil, err
+		}
+
+		object, err := decodeWireValues(val)
+		if object == nil && err == nil {
+			object = map[string]any{}
+		}
+
+		return object, err
+
+	case "map":
+		var pairs [][2]wireValue
+		if err := json.Unmarshal(wire.Value, &pairs); err != nil {
+			return nil, err
+		}
+
+		mapping := make(map[any]any, len(pairs))
+
+		for _, pair := range pairs {
+			key, err := decodeWireValue(pair[0])
+			if err != nil {
+				return nil, err
+			}
+
+			if key != nil && !reflect.TypeOf(key).Comparable() {
+				return nil, errors.Errorf("unhashable map key type: %T", key)
+			}
+
+			elem, err := decodeWireValue(pair[1])
+			if err != nil {
+				return nil, err
+			}
+
+			mapping[key] = elem
+		}
+
+		return mapping, nil
+
+	case "list":
+		var list []wireValue
+		if err := json.Unmarshal(wire.Value, &list); err != nil {
+			return nil, err
+		}
+
+		decoded := make([]any, 0, len(list))
+
+		for _, elem := range list {
+			value, err := decodeWireValue(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			decoded = append(decoded, value)
+		}
+
+		return decoded, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported value type tag: '%v'", wire.Type)
+	}
+}
+
+// isNil returns whether the given value is nil or an interface holding a nil pointer
+func isNil(object any) bool {
+	if object == nil {
+		return true
+	}
+
+	reflected := reflect.ValueOf(object)
+	switch reflected.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Interface, reflect.Chan:
+		return reflected.IsNil()
+	default:
+		return false
+	}
+}
+
+type okResult struct {
+	Ok bool `json:"ok"`
+}
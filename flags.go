@@ -0,0 +1,272 @@
+package engineio
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FlagType is an enum with variants that describe the type of the value of an EngineFlag
+type FlagType int
+
+const (
+	// BoolFlag is the FlagType of flags that are switched on by their name (such as "debug")
+	// or set with a boolean value (such as "debug=false"). They default to false.
+	BoolFlag FlagType = iota
+	// StringFlag is the FlagType of flags with a string value (such as "target=wasm")
+	StringFlag
+	// IntFlag is the FlagType of flags with a signed 64-bit integer value (such as "level=-1")
+	IntFlag
+	// UintFlag is the FlagType of flags with an unsigned 64-bit integer value (such as "fuel=1000")
+	UintFlag
+)
+
+// String implements the Stringer interface for FlagType
+func (kind FlagType) String() string {
+	switch kind {
+	case BoolFlag:
+		return "bool"
+	case StringFlag:
+		return "string"
+	case IntFlag:
+		return "int"
+	case UintFlag:
+		return "uint"
+	default:
+		return "unknown"
+	}
+}
+
+// parse parses the text form of a value of the FlagType
+func (kind FlagType) parse(value string) (any, error) {
+	switch kind {
+	case BoolFlag:
+		return strconv.ParseBool(value)
+	case StringFlag:
+		return value, nil
+	case IntFlag:
+		return strconv.ParseInt(value, 0, 64)
+	case UintFlag:
+		return strconv.ParseUint(value, 0, 64)
+	default:
+		return nil, errors.Errorf("unsupported flag type %d", int(kind))
+	}
+}
+
+// EngineFlag declares an engine flag that is supported by an EngineRuntime in the Flags of a ManifestEngine.
+// Flags are specified in a Manifest by their name for boolean flags (such as "debug") or as "name=value".
+type EngineFlag struct {
+	// Name is the unique name of the flag (such as "fuel")
+	Name string
+	// Type is the type of the value of the flag
+	Type FlagType
+	// Default is the text form of the value of the flag when it is not specified.
+	// If it is empty, the flag defaults to the zero value of its type.
+	Default string
+	// Description is a human-readable description of the flag
+	Description string
+}
+
+// FlagDeclarer is an optional interface that can be implemented by an EngineRuntime to declare the engine flags
+// that it supports. The declared flags are the only description of the flags supported by a runtime, from which
+// the Flags of its RuntimeCapabilities are derived. The flags of Manifests for runtimes that declare them are
+// validated when the Manifest is decoded and by CheckCapabilities, and can be parsed into EngineFlags with
+// ParseEngineFlags or ManifestEngine.ParseFlags (such as in CompileManifest).
+// The flags of Manifests for runtimes that do not implement it are not validated.
+type FlagDeclarer interface {
+	DeclaredFlags() []EngineFlag
+}
+
+// FlagsOf returns the engine flags declared by the EngineRuntime.
+// Returns false if the runtime does not implement the FlagDeclarer interface.
+func FlagsOf(runtime EngineRuntime) ([]EngineFlag, bool) {
+	declarer, ok := runtime.(FlagDeclarer)
+	if !ok {
+		return nil, false
+	}
+
+	return declarer.DeclaredFlags(), true
+}
+
+// ParseEngineFlags parses the engine flags of the Manifest with the flags declared by the EngineRuntime that
+// it resolves to in the Registry. Returns an error if the runtime cannot be resolved, if it does not declare
+// its engine flags (see FlagDeclarer) or if the flags of the Manifest are invalid for the declarations.
+func (registry *Registry) ParseEngineFlags(manifest *Manifest) (*EngineFlags, error) {
+	runtime, err := registry.ResolveEngineRuntime(manifest.Header().LogicEngine(), manifest.Engine.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	declared, ok := FlagsOf(runtime)
+	if !ok {
+		return nil, errors.Errorf("runtime %v@%v does not declare engine flags", runtime.Kind(), runtime.Version())
+	}
+
+	return manifest.Engine.ParseFlags(declared)
+}
+
+// ParseEngineFlags parses the engine flags of the Manifest with the flags declared by the
+// EngineRuntime that it resolves to in the default Registry. See Registry.ParseEngineFlags.
+func ParseEngineFlags(manifest *Manifest) (*EngineFlags, error) {
+	return defaultRegistry.ParseEngineFlags(manifest)
+}
+
+// EngineFlags is a set of engine flags of a ManifestEngine that have been parsed with their declarations.
+// It is created with ParseEngineFlags (or ManifestEngine.ParseFlags) and provides the typed value of
+// every declared flag, which is its default value if it was not specified in the Manifest.
+type EngineFlags struct {
+	declared map[string]EngineFlag
+	values   map[string]any
+	set      map[string]struct{}
+}
+
+// ParseFlags parses the engine flags of the ManifestEngine with the declarations of the supported flags.
+// Empty flags are ignored and flags that are specified more than once must have the same value.
+//
+// Returns an error if a flag is not declared, if the value of a flag is missing or malformed for its
+// type, if a flag is specified with conflicting values or if the declarations are invalid.
+func (engine ManifestEngine) ParseFlags(declared []EngineFlag) (*EngineFlags, error) {
+	flags := &EngineFlags{
+		declared: make(map[string]EngineFlag, len(declared)),
+		values:   make(map[string]any, len(declared)),
+		set:      make(map[string]struct{}),
+	}
+
+	for _, declaration := range declared {
+		if declaration.Name == "" || strings.ContainsAny(declaration.Name, "= \t") {
+			return nil, errors.Errorf("invalid engine flag name '%v'", declaration.Name)
+		}
+
+		if _, exists := flags.declared[declaration.Name]; exists {
+			return nil, errors.Errorf("engine flag '%v' is declared more than once", declaration.Name)
+		}
+
+		value, err := declaration.defaultValue()
+		if err != nil {
+			return nil, err
+		}
+
+		flags.declared[declaration.Name] = declaration
+		flags.values[declaration.Name] = value
+	}
+
+	for _, flag := range engine.Flags {
+		flag = strings.TrimSpace(flag)
+		if flag == "" {
+			continue
+		}
+
+		name, text, hasValue := strings.Cut(flag, "=")
+
+		declaration, ok := flags.declared[name]
+		if !ok {
+			return nil, errors.Errorf("unknown engine flag '%v'", name)
+		}
+
+		if !hasValue {
+			if declaration.Type != BoolFlag {
+				return nil, errors.Errorf("engine flag '%v' requires a value of type %v", name, declaration.Type)
+			}
+
+			text = "true"
+		}
+
+		value, err := declaration.Type.parse(text)
+		if err != nil {
+			return nil, errors.Errorf("invalid value '%v' for engine flag '%v' of type %v", text, name, declaration.Type)
+		}
+
+		if _, exists := flags.set[name]; exists && flags.values[name] != value {
+			return nil, errors.Errorf("engine flag '%v' is specified with conflicting values", name)
+		}
+
+		flags.values[name] = value
+		flags.set[name] = struct{}{}
+	}
+
+	return flags, nil
+}
+
+// defaultValue returns the parsed default value of the EngineFlag
+func (flag EngineFlag) defaultValue() (any, error) {
+	text := flag.Default
+	if text == "" {
+		switch flag.Type {
+		case BoolFlag:
+			text = "false"
+		case IntFlag, UintFlag:
+			text = "0"
+		}
+	}
+
+	value, err := flag.Type.parse(text)
+	if err != nil {
+		return nil, errors.Errorf("invalid default value '%v' for engine flag '%v' of type %v", text, flag.Name, flag.Type)
+	}
+
+	return value, nil
+}
+
+// Names returns the names of all the declared flags in sorted order
+func (flags *EngineFlags) Names() []string {
+	names := make([]string, 0, len(flags.declared))
+	for name := range flags.declared {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Declaration returns the declaration of a flag. Returns false if the flag is not declared.
+func (flags *EngineFlags) Declaration(name string) (EngineFlag, bool) {
+	declaration, ok := flags.declared[name]
+
+	return declaration, ok
+}
+
+// IsSet returns whether a flag was specified in the ManifestEngine, rather than having its default value
+func (flags *EngineFlags) IsSet(name string) bool {
+	_, ok := flags.set[name]
+
+	return ok
+}
+
+// Lookup returns the value of a flag, which is a bool, string, int64 or uint64 based on its FlagType.
+// Returns false if the flag is not declared.
+func (flags *EngineFlags) Lookup(name string) (any, bool) {
+	value, ok := flags.values[name]
+
+	return value, ok
+}
+
+// Bool returns the value of a BoolFlag. Returns false if the flag is not declared as a BoolFlag.
+func (flags *EngineFlags) Bool(name string) bool {
+	value, _ := flags.values[name].(bool)
+
+	return value
+}
+
+// String returns the value of a StringFlag. Returns an empty string if the flag is not declared as a StringFlag.
+func (flags *EngineFlags) String(name string) string {
+	value, _ := flags.values[name].(string)
+
+	return value
+}
+
+// Int returns the value of an IntFlag. Returns 0 if the flag is not declared as an IntFlag.
+func (flags *EngineFlags) Int(name string) int64 {
+	value, _ := flags.values[name].(int64)
+
+	return value
+}
+
+// Uint returns the value of a UintFlag. Returns 0 if the flag is not declared as a UintFlag.
+func (flags *EngineFlags) Uint(name string) uint64 {
+	value, _ := flags.values[name].(uint64)
+
+	return value
+}
@@ -0,0 +1,157 @@
+package engineio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// flaggedRuntime is a mock runtime that declares its engine flags
+type flaggedRuntime struct {
+	*mockEngineRuntime
+}
+
+func (flaggedRuntime) DeclaredFlags() []EngineFlag {
+	return []EngineFlag{
+		{Name: "debug", Type: BoolFlag, Description: "Compile with debug symbols"},
+		{Name: "target", Type: StringFlag, Default: "native"},
+		{Name: "level", Type: IntFlag, Default: "-1"},
+		{Name: "fuel", Type: UintFlag},
+	}
+}
+
+func TestManifestEngine_ParseFlags(t *testing.T) {
+	declared := flaggedRuntime{}.DeclaredFlags()
+
+	flags, err := ManifestEngine{Flags: []string{"debug", " fuel=0x10 ", "", "level=3", "debug=true"}}.ParseFlags(declared)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"debug", "fuel", "level", "target"}, flags.Names())
+	require.True(t, flags.Bool("debug"))
+	require.Equal(t, uint64(16), flags.Uint("fuel"))
+	require.Equal(t, int64(3), flags.Int("level"))
+	require.Equal(t, "native", flags.String("target"))
+
+	require.True(t, flags.IsSet("level"))
+	require.False(t, flags.IsSet("target"))
+
+	declaration, ok := flags.Declaration("debug")
+	require.True(t, ok)
+	require.Equal(t, declared[0], declaration)
+
+	value, ok := flags.Lookup("target")
+	require.True(t, ok)
+	require.Equal(t, "native", value)
+
+	_, ok = flags.Lookup("missing")
+	require.False(t, ok)
+
+	// Typed accessors return zero values for flags of other types
+	require.False(t, flags.Bool("target"))
+	require.Zero(t, flags.Uint("level"))
+
+	// Flags that are not specified have their default value
+	flags, err = ManifestEngine{}.ParseFlags(declared)
+	require.NoError(t, err)
+	require.False(t, flags.Bool("debug"))
+	require.Equal(t, int64(-1), flags.Int("level"))
+	require.Zero(t, flags.Uint("fuel"))
+}
+
+func TestManifestEngine_ParseFlags_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    []string
+		declared []EngineFlag
+		err      string
+	}{
+		{"unknown flag", []string{"optimize"}, nil, "unknown engine flag 'optimize'"},
+		{"missing value", []string{"fuel"}, nil, "engine flag 'fuel' requires a value of type uint"},
+		{"malformed uint", []string{"fuel=-5"}, nil, "invalid value '-5' for engine flag 'fuel' of type uint"},
+		{"malformed bool", []string{"debug=yes"}, nil, "invalid value 'yes' for engine flag 'debug' of type bool"},
+		{
+			"conflicting values",
+			[]string{"debug", "debug=false"}, nil,
+			"engine flag 'debug' is specified with conflicting values",
+		},
+		{
+			"duplicate declaration",
+			nil, []EngineFlag{{Name: "debug", Type: BoolFlag}},
+			"engine flag 'debug' is declared more than once",
+		},
+		{"invalid name", nil, []EngineFlag{{Name: "a=b"}}, "invalid engine flag name 'a=b'"},
+		{
+			"invalid default",
+			nil, []EngineFlag{{Name: "count", Type: IntFlag, Default: "many"}},
+			"invalid default value 'many' for engine flag 'count' of type int",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			declared := append(flaggedRuntime{}.DeclaredFlags(), test.declared...)
+
+			_, err := ManifestEngine{Flags: test.flags}.ParseFlags(declared)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestRegistry_NewManifest_Flags(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(flaggedRuntime{newMockRuntime("FLAGGED", "0.1.0")}, nil)
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	decode := func(kind string, flags ...string) error {
+		manifest := newMockManifest(kind)
+		manifest.Engine.Flags = flags
+
+		encoded, err := manifest.Encode(JSON)
+		require.NoError(t, err)
+
+		_, err = registry.NewManifest(encoded, JSON)
+
+		return err
+	}
+
+	require.NoError(t, decode("FLAGGED", "debug", "target=wasm"))
+
+	// The flags of a manifest are parsed on demand with the flags declared by its runtime
+	manifest := newMockManifest("FLAGGED")
+	manifest.Engine.Flags = []string{"debug", "fuel=7"}
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		encoded, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		decoded, err := registry.NewManifest(encoded, encoding)
+		require.NoError(t, err)
+		require.Equal(t, manifest, *decoded)
+
+		flags, err := registry.ParseEngineFlags(decoded)
+		require.NoError(t, err, "encoding: %v", encoding)
+		require.True(t, flags.Bool("debug"))
+		require.Equal(t, uint64(7), flags.Uint("fuel"))
+		require.Equal(t, "native", flags.String("target"))
+	}
+
+	require.EqualError(t, decode("FLAGGED", "debgu"), "invalid manifest engine flags: unknown engine flag 'debgu'")
+	require.EqualError(t, decode("FLAGGED", "fuel=lots"),
+		"invalid manifest engine flags: invalid value 'lots' for engine flag 'fuel' of type uint")
+
+	// The flags of runtimes that do not declare them are not validated or parsed
+	require.NoError(t, decode("MOCK", "debgu"))
+
+	undeclared := newMockManifest("MOCK")
+
+	_, err := registry.ParseEngineFlags(&undeclared)
+	require.EqualError(t, err, "runtime MOCK@0.1.0 does not declare engine flags")
+
+	unregistered := newMockManifest("MISSING")
+
+	_, err = registry.ParseEngineFlags(&unregistered)
+	require.EqualError(t, err, "unknown engine 'MISSING'")
+
+	_, ok := FlagsOf(newMockRuntime("MOCK", "0.1.0"))
+	require.False(t, ok)
+}
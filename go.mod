@@ -3,6 +3,7 @@ module github.com/sarvalabs/go-moi-engineio
 go 1.18
 
 require (
+	github.com/klauspost/compress v1.16.7
 	github.com/pkg/errors v0.9.1
 	github.com/sarvalabs/go-moi-identifiers v0.1.0
 	github.com/sarvalabs/go-polo v0.4.1
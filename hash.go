@@ -0,0 +1,276 @@
+package engineio
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// Hash is a 256-bit checksum digest.
+//
+// It is encoded as a 0x-prefixed hex string in its text, JSON and YAML
+// forms and as a 32-byte word (identical to a [32]byte) in its POLO form.
+type Hash [32]byte
+
+// ParseHash parses a hex string with an optional 0x prefix into a Hash.
+// Returns an error if the string is not valid hex or is not 32 bytes long.
+func ParseHash(str string) (Hash, error) {
+	decoded, err := decodeHex(str)
+	if err != nil {
+		return Hash{}, errors.Wrap(err, "invalid hash")
+	}
+
+	return hashFromBytes(decoded)
+}
+
+// String implements the Stringer interface for Hash.
+// Returns the 0x-prefixed hex encoded form of the Hash.
+func (hash Hash) String() string {
+	return encodeHex(hash[:])
+}
+
+// Bytes returns the Hash as a byte slice
+func (hash Hash) Bytes() []byte {
+	return append(make([]byte, 0, len(hash)), hash[:]...)
+}
+
+// IsZero returns whether all bytes of the Hash are zero
+func (hash Hash) IsZero() bool {
+	return hash == Hash{}
+}
+
+// MarshalText implements the encoding.TextMarshaler interface for Hash
+func (hash Hash) MarshalText() ([]byte, error) {
+	return []byte(hash.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface for Hash
+func (hash *Hash) UnmarshalText(text []byte) (err error) {
+	*hash, err = ParseHash(string(text))
+
+	return err
+}
+
+// Polorize implements the polo.Polorizable interface for Hash
+func (hash Hash) Polorize() (*polo.Polorizer, error) {
+	polorizer := polo.NewPolorizer()
+	polorizer.PolorizeBytes(hash[:])
+
+	return polorizer, nil
+}
+
+// Depolorize implements the polo.Depolorizable interface for Hash
+func (hash *Hash) Depolorize(depolorizer *polo.Depolorizer) error {
+	decoded, err := depolorizer.DepolorizeBytes()
+	if errors.Is(err, polo.ErrNullPack) {
+		*hash = Hash{}
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	*hash, err = hashFromBytes(decoded)
+
+	return err
+}
+
+// hashFromBytes returns the Hash for a byte slice that must be exactly 32 bytes long
+func hashFromBytes(data []byte) (Hash, error) {
+	var hash Hash
+	if len(data) != len(hash) {
+		return Hash{}, errors.Errorf("invalid hash: expected %v bytes, got %v", len(hash), len(data))
+	}
+
+	copy(hash[:], data)
+
+	return hash, nil
+}
+
+// HashAlgorithm is an enum with variants that describe the digest algorithms with which Manifests can be
+// hashed. The value of each variant is its code in the multicodec table, with which its digests are tagged.
+type HashAlgorithm uint64
+
+const (
+	SHA256     HashAlgorithm = 0x12
+	Keccak256  HashAlgorithm = 0x1b
+	Blake2b256 HashAlgorithm = 0xb220
+)
+
+// hashAlgorithms are the names of the supported digest algorithms in the multicodec table
+var hashAlgorithms = map[HashAlgorithm]string{
+	SHA256:     "sha2-256",
+	Keccak256:  "keccak-256",
+	Blake2b256: "blake2b-256",
+}
+
+// ParseHashAlgorithm parses the multicodec name of a digest algorithm
+// (such as 'blake2b-256') into a HashAlgorithm. The name is case-insensitive.
+func ParseHashAlgorithm(str string) (HashAlgorithm, error) {
+	name := strings.ToLower(strings.TrimSpace(str))
+
+	for algorithm, known := range hashAlgorithms {
+		if known == name {
+			return algorithm, nil
+		}
+	}
+
+	return 0, errors.Errorf("unsupported hash algorithm '%v'", str)
+}
+
+// String implements the Stringer interface for HashAlgorithm.
+// Returns the multicodec name of the algorithm.
+func (algorithm HashAlgorithm) String() string {
+	if name, ok := hashAlgorithms[algorithm]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// Supported returns whether the HashAlgorithm is a supported digest algorithm
+func (algorithm HashAlgorithm) Supported() bool {
+	_, ok := hashAlgorithms[algorithm]
+
+	return ok
+}
+
+// Sum returns the Digest of the data for the HashAlgorithm.
+// Returns an error if the algorithm is not supported.
+func (algorithm HashAlgorithm) Sum(data []byte) (Digest, error) {
+	var hash Hash
+
+	switch algorithm {
+	case SHA256:
+		hash = sha256.Sum256(data)
+
+	case Keccak256:
+		hasher := sha3.NewLegacyKeccak256()
+		hasher.Write(data)
+		copy(hash[:], hasher.Sum(nil))
+
+	case Blake2b256:
+		hash = blake2b.Sum256(data)
+
+	default:
+		return Digest{}, errors.Errorf("unsupported hash algorithm '%v'", uint64(algorithm))
+	}
+
+	return Digest{Algorithm: algorithm, Hash: hash}, nil
+}
+
+// Digest is a Hash tagged with the HashAlgorithm that produced it, so that it can be verified by other chains and
+// tooling. It is encoded in the multihash format: the multicodec code of the algorithm and the length of the hash
+// (as unsigned varints), followed by the hash. Its text, JSON and YAML forms are the 0x-prefixed hex encoded
+// multihash bytes and its POLO form is the multihash bytes.
+type Digest struct {
+	Algorithm HashAlgorithm
+	Hash      Hash
+}
+
+// DecodeDigest decodes multihash bytes into a Digest.
+// Returns an error if the algorithm is not supported or the bytes are malformed.
+func DecodeDigest(data []byte) (Digest, error) {
+	code, read := binary.Uvarint(data)
+	if read <= 0 {
+		return Digest{}, errors.New("invalid digest: malformed algorithm code")
+	}
+
+	algorithm := HashAlgorithm(code)
+	if !algorithm.Supported() {
+		return Digest{}, errors.Errorf("invalid digest: unsupported hash algorithm '%v'", code)
+	}
+
+	data = data[read:]
+
+	length, read := binary.Uvarint(data)
+	if read <= 0 {
+		return Digest{}, errors.New("invalid digest: malformed hash length")
+	}
+
+	if data = data[read:]; uint64(len(data)) != length {
+		return Digest{}, errors.Errorf("invalid digest: expected %v bytes of hash, got %v", length, len(data))
+	}
+
+	hash, err := hashFromBytes(data)
+	if err != nil {
+		return Digest{}, errors.Wrap(err, "invalid digest")
+	}
+
+	return Digest{Algorithm: algorithm, Hash: hash}, nil
+}
+
+// ParseDigest parses the hex encoded multihash bytes with an optional 0x prefix into a Digest
+func ParseDigest(str string) (Digest, error) {
+	decoded, err := decodeHex(str)
+	if err != nil {
+		return Digest{}, errors.Wrap(err, "invalid digest")
+	}
+
+	return DecodeDigest(decoded)
+}
+
+// Bytes returns the multihash bytes of the Digest
+func (digest Digest) Bytes() []byte {
+	encoded := make([]byte, 2*binary.MaxVarintLen64+len(digest.Hash))
+
+	size := binary.PutUvarint(encoded, uint64(digest.Algorithm))
+	size += binary.PutUvarint(encoded[size:], uint64(len(digest.Hash)))
+	size += copy(encoded[size:], digest.Hash[:])
+
+	return encoded[:size]
+}
+
+// String implements the Stringer interface for Digest.
+// Returns the 0x-prefixed hex encoded multihash bytes of the Digest.
+func (digest Digest) String() string {
+	return encodeHex(digest.Bytes())
+}
+
+// MarshalText implements the encoding.TextMarshaler interface for Digest
+func (digest Digest) MarshalText() ([]byte, error) {
+	return []byte(digest.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface for Digest
+func (digest *Digest) UnmarshalText(text []byte) (err error) {
+	*digest, err = ParseDigest(string(text))
+
+	return err
+}
+
+// Polorize implements the polo.Polorizable interface for Digest
+func (digest Digest) Polorize() (*polo.Polorizer, error) {
+	polorizer := polo.NewPolorizer()
+	polorizer.PolorizeBytes(digest.Bytes())
+
+	return polorizer, nil
+}
+
+// Depolorize implements the polo.Depolorizable interface for Digest
+func (digest *Digest) Depolorize(depolorizer *polo.Depolorizer) error {
+	decoded, err := depolorizer.DepolorizeBytes()
+	if err != nil {
+		return err
+	}
+
+	*digest, err = DecodeDigest(decoded)
+
+	return err
+}
+
+// encodeHex returns the 0x-prefixed hex encoded form of the data
+func encodeHex(data []byte) string {
+	return "0x" + hex.EncodeToString(data)
+}
+
+// decodeHex decodes a hex string with an optional 0x prefix
+func decodeHex(str string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(str, "0x"))
+}
@@ -0,0 +1,186 @@
+package engineio
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sarvalabs/go-polo"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestHash(t *testing.T) {
+	hash := Hash{0xde, 0xad, 0xbe, 0xef}
+	encoded := "0xdeadbeef00000000000000000000000000000000000000000000000000000000"
+
+	require.Equal(t, encoded, hash.String())
+	require.Equal(t, hash[:], hash.Bytes())
+	require.False(t, hash.IsZero())
+	require.True(t, Hash{}.IsZero())
+
+	parsed, err := ParseHash(encoded)
+	require.NoError(t, err)
+	require.Equal(t, hash, parsed)
+
+	parsed, err = ParseHash(encoded[2:])
+	require.NoError(t, err)
+	require.Equal(t, hash, parsed)
+
+	_, err = ParseHash("0xzz")
+	require.ErrorContains(t, err, "invalid hash")
+
+	_, err = ParseHash("0xdeadbeef")
+	require.EqualError(t, err, "invalid hash: expected 32 bytes, got 4")
+
+	t.Run("JSON", func(t *testing.T) {
+		data, err := json.Marshal(hash)
+		require.NoError(t, err)
+		require.Equal(t, `"`+encoded+`"`, string(data))
+
+		decoded := new(Hash)
+		require.NoError(t, json.Unmarshal(data, decoded))
+		require.Equal(t, hash, *decoded)
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		data, err := yaml.Marshal(map[string]Hash{"hash": hash})
+		require.NoError(t, err)
+		require.Equal(t, "hash: "+encoded+"\n", string(data))
+
+		decoded := make(map[string]Hash)
+		require.NoError(t, yaml.Unmarshal(data, &decoded))
+		require.Equal(t, hash, decoded["hash"])
+	})
+
+	t.Run("POLO", func(t *testing.T) {
+		// Hash must encode exactly like a [32]byte
+		array, err := polo.Polorize([32]byte(hash))
+		require.NoError(t, err)
+
+		data, err := polo.Polorize(hash)
+		require.NoError(t, err)
+		require.Equal(t, array, data)
+
+		decoded := new(Hash)
+		require.NoError(t, polo.Depolorize(decoded, data))
+		require.Equal(t, hash, *decoded)
+
+		short, err := polo.Polorize([]byte{1, 2})
+		require.NoError(t, err)
+		require.EqualError(t, polo.Depolorize(decoded, short), "invalid hash: expected 32 bytes, got 2")
+	})
+}
+
+func TestHashAlgorithm(t *testing.T) {
+	tests := []struct {
+		algorithm HashAlgorithm
+		name      string
+		digest    string
+	}{
+		{SHA256, "sha2-256", "0x1220e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{Keccak256, "keccak-256", "0x1b20c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{Blake2b256, "blake2b-256", "0xa0e402200e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.name, test.algorithm.String())
+			require.True(t, test.algorithm.Supported())
+
+			parsed, err := ParseHashAlgorithm(test.name)
+			require.NoError(t, err)
+			require.Equal(t, test.algorithm, parsed)
+
+			digest, err := test.algorithm.Sum(nil)
+			require.NoError(t, err)
+			require.Equal(t, test.algorithm, digest.Algorithm)
+			require.Equal(t, test.digest, digest.String())
+
+			decoded, err := ParseDigest(test.digest)
+			require.NoError(t, err)
+			require.Equal(t, digest, decoded)
+		})
+	}
+
+	require.Equal(t, "unknown", HashAlgorithm(0x13).String())
+	require.False(t, HashAlgorithm(0x13).Supported())
+
+	_, err := HashAlgorithm(0x13).Sum(nil)
+	require.EqualError(t, err, "unsupported hash algorithm '19'")
+
+	_, err = ParseHashAlgorithm("md5")
+	require.EqualError(t, err, "unsupported hash algorithm 'md5'")
+}
+
+func TestDigest(t *testing.T) {
+	digest := Digest{Algorithm: SHA256, Hash: Hash{1}}
+
+	t.Run("JSON", func(t *testing.T) {
+		data, err := json.Marshal(digest)
+		require.NoError(t, err)
+		require.Equal(t, `"`+digest.String()+`"`, string(data))
+
+		decoded := new(Digest)
+		require.NoError(t, json.Unmarshal(data, decoded))
+		require.Equal(t, digest, *decoded)
+	})
+
+	t.Run("POLO", func(t *testing.T) {
+		data, err := polo.Polorize(digest)
+		require.NoError(t, err)
+
+		decoded := new(Digest)
+		require.NoError(t, polo.Depolorize(decoded, data))
+		require.Equal(t, digest, *decoded)
+	})
+
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"not hex", "0xzz", "invalid digest: encoding/hex: invalid byte: U+007A 'z'"},
+		{"empty", "0x", "invalid digest: malformed algorithm code"},
+		{"unsupported algorithm", "0x1320", "invalid digest: unsupported hash algorithm '19'"},
+		{"missing length", "0x12", "invalid digest: malformed hash length"},
+		{"truncated hash", "0x122001", "invalid digest: expected 32 bytes of hash, got 1"},
+		{"short hash", "0x120101", "invalid digest: invalid hash: expected 32 bytes, got 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseDigest(test.data)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestManifest_Digest(t *testing.T) {
+	manifest := newMockManifest("mock")
+
+	encoded, err := manifest.Encode(POLO)
+	require.NoError(t, err)
+
+	hash, err := manifest.Hash()
+	require.NoError(t, err)
+
+	for _, algorithm := range []HashAlgorithm{SHA256, Keccak256, Blake2b256} {
+		expected, err := algorithm.Sum(encoded)
+		require.NoError(t, err)
+
+		digest, err := manifest.Digest(algorithm)
+		require.NoError(t, err)
+		require.Equal(t, expected, digest)
+
+		raw, err := manifest.Raw(POLO)
+		require.NoError(t, err)
+
+		rawDigest, err := raw.Digest(algorithm)
+		require.NoError(t, err)
+		require.Equal(t, digest, rawDigest)
+	}
+
+	digest, err := manifest.Digest(Blake2b256)
+	require.NoError(t, err)
+	require.Equal(t, hash, digest.Hash)
+}
@@ -0,0 +1,188 @@
+package engineio
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// ManifestIndex is an indexed view over the elements of a structurally valid Manifest.
+// It is created with Manifest.Index and allows compilers and analyzers to look up elements
+// and walk the element graph without scanning the elements of the Manifest each time.
+//
+// The index is a snapshot of the Manifest at the time it was created, later changes to
+// the Manifest are not reflected in it. The element data objects are shared with the Manifest.
+type ManifestIndex struct {
+	// elements are the elements of the Manifest mapped to their pointer
+	elements map[ElementPtr]ManifestElement
+	// kinds are the pointers of the elements of each kind in ascending order
+	kinds map[ElementKind][]ElementPtr
+	// dependents are the pointers of the elements that depend on each element in ascending order
+	dependents map[ElementPtr][]ElementPtr
+	// order are the pointers of the elements in topological order
+	order []ElementPtr
+}
+
+// Index returns a ManifestIndex for the elements of the Manifest.
+// Returns a *ManifestValidationError if the Manifest is not structurally valid (see Manifest.Validate).
+func (manifest Manifest) Index() (*ManifestIndex, error) {
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+
+	index := &ManifestIndex{
+		elements:   make(map[ElementPtr]ManifestElement, len(manifest.Elements)),
+		kinds:      make(map[ElementKind][]ElementPtr),
+		dependents: make(map[ElementPtr][]ElementPtr, len(manifest.Elements)),
+	}
+
+	for _, element := range manifest.Canonical().Elements {
+		index.elements[element.Ptr] = element
+		index.kinds[element.Kind] = append(index.kinds[element.Kind], element.Ptr)
+
+		for _, dep := range element.Deps {
+			index.dependents[dep] = append(index.dependents[dep], element.Ptr)
+		}
+	}
+
+	index.order = index.topologicalOrder()
+
+	return index, nil
+}
+
+// Len returns the number of elements in the ManifestIndex
+func (index *ManifestIndex) Len() int {
+	return len(index.elements)
+}
+
+// Element returns the element with the given pointer and whether it exists
+func (index *ManifestIndex) Element(ptr ElementPtr) (ManifestElement, bool) {
+	element, ok := index.elements[ptr]
+
+	return element, ok
+}
+
+// ElementsOfKind returns the elements of the given kind in the order of their pointers
+func (index *ManifestIndex) ElementsOfKind(kind ElementKind) []ManifestElement {
+	return index.lookup(index.kinds[kind])
+}
+
+// Dependents returns the pointers of the elements that directly depend on the element
+// with the given pointer in ascending order. Returns an empty slice if there are none.
+func (index *ManifestIndex) Dependents(ptr ElementPtr) []ElementPtr {
+	dependents := make([]ElementPtr, 0, len(index.dependents[ptr]))
+
+	return append(dependents, index.dependents[ptr]...)
+}
+
+// Closure returns the pointers of the elements that the element with the given pointer transitively
+// depends on in ascending order, excluding the element itself. Returns false if the element does not exist.
+func (index *ManifestIndex) Closure(ptr ElementPtr) ([]ElementPtr, bool) {
+	element, ok := index.elements[ptr]
+	if !ok {
+		return nil, false
+	}
+
+	closure := make([]ElementPtr, 0)
+	visited := map[ElementPtr]struct{}{ptr: {}}
+	pending := append(make([]ElementPtr, 0, len(element.Deps)), element.Deps...)
+
+	for len(pending) > 0 {
+		dep := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if _, seen := visited[dep]; seen {
+			continue
+		}
+
+		visited[dep] = struct{}{}
+		closure = append(closure, dep)
+		pending = append(pending, index.elements[dep].Deps...)
+	}
+
+	sort.Slice(closure, func(i, j int) bool { return closure[i] < closure[j] })
+
+	return closure, true
+}
+
+// Topological returns an ElementIterator over the elements in topological order, in which every element
+// comes after all of its dependencies. The order is deterministic: among the elements whose dependencies
+// have all been visited, the element with the lowest pointer always comes first.
+func (index *ManifestIndex) Topological() *ElementIterator {
+	return &ElementIterator{elements: index.lookup(index.order)}
+}
+
+// ElementIterator iterates over a sequence of manifest elements
+type ElementIterator struct {
+	elements []ManifestElement
+	position int
+}
+
+// Next returns the next element in the sequence of the
+// ElementIterator or false if there are no more elements
+func (iterator *ElementIterator) Next() (ManifestElement, bool) {
+	if iterator.position >= len(iterator.elements) {
+		return ManifestElement{}, false
+	}
+
+	iterator.position++
+
+	return iterator.elements[iterator.position-1], true
+}
+
+// lookup returns the elements for the given pointers
+func (index *ManifestIndex) lookup(ptrs []ElementPtr) []ManifestElement {
+	elements := make([]ManifestElement, 0, len(ptrs))
+	for _, ptr := range ptrs {
+		elements = append(elements, index.elements[ptr])
+	}
+
+	return elements
+}
+
+// topologicalOrder returns the pointers of the elements in topological order. Elements are visited with
+// Kahn's algorithm, always picking the lowest pointer among the elements whose dependencies have been visited.
+func (index *ManifestIndex) topologicalOrder() []ElementPtr {
+	order := make([]ElementPtr, 0, len(index.elements))
+	pending := make(map[ElementPtr]int, len(index.elements))
+	ready := make(ptrHeap, 0)
+
+	for ptr, element := range index.elements {
+		if pending[ptr] = len(element.Deps); pending[ptr] == 0 {
+			ready = append(ready, ptr)
+		}
+	}
+
+	heap.Init(&ready)
+
+	for ready.Len() > 0 {
+		ptr, _ := heap.Pop(&ready).(ElementPtr)
+		order = append(order, ptr)
+
+		for _, dependent := range index.dependents[ptr] {
+			if pending[dependent]--; pending[dependent] == 0 {
+				heap.Push(&ready, dependent)
+			}
+		}
+	}
+
+	return order
+}
+
+// ptrHeap is a min-heap of element pointers that implements the heap.Interface
+type ptrHeap []ElementPtr
+
+func (ptrs ptrHeap) Len() int           { return len(ptrs) }
+func (ptrs ptrHeap) Less(i, j int) bool { return ptrs[i] < ptrs[j] }
+func (ptrs ptrHeap) Swap(i, j int)      { ptrs[i], ptrs[j] = ptrs[j], ptrs[i] }
+
+func (ptrs *ptrHeap) Push(ptr any) {
+	*ptrs = append(*ptrs, ptr.(ElementPtr)) //nolint:forcetypeassert
+}
+
+func (ptrs *ptrHeap) Pop() any {
+	old := *ptrs
+	ptr := old[len(old)-1]
+	*ptrs = old[:len(old)-1]
+
+	return ptr
+}
@@ -0,0 +1,78 @@
+package engineio
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestIndex(t *testing.T) {
+	element := func(ptr ElementPtr, kind ElementKind, deps ...ElementPtr) ManifestElement {
+		return ManifestElement{Ptr: ptr, Deps: deps, Kind: kind, Data: &mockElement{Value: ptr}}
+	}
+
+	manifest := newMockManifest("mock")
+	manifest.Elements = []ManifestElement{
+		element(5, "routine", 2, 3),
+		element(3, "class"),
+		element(1, "routine", 5, 3),
+		element(2, "class", 4),
+		element(4, "state"),
+		element(0, "state"),
+	}
+
+	index, err := manifest.Index()
+	require.NoError(t, err)
+	require.Equal(t, 6, index.Len())
+
+	found, ok := index.Element(2)
+	require.True(t, ok)
+	require.Equal(t, element(2, "class", 4), found)
+
+	_, ok = index.Element(6)
+	require.False(t, ok)
+
+	routines := []ManifestElement{element(1, "routine", 3, 5), element(5, "routine", 2, 3)}
+	require.Equal(t, routines, index.ElementsOfKind("routine"))
+	require.Equal(t, []ManifestElement{}, index.ElementsOfKind("event"))
+
+	require.Equal(t, []ElementPtr{1, 5}, index.Dependents(3))
+	require.Equal(t, []ElementPtr{}, index.Dependents(1))
+
+	closure, ok := index.Closure(1)
+	require.True(t, ok)
+	require.Equal(t, []ElementPtr{2, 3, 4, 5}, closure)
+
+	closure, ok = index.Closure(0)
+	require.True(t, ok)
+	require.Equal(t, []ElementPtr{}, closure)
+
+	_, ok = index.Closure(6)
+	require.False(t, ok)
+
+	order := make([]ElementPtr, 0)
+	iterator := index.Topological()
+
+	for element, ok := iterator.Next(); ok; element, ok = iterator.Next() {
+		order = append(order, element.Ptr)
+	}
+
+	require.Equal(t, []ElementPtr{0, 3, 4, 2, 5, 1}, order)
+
+	// Modifying the results must not modify the index
+	dependents := index.Dependents(3)
+	dependents[0] = 7
+	require.Equal(t, []ElementPtr{1, 5}, index.Dependents(3))
+}
+
+func TestManifestIndex_Invalid(t *testing.T) {
+	manifest := newMockManifest("mock")
+	manifest.Elements[0].Deps = []ElementPtr{1}
+
+	_, err := manifest.Index()
+
+	validation := new(ManifestValidationError)
+	require.True(t, errors.As(err, &validation))
+	require.Equal(t, []ManifestIssue{{0, "dependency cycle: 0 -> 1 -> 0"}}, validation.Issues)
+}
@@ -0,0 +1,293 @@
+package engineio
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrUnknownEngine is returned when an EngineKind is neither declared as a
+	// known engine kind nor has a runtime registered for it in the Registry
+	ErrUnknownEngine = errors.New("unknown engine")
+	// ErrRuntimeNotRegistered is returned when an EngineKind is known
+	// but no runtime (or runtime version) is registered for it in the Registry
+	ErrRuntimeNotRegistered = errors.New("no runtime registered for engine")
+)
+
+// ParseEngineKind parses an EngineKind from a string. The string is trimmed and normalized
+// to uppercase, and must begin with a letter followed only by letters, digits, '_' or '-'.
+// The parsed EngineKind is not required to be a known engine kind (see LookupEngineKind).
+func ParseEngineKind(str string) (EngineKind, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(str))
+	if normalized == "" {
+		return "", errors.New("invalid engine kind: empty")
+	}
+
+	for idx, char := range normalized {
+		switch {
+		case char >= 'A' && char <= 'Z':
+		case idx > 0 && (char >= '0' && char <= '9' || char == '_' || char == '-'):
+		default:
+			return "", errors.Errorf("invalid engine kind '%v': unexpected character '%c'", str, char)
+		}
+	}
+
+	return EngineKind(normalized), nil
+}
+
+// String implements the Stringer interface for EngineKind
+func (kind EngineKind) String() string {
+	return string(kind)
+}
+
+// Known returns whether the EngineKind has been declared as a known engine kind
+func (kind EngineKind) Known() bool {
+	_, ok := LookupEngineKind(kind)
+
+	return ok
+}
+
+// normalize returns the EngineKind in its uppercase form
+func (kind EngineKind) normalize() EngineKind {
+	return EngineKind(strings.ToUpper(strings.TrimSpace(string(kind))))
+}
+
+// parseEngineKind parses an encoded EngineKind, allowing an empty string for the zero value
+func parseEngineKind(raw string) (EngineKind, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	return ParseEngineKind(raw)
+}
+
+// Polorize implements the polo.Polorizable interface for EngineKind.
+// The EngineKind is encoded in its normalized uppercase form.
+func (kind EngineKind) Polorize() (*polo.Polorizer, error) {
+	polorizer := polo.NewPolorizer()
+	polorizer.PolorizeString(string(kind.normalize()))
+
+	return polorizer, nil
+}
+
+// Depolorize implements the polo.Depolorizable interface for EngineKind.
+// The decoded value is validated and normalized with ParseEngineKind.
+func (kind *EngineKind) Depolorize(depolorizer *polo.Depolorizer) error {
+	raw, err := depolorizer.DepolorizeString()
+	if err != nil {
+		return err
+	}
+
+	parsed, err := parseEngineKind(raw)
+	if err != nil {
+		return err
+	}
+
+	*kind = parsed
+
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaller interface for EngineKind.
+// The EngineKind is encoded in its normalized uppercase form.
+func (kind EngineKind) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(kind.normalize()))
+}
+
+// UnmarshalJSON implements the json.Unmarshaller interface for EngineKind.
+// The decoded value is validated and normalized with ParseEngineKind.
+func (kind *EngineKind) UnmarshalJSON(data []byte) error {
+	raw := new(string)
+	if err := json.Unmarshal(data, raw); err != nil {
+		return err
+	}
+
+	parsed, err := parseEngineKind(*raw)
+	if err != nil {
+		return err
+	}
+
+	*kind = parsed
+
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaller interface for EngineKind.
+// The EngineKind is encoded in its normalized uppercase form.
+func (kind EngineKind) MarshalYAML() (interface{}, error) {
+	return string(kind.normalize()), nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaller interface for EngineKind.
+// The decoded value is validated and normalized with ParseEngineKind.
+func (kind *EngineKind) UnmarshalYAML(node *yaml.Node) error {
+	raw := new(string)
+	if err := node.Decode(raw); err != nil {
+		return err
+	}
+
+	parsed, err := parseEngineKind(*raw)
+	if err != nil {
+		return err
+	}
+
+	*kind = parsed
+
+	return nil
+}
+
+// EngineStatus represents the development status of an engine kind
+type EngineStatus int
+
+const (
+	StableEngine EngineStatus = iota
+	ExperimentalEngine
+	ProposedEngine
+	DeprecatedEngine
+)
+
+var engineStatusToString = map[EngineStatus]string{
+	StableEngine:       "stable",
+	ExperimentalEngine: "experimental",
+	ProposedEngine:     "proposed",
+	DeprecatedEngine:   "deprecated",
+}
+
+var engineStatusFromString = map[string]EngineStatus{
+	"stable":       StableEngine,
+	"experimental": ExperimentalEngine,
+	"proposed":     ProposedEngine,
+	"deprecated":   DeprecatedEngine,
+}
+
+// String implements the Stringer interface for EngineStatus
+func (status EngineStatus) String() string {
+	str, ok := engineStatusToString[status]
+	if !ok {
+		panic("unknown EngineStatus variant")
+	}
+
+	return str
+}
+
+// MarshalJSON implements the json.Marshaller interface for EngineStatus
+func (status EngineStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(status.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaller interface for EngineStatus
+func (status *EngineStatus) UnmarshalJSON(data []byte) error {
+	raw := new(string)
+	if err := json.Unmarshal(data, raw); err != nil {
+		return err
+	}
+
+	parsed, ok := engineStatusFromString[*raw]
+	if !ok {
+		return errors.New("invalid EngineStatus value")
+	}
+
+	*status = parsed
+
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaller interface for EngineStatus
+func (status EngineStatus) MarshalYAML() (interface{}, error) {
+	return status.String(), nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaller interface for EngineStatus
+func (status *EngineStatus) UnmarshalYAML(node *yaml.Node) error {
+	raw := new(string)
+	if err := node.Decode(raw); err != nil {
+		return err
+	}
+
+	parsed, ok := engineStatusFromString[*raw]
+	if !ok {
+		return errors.New("invalid EngineStatus value")
+	}
+
+	*status = parsed
+
+	return nil
+}
+
+// EngineKindInfo is the metadata of a known engine kind
+type EngineKindInfo struct {
+	Kind        EngineKind   `yaml:"kind" json:"kind"`
+	Description string       `yaml:"description" json:"description"`
+	Status      EngineStatus `yaml:"status" json:"status"`
+}
+
+// knownKinds is the set of declared engine kinds, keyed by their normalized EngineKind
+var knownKinds = struct {
+	mutex sync.RWMutex
+	kinds map[EngineKind]EngineKindInfo
+}{
+	kinds: map[EngineKind]EngineKindInfo{
+		PISA: {
+			Kind:        PISA,
+			Description: "PISA VM Runtime (https://github.com/sarvalabs/go-pisa)",
+			Status:      StableEngine,
+		},
+		MERU: {
+			Kind:        MERU,
+			Description: "WASI (WebAssembly) based VM Runtime",
+			Status:      ProposedEngine,
+		},
+	},
+}
+
+// DeclareEngineKind declares an engine kind as known with some metadata. PISA and MERU are declared by default.
+// Declared engine kinds allow errors to distinguish between an unknown engine kind (such as a typo in a Manifest)
+// and a known engine kind that has no runtime registered. If the engine kind is already declared, its metadata
+// is overwritten. Returns an error if the engine kind is invalid.
+func DeclareEngineKind(info EngineKindInfo) error {
+	kind, err := ParseEngineKind(string(info.Kind))
+	if err != nil {
+		return err
+	}
+
+	info.Kind = kind
+
+	knownKinds.mutex.Lock()
+	defer knownKinds.mutex.Unlock()
+
+	knownKinds.kinds[kind] = info
+
+	return nil
+}
+
+// LookupEngineKind returns the metadata of a declared engine kind. The
+// EngineKind is normalized before lookup. Returns false if it is not declared.
+func LookupEngineKind(kind EngineKind) (EngineKindInfo, bool) {
+	knownKinds.mutex.RLock()
+	defer knownKinds.mutex.RUnlock()
+
+	info, ok := knownKinds.kinds[kind.normalize()]
+
+	return info, ok
+}
+
+// KnownEngineKinds returns the metadata of every declared engine kind, sorted by their EngineKind
+func KnownEngineKinds() []EngineKindInfo {
+	knownKinds.mutex.RLock()
+	defer knownKinds.mutex.RUnlock()
+
+	infos := make([]EngineKindInfo, 0, len(knownKinds.kinds))
+	for _, info := range knownKinds.kinds {
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Kind < infos[j].Kind })
+
+	return infos
+}
@@ -0,0 +1,173 @@
+package engineio
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sarvalabs/go-polo"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseEngineKind(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  EngineKind
+		err   string
+	}{
+		{"PISA", PISA, ""},
+		{"pisa", PISA, ""},
+		{"  Meru\n", MERU, ""},
+		{"wasm_v2-beta", "WASM_V2-BETA", ""},
+		{"", "", "invalid engine kind: empty"},
+		{"   ", "", "invalid engine kind: empty"},
+		{"2PISA", "", "invalid engine kind '2PISA': unexpected character '2'"},
+		{"pi sa", "", "invalid engine kind 'pi sa': unexpected character ' '"},
+		{"pis@", "", "invalid engine kind 'pis@': unexpected character '@'"},
+	}
+
+	for _, test := range tests {
+		kind, err := ParseEngineKind(test.input)
+		if test.err != "" {
+			require.EqualError(t, err, test.err, "input: %q", test.input)
+
+			continue
+		}
+
+		require.NoError(t, err, "input: %q", test.input)
+		require.Equal(t, test.kind, kind)
+	}
+}
+
+func TestEngineKind_Serialization(t *testing.T) {
+	t.Run("POLO", func(t *testing.T) {
+		encoded, err := polo.Polorize(EngineKind("pisa"))
+		require.NoError(t, err)
+
+		// the encoding of the kind is identical to that of its normalized string
+		expected, err := polo.Polorize("PISA")
+		require.NoError(t, err)
+		require.Equal(t, expected, encoded)
+
+		decoded := new(EngineKind)
+		require.NoError(t, polo.Depolorize(decoded, encoded))
+		require.Equal(t, PISA, *decoded)
+
+		lowercase, _ := polo.Polorize("meru")
+		require.NoError(t, polo.Depolorize(decoded, lowercase))
+		require.Equal(t, MERU, *decoded)
+
+		malformed, _ := polo.Polorize("pi$a")
+		require.EqualError(t, polo.Depolorize(decoded, malformed), "invalid engine kind 'pi$a': unexpected character '$'")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		encoded, err := json.Marshal(EngineKind("pisa"))
+		require.NoError(t, err)
+		require.Equal(t, `"PISA"`, string(encoded))
+
+		decoded := new(EngineKind)
+		require.NoError(t, json.Unmarshal([]byte(`"meru"`), decoded))
+		require.Equal(t, MERU, *decoded)
+
+		// the zero value is preserved
+		require.NoError(t, json.Unmarshal([]byte(`""`), decoded))
+		require.Equal(t, EngineKind(""), *decoded)
+
+		require.EqualError(t, json.Unmarshal([]byte(`"pi$a"`), decoded),
+			"invalid engine kind 'pi$a': unexpected character '$'")
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		encoded, err := yaml.Marshal(EngineKind("pisa"))
+		require.NoError(t, err)
+		require.Equal(t, "PISA\n", string(encoded))
+
+		decoded := new(EngineKind)
+		require.NoError(t, yaml.Unmarshal([]byte("meru"), decoded))
+		require.Equal(t, MERU, *decoded)
+
+		require.EqualError(t, yaml.Unmarshal([]byte("pi$a"), decoded),
+			"invalid engine kind 'pi$a': unexpected character '$'")
+	})
+}
+
+func TestEngineStatus(t *testing.T) {
+	require.True(t, len(engineStatusFromString) == len(engineStatusToString))
+
+	for status, str := range engineStatusToString {
+		require.Equal(t, str, status.String())
+
+		encoded, err := json.Marshal(status)
+		require.NoError(t, err)
+		require.Equal(t, `"`+str+`"`, string(encoded))
+
+		decoded := new(EngineStatus)
+		require.NoError(t, json.Unmarshal(encoded, decoded))
+		require.Equal(t, status, *decoded)
+
+		encoded, err = yaml.Marshal(status)
+		require.NoError(t, err)
+
+		decoded = new(EngineStatus)
+		require.NoError(t, yaml.Unmarshal(encoded, decoded))
+		require.Equal(t, status, *decoded)
+	}
+
+	require.PanicsWithValue(t, "unknown EngineStatus variant", func() {
+		_ = EngineStatus(10).String()
+	})
+
+	require.EqualError(t, json.Unmarshal([]byte(`"retired"`), new(EngineStatus)), "invalid EngineStatus value")
+	require.EqualError(t, yaml.Unmarshal([]byte("retired"), new(EngineStatus)), "invalid EngineStatus value")
+}
+
+func TestDeclareEngineKind(t *testing.T) {
+	info, ok := LookupEngineKind(PISA)
+	require.True(t, ok)
+	require.Equal(t, StableEngine, info.Status)
+
+	info, ok = LookupEngineKind("meru")
+	require.True(t, ok)
+	require.Equal(t, MERU, info.Kind)
+	require.Equal(t, ProposedEngine, info.Status)
+
+	require.False(t, EngineKind("DECLARED").Known())
+
+	err := DeclareEngineKind(EngineKindInfo{Kind: "declared", Description: "custom runtime", Status: ExperimentalEngine})
+	require.NoError(t, err)
+	require.True(t, EngineKind("DECLARED").Known())
+
+	info, ok = LookupEngineKind("DECLARED")
+	require.True(t, ok)
+	require.Equal(t, EngineKindInfo{Kind: "DECLARED", Description: "custom runtime", Status: ExperimentalEngine}, info)
+
+	kinds := make([]EngineKind, 0)
+	for _, info := range KnownEngineKinds() {
+		kinds = append(kinds, info.Kind)
+	}
+
+	require.Subset(t, kinds, []EngineKind{"DECLARED", MERU, PISA})
+	require.IsIncreasing(t, kinds)
+
+	require.EqualError(t, DeclareEngineKind(EngineKindInfo{Kind: "bad kind"}),
+		"invalid engine kind 'bad kind': unexpected character ' '")
+
+	// declared kinds without a runtime are reported as not registered rather than unknown
+	manifest := newMockManifest("declared")
+
+	encoded, err := manifest.Encode(JSON)
+	require.NoError(t, err)
+
+	_, err = NewRegistry().NewManifest(encoded, JSON)
+	require.EqualError(t, err, "unsupported manifest engine: no runtime registered for engine 'DECLARED'")
+	require.ErrorIs(t, err, ErrRuntimeNotRegistered)
+
+	manifest.Engine.Kind = "pi$a"
+
+	encoded, err = manifest.Encode(JSON)
+	require.NoError(t, err)
+
+	_, err = NewRegistry().NewManifest(encoded, JSON)
+	require.EqualError(t, err, "unsupported manifest engine: invalid engine kind 'pi$a': unexpected character '$'")
+}
@@ -0,0 +1,302 @@
+package engineio
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+)
+
+// SymbolicElement is an optional interface that can be implemented by a ManifestElementObject
+// to declare the symbol that its element defines (such as the name of a class or a routine).
+// Elements with an empty symbol do not define a symbol. It is used by LinkManifests to detect
+// elements from different Manifests that define the same symbol for an element kind.
+type SymbolicElement interface {
+	ElementSymbol() string
+}
+
+// Relocation maps the pointers of the elements of a Manifest to their pointers in a linked Manifest
+type Relocation map[ElementPtr]ElementPtr
+
+// SymbolConflict describes an element that defines a symbol which is already
+// defined by a different element of the same kind in the linked Manifest
+type SymbolConflict struct {
+	// Kind is the element kind of the conflicting elements
+	Kind ElementKind
+	// Symbol is the symbol defined by both elements
+	Symbol string
+	// Ptr is the pointer of the element in the linked Manifest that defined the symbol first
+	Ptr ElementPtr
+	// Fragment is the index of the Manifest with the conflicting element
+	Fragment int
+	// FragmentPtr is the pointer of the conflicting element in its Manifest
+	FragmentPtr ElementPtr
+}
+
+// String implements the Stringer interface for SymbolConflict
+func (conflict SymbolConflict) String() string {
+	return fmt.Sprintf(
+		"%v symbol '%v' of element %v in fragment %v is already defined by element %v",
+		conflict.Kind, conflict.Symbol, conflict.FragmentPtr, conflict.Fragment, conflict.Ptr,
+	)
+}
+
+// ManifestLinkError is the error returned when Manifests cannot be linked because
+// of symbol conflicts. It contains every conflict that was found while linking.
+type ManifestLinkError struct {
+	Conflicts []SymbolConflict
+}
+
+// Error implements the error interface for ManifestLinkError
+func (err *ManifestLinkError) Error() string {
+	conflicts := make([]string, 0, len(err.Conflicts))
+	for _, conflict := range err.Conflicts {
+		conflicts = append(conflicts, conflict.String())
+	}
+
+	return fmt.Sprintf("symbol conflicts: %v", strings.Join(conflicts, "; "))
+}
+
+// LinkManifests merges the elements of multiple Manifest fragments (such as reusable libraries of
+// class definitions and routines) into a single Manifest. Each fragment must be structurally valid
+// (see Manifest.Validate) and all fragments must be for the same engine and syntax.
+//
+// The fragments are linked in order. Elements keep their pointer unless it is already used in the linked
+// Manifest, in which case they are relocated to the next unused pointer and the dependencies on them are
+// rewritten accordingly. An element that is identical to an element already in the linked Manifest (same kind,
+// same data and same relocated dependencies) is deduplicated into it. The engine flags of the fragments are
+// merged, and the fragments must not have conflicting engine version constraints or engine flag values.
+//
+// Returns the linked Manifest and the Relocation of each fragment (by index), or a *ManifestLinkError
+// with every conflict that was found if elements of different fragments define the same symbol
+// for an element kind (see SymbolicElement). The fragments are not modified, but the element data
+// objects are shared with the linked Manifest.
+func LinkManifests(fragments ...*Manifest) (*Manifest, []Relocation, error) {
+	if len(fragments) == 0 {
+		return nil, nil, errors.New("no manifests to link")
+	}
+
+	linked, err := linkHeaders(fragments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	linker := &manifestLinker{
+		used:       make(map[ElementPtr]struct{}),
+		identities: make(map[string]ElementPtr),
+		symbols:    make(map[ElementKind]map[string]ElementPtr),
+		conflicts:  make([]SymbolConflict, 0),
+	}
+
+	relocations := make([]Relocation, 0, len(fragments))
+
+	for idx, fragment := range fragments {
+		index, err := fragment.Index()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid manifest fragment %v", idx)
+		}
+
+		relocation, err := linker.link(idx, index, linked)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to link manifest fragment %v", idx)
+		}
+
+		relocations = append(relocations, relocation)
+	}
+
+	if len(linker.conflicts) > 0 {
+		return nil, nil, &ManifestLinkError{Conflicts: linker.conflicts}
+	}
+
+	// Elements are linked in dependency order, sort them by their pointer
+	sort.Slice(linked.Elements, func(i, j int) bool { return linked.Elements[i].Ptr < linked.Elements[j].Ptr })
+
+	return linked, relocations, nil
+}
+
+// linkHeaders returns an empty Manifest with the merged header of the fragments.
+// Returns an error if the fragments have different syntaxes, engines or version constraints,
+// or if they specify an engine flag with different values (such as 'fuel=10' and 'fuel=20').
+func linkHeaders(fragments []*Manifest) (*Manifest, error) {
+	linked := &Manifest{
+		Syntax: fragments[0].Syntax,
+		Engine: ManifestEngine{
+			Kind:  fragments[0].Engine.Kind,
+			Flags: make([]string, 0),
+		},
+		Elements: make([]ManifestElement, 0),
+	}
+
+	// flags maps the name of each engine flag in the linked Manifest to the flag
+	flags := make(map[string]string)
+
+	for idx, fragment := range fragments {
+		header := fragment.Header()
+
+		if header.Syntax != linked.Syntax {
+			return nil, errors.Errorf("manifest fragment %v has syntax '%v', expected '%v'", idx, header.Syntax, linked.Syntax)
+		}
+
+		if header.LogicEngine() != linked.Header().LogicEngine() {
+			return nil, errors.Errorf(
+				"manifest fragment %v has engine '%v', expected '%v'", idx, header.Engine.Kind, linked.Engine.Kind,
+			)
+		}
+
+		switch version := header.Engine.Version; {
+		case version == "":
+		case linked.Engine.Version == "":
+			linked.Engine.Version = version
+		case version != linked.Engine.Version:
+			return nil, errors.Errorf(
+				"manifest fragment %v has engine version '%v', expected '%v'", idx, version, linked.Engine.Version,
+			)
+		}
+
+		for _, flag := range header.Engine.Flags {
+			name, _, _ := strings.Cut(flag, "=")
+
+			existing, exists := flags[name]
+			if !exists {
+				flags[name] = flag
+				linked.Engine.Flags = append(linked.Engine.Flags, flag)
+
+				continue
+			}
+
+			if existing != flag {
+				return nil, errors.Errorf(
+					"manifest fragment %v has engine flag '%v', conflicting with '%v'", idx, flag, existing,
+				)
+			}
+		}
+	}
+
+	return linked, nil
+}
+
+// manifestLinker tracks the elements of a Manifest that is being linked
+type manifestLinker struct {
+	// used is the set of pointers in the linked Manifest, and next is the lowest
+	// pointer above all used pointers to which conflicting elements are relocated
+	used map[ElementPtr]struct{}
+	next ElementPtr
+
+	// identities maps the identity of each element in the linked Manifest to its pointer
+	identities map[string]ElementPtr
+	// symbols maps the symbols defined by the elements of each kind to their pointer
+	symbols map[ElementKind]map[string]ElementPtr
+
+	conflicts []SymbolConflict
+}
+
+// link adds the elements of a fragment to the linked Manifest and returns their relocation.
+// The elements are added in dependency order, so that the dependencies of each element are
+// relocated before the element itself and identical elements can be deduplicated.
+func (linker *manifestLinker) link(idx int, fragment *ManifestIndex, linked *Manifest) (Relocation, error) {
+	relocation := make(Relocation, fragment.Len())
+	elements := fragment.Topological()
+
+	for element, ok := elements.Next(); ok; element, ok = elements.Next() {
+		deps := make([]ElementPtr, 0, len(element.Deps))
+		for _, dep := range element.Deps {
+			deps = append(deps, relocation[dep])
+		}
+
+		deps = canonicalDeps(deps)
+
+		identity, err := elementIdentity(element.Kind, deps, element.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode data of element %v", element.Ptr)
+		}
+
+		if existing, ok := linker.identities[identity]; ok {
+			relocation[element.Ptr] = existing
+
+			continue
+		}
+
+		ptr := linker.allocate(element.Ptr)
+
+		relocation[element.Ptr] = ptr
+		linker.identities[identity] = ptr
+		linker.define(idx, ptr, element)
+
+		linked.Elements = append(linked.Elements, ManifestElement{
+			Ptr:  ptr,
+			Deps: deps,
+			Kind: element.Kind,
+			Data: element.Data,
+		})
+	}
+
+	return relocation, nil
+}
+
+// allocate returns the pointer for an element, which is its own pointer
+// if it is unused in the linked Manifest or the next unused pointer otherwise
+func (linker *manifestLinker) allocate(ptr ElementPtr) ElementPtr {
+	if _, used := linker.used[ptr]; used {
+		ptr = linker.next
+	}
+
+	linker.used[ptr] = struct{}{}
+
+	if ptr >= linker.next {
+		linker.next = ptr + 1
+	}
+
+	return ptr
+}
+
+// define records the symbol defined by an element that was added to the linked Manifest
+// at ptr, or a SymbolConflict if the symbol is already defined by another element
+func (linker *manifestLinker) define(idx int, ptr ElementPtr, element ManifestElement) {
+	symbolic, ok := element.Data.(SymbolicElement)
+	if !ok || symbolic.ElementSymbol() == "" {
+		return
+	}
+
+	symbol := symbolic.ElementSymbol()
+
+	if _, ok = linker.symbols[element.Kind]; !ok {
+		linker.symbols[element.Kind] = make(map[string]ElementPtr)
+	}
+
+	if existing, exists := linker.symbols[element.Kind][symbol]; exists {
+		linker.conflicts = append(linker.conflicts, SymbolConflict{
+			Kind:        element.Kind,
+			Symbol:      symbol,
+			Ptr:         existing,
+			Fragment:    idx,
+			FragmentPtr: element.Ptr,
+		})
+
+		return
+	}
+
+	linker.symbols[element.Kind][symbol] = ptr
+}
+
+// elementIdentity returns a key that is identical for elements with the same kind, dependencies and data
+func elementIdentity(kind ElementKind, deps []ElementPtr, data ManifestElementObject) (string, error) {
+	encoded, err := polo.Polorize(data)
+	if err != nil {
+		return "", err
+	}
+
+	// The identity is the POLO encoded tuple of the kind, dependencies and data,
+	// so that the identities of different elements can never be ambiguous
+	identity, err := polo.Polorize(struct {
+		Kind ElementKind
+		Deps []ElementPtr
+		Data polo.Any
+	}{kind, deps, encoded})
+	if err != nil {
+		return "", err
+	}
+
+	return string(identity), nil
+}
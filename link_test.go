@@ -0,0 +1,132 @@
+package engineio
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ElementSymbol implements the SymbolicElement interface for mockElement
+func (element mockElement) ElementSymbol() string {
+	return element.Name
+}
+
+func TestLinkManifests(t *testing.T) {
+	library := newMockManifest("mock")
+	library.Engine.Flags = []string{"lib"}
+
+	application := newMockManifest("mock")
+	application.Engine.Flags = []string{"app", "lib"}
+	application.Engine.Version = "^1.0.0"
+	application.Elements = []ManifestElement{
+		// Identical to element 0 of the library
+		{Ptr: 0, Deps: []ElementPtr{}, Kind: "mock", Data: &mockElement{Name: "foo", Value: 5}},
+		// Conflicts with the pointer of element 1 of the library
+		{Ptr: 1, Deps: []ElementPtr{0, 4}, Kind: "mock", Data: &mockElement{Name: "baz", Value: 1}},
+		// Does not conflict with any pointer of the library
+		{Ptr: 4, Deps: []ElementPtr{}, Kind: "mock", Data: &mockElement{Value: 2}},
+		// Identical to element 1 of the library, after relocating its dependencies
+		{Ptr: 6, Deps: []ElementPtr{0}, Kind: "mock", Data: &mockElement{Name: "bar", Value: 10}},
+	}
+
+	linked, relocations, err := LinkManifests(&library, &application)
+	require.NoError(t, err)
+
+	require.Equal(t, []Relocation{{0: 0, 1: 1}, {0: 0, 1: 5, 4: 4, 6: 1}}, relocations)
+	require.Equal(t, &Manifest{
+		Syntax: "0.1.0",
+		Engine: ManifestEngine{Kind: "mock", Flags: []string{"lib", "app"}, Version: "^1.0.0"},
+		Elements: []ManifestElement{
+			{Ptr: 0, Deps: []ElementPtr{}, Kind: "mock", Data: &mockElement{Name: "foo", Value: 5}},
+			{Ptr: 1, Deps: []ElementPtr{0}, Kind: "mock", Data: &mockElement{Name: "bar", Value: 10}},
+			{Ptr: 4, Deps: []ElementPtr{}, Kind: "mock", Data: &mockElement{Value: 2}},
+			{Ptr: 5, Deps: []ElementPtr{0, 4}, Kind: "mock", Data: &mockElement{Name: "baz", Value: 1}},
+		},
+	}, linked)
+
+	require.NoError(t, linked.Validate())
+
+	// The fragments must not be modified
+	require.Equal(t, []ElementPtr{0, 4}, application.Elements[1].Deps)
+}
+
+func TestLinkManifests_Conflicts(t *testing.T) {
+	library := newMockManifest("mock")
+
+	application := newMockManifest("mock")
+	application.Elements = []ManifestElement{
+		{Ptr: 0, Deps: []ElementPtr{}, Kind: "mock", Data: &mockElement{Name: "bar", Value: 1}},
+		{Ptr: 1, Deps: []ElementPtr{}, Kind: "mock", Data: &mockElement{Name: "foo", Value: 2}},
+		{Ptr: 2, Deps: []ElementPtr{}, Kind: "other", Data: &mockElement{Name: "foo", Value: 2}},
+	}
+
+	_, _, err := LinkManifests(&library, &application)
+
+	conflicts := new(ManifestLinkError)
+	require.True(t, errors.As(err, &conflicts))
+	require.Equal(t, []SymbolConflict{
+		{Kind: "mock", Symbol: "bar", Ptr: 1, Fragment: 1, FragmentPtr: 0},
+		{Kind: "mock", Symbol: "foo", Ptr: 0, Fragment: 1, FragmentPtr: 1},
+	}, conflicts.Conflicts)
+
+	require.EqualError(t, err, "symbol conflicts: "+
+		"mock symbol 'bar' of element 0 in fragment 1 is already defined by element 1; "+
+		"mock symbol 'foo' of element 1 in fragment 1 is already defined by element 0",
+	)
+}
+
+func TestLinkManifests_Errors(t *testing.T) {
+	fragment := func(modify func(*Manifest)) *Manifest {
+		manifest := newMockManifest("mock")
+		modify(&manifest)
+
+		return &manifest
+	}
+
+	tests := []struct {
+		name      string
+		fragments []*Manifest
+		err       string
+	}{
+		{"no fragments", nil, "no manifests to link"},
+		{
+			"syntax mismatch",
+			[]*Manifest{fragment(func(*Manifest) {}), fragment(func(m *Manifest) { m.Syntax = "0.2.0" })},
+			"manifest fragment 1 has syntax '0.2.0', expected '0.1.0'",
+		},
+		{
+			"engine mismatch",
+			[]*Manifest{fragment(func(*Manifest) {}), fragment(func(m *Manifest) { m.Engine.Kind = "other" })},
+			"manifest fragment 1 has engine 'other', expected 'mock'",
+		},
+		{
+			"version mismatch",
+			[]*Manifest{
+				fragment(func(m *Manifest) { m.Engine.Version = "^1.0.0" }),
+				fragment(func(m *Manifest) { m.Engine.Version = "^2.0.0" }),
+			},
+			"manifest fragment 1 has engine version '^2.0.0', expected '^1.0.0'",
+		},
+		{
+			"flag conflict",
+			[]*Manifest{
+				fragment(func(m *Manifest) { m.Engine.Flags = []string{"debug", "fuel=10"} }),
+				fragment(func(m *Manifest) { m.Engine.Flags = []string{"fuel=20"} }),
+			},
+			"manifest fragment 1 has engine flag 'fuel=20', conflicting with 'fuel=10'",
+		},
+		{
+			"invalid fragment",
+			[]*Manifest{fragment(func(m *Manifest) { m.Elements[0].Deps = []ElementPtr{1} })},
+			"invalid manifest fragment 0: invalid manifest: dependency cycle: 0 -> 1 -> 0 [ptr: 0]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := LinkManifests(test.fragments...)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package engineio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+)
+
+// StdinPath is the path that can be given to ReadManifestFile to read the Manifest from the standard input.
+// The encoding of a Manifest read from the standard input is always detected from its content.
+const StdinPath = "-"
+
+// MaxManifestSize is the maximum size of the (decompressed) manifest data that is read by DecodeManifest and
+// ReadManifestFile. It bounds the memory used for reading a manifest, including compressed manifests that
+// decompress into much larger data. Reading fails if the data exceeds it.
+const MaxManifestSize = 64 << 20
+
+// stdin is the reader for the standard input, replaceable for tests
+var stdin io.Reader = os.Stdin
+
+// maxManifestSize is the enforced MaxManifestSize, replaceable for tests
+var maxManifestSize int64 = MaxManifestSize
+
+var (
+	// gzipMagic is the header that begins every gzip compressed stream
+	gzipMagic = []byte{0x1f, 0x8b}
+	// zstdMagic is the header that begins every zstd compressed frame
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// compressionExtensions is the set of file extensions for compressed manifest files.
+// A compression extension is stripped from the path before its encoding extension is resolved.
+var compressionExtensions = map[string]struct{}{
+	".gz":   {},
+	".zst":  {},
+	".zstd": {},
+}
+
+// DecodeManifest reads the Manifest data of the specified encoding from the reader and decodes it.
+// The data is transparently decompressed if it is gzip or zstd compressed, and must not exceed
+// MaxManifestSize. The elements of the Manifest are decoded with the runtimes in the default Registry.
+func DecodeManifest(reader io.Reader, encoding Encoding) (*Manifest, error) {
+	return defaultRegistry.DecodeManifest(reader, encoding)
+}
+
+// DecodeManifest reads the Manifest data of the specified encoding from the reader and decodes it.
+// The data is transparently decompressed if it is gzip or zstd compressed, and must not exceed
+// MaxManifestSize. The elements of the Manifest are decoded with the runtimes in the Registry.
+func (registry *Registry) DecodeManifest(reader io.Reader, encoding Encoding) (*Manifest, error) {
+	data, err := readManifestData(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return registry.NewManifest(data, encoding)
+}
+
+// readManifest reads the Manifest data from the reader and decodes it with the encoding detected from its content
+func (registry *Registry) readManifest(reader io.Reader) (*Manifest, error) {
+	data, err := readManifestData(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding, err := detectEncoding(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return registry.NewManifest(data, encoding)
+}
+
+// readManifestData reads all the data from the reader (up to MaxManifestSize), decompressing
+// it if it begins with the magic header of a gzip stream or a zstd frame
+func readManifestData(reader io.Reader) ([]byte, error) {
+	buffered := bufio.NewReader(reader)
+
+	// A short read is not an error here, the data may simply be smaller than the magic header
+	magic, _ := buffered.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		decompressor, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress manifest data")
+		}
+
+		defer decompressor.Close()
+
+		data, err := readLimited(decompressor)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress manifest data")
+		}
+
+		return data, nil
+
+	case bytes.HasPrefix(magic, zstdMagic):
+		decompressor, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress manifest data")
+		}
+
+		defer decompressor.Close()
+
+		data, err := readLimited(decompressor)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress manifest data")
+		}
+
+		return data, nil
+
+	default:
+		data, err := readLimited(buffered)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read manifest data")
+		}
+
+		return data, nil
+	}
+}
+
+// readLimited reads all the data from the reader.
+// Returns an error if the data exceeds MaxManifestSize.
+func readLimited(reader io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxManifestSize {
+		return nil, errors.Errorf("manifest data exceeds the maximum size of %v bytes", maxManifestSize)
+	}
+
+	return data, nil
+}
+
+// detectEncoding detects the encoding of some (decompressed) manifest data from its content.
+//
+// POLO encoded Manifests always begin with the wire type of a pack, which is a control character that
+// cannot begin a JSON or YAML document. Text that begins with an object is detected as JSON, while
+// any other text is detected as YAML (which is also a superset of JSON).
+func detectEncoding(data []byte) (Encoding, error) {
+	if len(data) > 0 && data[0] == byte(polo.WirePack) {
+		return POLO, nil
+	}
+
+	text := bytes.TrimLeft(data, " \t\r\n")
+	if len(text) == 0 {
+		return 0, errors.New("unable to detect manifest encoding: empty data")
+	}
+
+	if !utf8.Valid(text) {
+		return 0, errors.New("unable to detect manifest encoding")
+	}
+
+	if text[0] == '{' {
+		return JSON, nil
+	}
+
+	return YAML, nil
+}
+
+// fileEncoding returns the encoding of a manifest file based on its extension, ignoring any compression
+// extension (such as the '.gz' of 'manifest.yaml.gz'). Returns false if the extension is missing or unknown.
+func fileEncoding(path string) (Encoding, bool) {
+	extension := strings.ToLower(filepath.Ext(path))
+	if _, compressed := compressionExtensions[extension]; compressed {
+		extension = strings.ToLower(filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path))))
+	}
+
+	return extensionEncoding(extension)
+}
@@ -0,0 +1,192 @@
+package engineio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	buffer := new(bytes.Buffer)
+	writer := gzip.NewWriter(buffer)
+
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buffer.Bytes()
+}
+
+func zstdData(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	defer encoder.Close()
+
+	return encoder.EncodeAll(data, nil)
+}
+
+func TestRegistry_DecodeManifest(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		encoded, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		for _, data := range [][]byte{encoded, gzipData(t, encoded), zstdData(t, encoded)} {
+			decoded, err := registry.DecodeManifest(bytes.NewReader(data), encoding)
+			require.NoError(t, err)
+			require.Equal(t, manifest, *decoded)
+		}
+	}
+
+	_, err := registry.DecodeManifest(bytes.NewReader([]byte{0x1f, 0x8b, 0x00}), JSON)
+	require.ErrorContains(t, err, "failed to decompress manifest data")
+}
+
+func TestRegistry_DecodeManifest_MaxSize(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+
+	encoded, err := manifest.Encode(JSON)
+	require.NoError(t, err)
+
+	previous := maxManifestSize
+	maxManifestSize = int64(len(encoded))
+
+	t.Cleanup(func() { maxManifestSize = previous })
+
+	// Data of exactly the maximum size is accepted
+	_, err = registry.DecodeManifest(bytes.NewReader(encoded), JSON)
+	require.NoError(t, err)
+
+	// Larger data is rejected, even if it is compressed into a much smaller size
+	padded := append(bytes.Repeat([]byte(" "), 1<<20), encoded...)
+
+	for _, data := range [][]byte{padded, gzipData(t, padded), zstdData(t, padded)} {
+		_, err = registry.DecodeManifest(bytes.NewReader(data), JSON)
+		require.ErrorContains(t, err, fmt.Sprintf("manifest data exceeds the maximum size of %v bytes", len(encoded)))
+	}
+}
+
+func TestRegistry_ReadManifestFile_Detection(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+	directory := t.TempDir()
+
+	tests := []struct {
+		name     string
+		encoding Encoding
+		compress func(*testing.T, []byte) []byte
+	}{
+		{"manifest.yml", YAML, nil},
+		{"manifest.YAML", YAML, nil},
+		{"manifest.yaml.gz", YAML, gzipData},
+		{"manifest.json.zst", JSON, zstdData},
+		{"manifest.polo.zstd", POLO, zstdData},
+		{"manifest", POLO, nil},
+		{"manifest.txt", JSON, nil},
+		{"manifest.out", YAML, nil},
+		{"manifest.gz", JSON, gzipData},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded, err := manifest.Encode(test.encoding)
+			require.NoError(t, err)
+
+			if test.compress != nil {
+				encoded = test.compress(t, encoded)
+			}
+
+			path := filepath.Join(directory, test.name)
+			require.NoError(t, os.WriteFile(path, encoded, 0o600))
+
+			decoded, err := registry.ReadManifestFile(path)
+			require.NoError(t, err)
+			require.Equal(t, manifest, *decoded)
+		})
+	}
+
+	// a mismatch between the extension and the content is not detected
+	encoded, err := manifest.Encode(YAML)
+	require.NoError(t, err)
+
+	path := filepath.Join(directory, "mismatch.json")
+	require.NoError(t, os.WriteFile(path, encoded, 0o600))
+
+	_, err = registry.ReadManifestFile(path)
+	require.ErrorContains(t, err, "failed to decode manifest file 'mismatch.json'")
+}
+
+func TestRegistry_ReadManifestFile_Stdin(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+
+	encoded, err := manifest.Encode(YAML)
+	require.NoError(t, err)
+
+	previous := stdin
+	t.Cleanup(func() { stdin = previous })
+
+	stdin = bytes.NewReader(gzipData(t, encoded))
+
+	decoded, err := registry.ReadManifestFile(StdinPath)
+	require.NoError(t, err)
+	require.Equal(t, manifest, *decoded)
+
+	stdin = bytes.NewReader(nil)
+
+	_, err = registry.ReadManifestFile(StdinPath)
+	require.EqualError(t, err, "failed to decode manifest from stdin: unable to detect manifest encoding: empty data")
+}
+
+func TestDetectEncoding(t *testing.T) {
+	polo, err := newMockManifest("MOCK").Encode(POLO)
+	require.NoError(t, err)
+
+	tests := []struct {
+		data     []byte
+		encoding Encoding
+		err      string
+	}{
+		{polo, POLO, ""},
+		{[]byte(`{"syntax": "0.1.0"}`), JSON, ""},
+		{[]byte("\n\t {\"syntax\": \"0.1.0\"}"), JSON, ""},
+		{[]byte("syntax: 0.1.0\n"), YAML, ""},
+		{[]byte("---\nsyntax: 0.1.0\n"), YAML, ""},
+		{[]byte(" \n "), 0, "unable to detect manifest encoding: empty data"},
+		{[]byte{0xff, 0xfe, 0x00}, 0, "unable to detect manifest encoding"},
+	}
+
+	for _, test := range tests {
+		encoding, err := detectEncoding(test.data)
+		if test.err != "" {
+			require.EqualError(t, err, test.err)
+
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, test.encoding, encoding)
+	}
+}
@@ -4,24 +4,12 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/sarvalabs/go-polo"
-	"golang.org/x/crypto/blake2b"
 	"gopkg.in/yaml.v3"
 )
 
-// Encoding is an enum with variants that describe
-// encoding schemes supported for file objects.
-type Encoding int
-
-const (
-	POLO Encoding = iota
-	JSON
-	YAML
-)
-
 // Manifest is the canonical deployment artifact for logics in MOI.
 //
 // It is a composite artifact that describes the bytecode, the binary interface (ABI) and
@@ -33,10 +21,59 @@ type Manifest struct {
 	Elements []ManifestElement `yaml:"elements" json:"elements"`
 }
 
-// ManifestEngine describes the engine specific information in the Manifest
+// ManifestEngine describes the engine specific information in the Manifest.
+//
+// The optional Version is a semver constraint (such as "^0.4.0") for the version of the engine runtime that
+// must be used to handle the Manifest. If it is not specified, the latest registered version of the runtime
+// is used. The Version is omitted from all encoded forms if it is empty, preserving the Manifest Hash.
 type ManifestEngine struct {
-	Kind  string   `yaml:"kind" json:"kind"`
-	Flags []string `yaml:"flags" json:"flags"`
+	Kind    string   `yaml:"kind" json:"kind"`
+	Flags   []string `yaml:"flags" json:"flags"`
+	Version string   `yaml:"version,omitempty" json:"version,omitempty"`
+}
+
+// Polorize implements the polo.Polorizable interface for ManifestEngine
+func (engine ManifestEngine) Polorize() (*polo.Polorizer, error) {
+	polorizer := polo.NewPolorizer()
+	polorizer.PolorizeString(engine.Kind)
+
+	if err := polorizer.Polorize(engine.Flags); err != nil {
+		return nil, err
+	}
+
+	// The version constraint is only encoded if it
+	// is set, to keep the encoding backward compatible
+	if engine.Version != "" {
+		polorizer.PolorizeString(engine.Version)
+	}
+
+	return polorizer, nil
+}
+
+// Depolorize implements the polo.Depolorizable interface for ManifestEngine
+func (engine *ManifestEngine) Depolorize(depolorizer *polo.Depolorizer) (err error) {
+	depolorizer, err = depolorizer.DepolorizePacked()
+	if errors.Is(err, polo.ErrNullPack) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if engine.Kind, err = depolorizer.DepolorizeString(); err != nil {
+		return err
+	}
+
+	if err = depolorizer.Depolorize(&engine.Flags); err != nil {
+		return err
+	}
+
+	if depolorizer.Done() {
+		return nil
+	}
+
+	engine.Version, err = depolorizer.DepolorizeString()
+
+	return err
 }
 
 // ManifestElement describes a single element in the Manifest.
@@ -65,63 +102,73 @@ type ManifestElementObject interface {
 type ManifestElementGenerator func() ManifestElementObject
 
 // NewManifest decodes the given raw data of the specified encoding type into a Manifest.
-// Fails if the encoding is unsupported or if the data is malformed.
+// Fails if the encoding or syntax version is unsupported, if the data is malformed or if the
+// engine flags are not supported by the runtime (for runtimes that implement FlagDeclarer).
+// Manifests of older syntax versions are migrated to the current syntax version.
+// The elements of the Manifest are decoded with the runtimes in the default Registry.
 func NewManifest(data []byte, encoding Encoding) (*Manifest, error) {
-	manifest := new(Manifest)
+	return defaultRegistry.NewManifest(data, encoding)
+}
 
-	switch encoding {
-	case JSON:
-		if err := json.Unmarshal(data, manifest); err != nil {
-			return nil, err
-		}
-	case POLO:
-		if err := polo.Depolorize(manifest, data); err != nil {
-			return nil, err
-		}
-	case YAML:
-		if err := yaml.Unmarshal(data, manifest); err != nil {
-			return nil, err
-		}
+// ReadManifestFile reads a file at the specified filepath (or the standard input for StdinPath)
+// and decodes it into a Manifest. See Registry.ReadManifestFile for how the encoding is determined.
+// The elements of the Manifest are decoded with the runtimes in the default Registry.
+func ReadManifestFile(path string) (*Manifest, error) {
+	return defaultRegistry.ReadManifestFile(path)
+}
 
-	default:
+// NewManifest decodes the given raw data of the specified encoding type into a Manifest.
+// Fails if the encoding or syntax version is unsupported, if the data is malformed or if the
+// engine flags are not supported by the runtime (for runtimes that implement FlagDeclarer).
+// Manifests of older syntax versions are migrated to the current syntax version.
+// The elements of the Manifest are decoded with the runtimes in the Registry.
+func (registry *Registry) NewManifest(data []byte, encoding Encoding) (*Manifest, error) {
+	format, ok := LookupEncoding(encoding)
+	if !ok {
 		return nil, errors.New("unsupported manifest encoding")
 	}
 
-	return manifest, nil
+	return format.Decode(data, registry)
 }
 
 // ReadManifestFile reads a file at the specified filepath and decodes it into a Manifest.
-// The encoding format of the file is determined from the file extension.
-func ReadManifestFile(path string) (*Manifest, error) {
+// The encoding format of the file is determined from the file extension ('.polo', '.json', '.yaml' or '.yml', or
+// the extensions of a registered EncodingFormat), or detected from its content (as POLO, JSON or YAML) if the
+// extension is missing or unknown. Files compressed with gzip or zstd (such as 'manifest.yaml.gz') are transparently
+// decompressed. The (decompressed) data of the file must not exceed MaxManifestSize. If the path is StdinPath,
+// the Manifest is read from the standard input instead. The elements of the Manifest are decoded with the
+// runtimes in the Registry.
+func (registry *Registry) ReadManifestFile(path string) (*Manifest, error) {
+	if path == StdinPath {
+		manifest, err := registry.readManifest(stdin)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode manifest from stdin")
+		}
+
+		return manifest, nil
+	}
+
 	path, _ = filepath.Abs(path)
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
 		return nil, errors.Errorf("manifest file not found @ '%v'", path)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest file")
 	}
 
-	var (
-		extension string
-		encoding  Encoding
-	)
-
-	switch extension = filepath.Ext(path); extension {
-	case ".json":
-		encoding = JSON
-	case ".polo":
-		encoding = POLO
-	case ".yaml":
-		encoding = YAML
-	default:
-		return nil, errors.Errorf("manifest file has unsupported extension: '%v'", extension)
-	}
+	defer file.Close()
 
-	encoded, err := os.ReadFile(path)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read manifest file")
+	var manifest *Manifest
+
+	if encoding, ok := fileEncoding(path); ok {
+		manifest, err = registry.DecodeManifest(file, encoding)
+	} else {
+		manifest, err = registry.readManifest(file)
 	}
 
-	manifest, err := NewManifest(encoded, encoding)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to decode %v manifest data", extension)
+		return nil, errors.Wrapf(err, "failed to decode manifest file '%v'", filepath.Base(path))
 	}
 
 	return manifest, nil
@@ -130,28 +177,83 @@ func ReadManifestFile(path string) (*Manifest, error) {
 // Hash returns the 256-bit hash of the Manifest.
 // The hash is derived by applying the Blake2b hashing
 // function on the POLO encoded bytes of the Manifest.
-func (manifest Manifest) Hash() ([32]byte, error) {
+// Use CanonicalHash for a hash that is independent of the element order.
+func (manifest Manifest) Hash() (Hash, error) {
+	digest, err := manifest.Digest(Blake2b256)
+	if err != nil {
+		return Hash{}, err
+	}
+
+	return digest.Hash, nil
+}
+
+// Digest returns the Digest of the Manifest for the given HashAlgorithm. It is derived by applying
+// the algorithm on the POLO encoded bytes of the Manifest, so the Digest for Blake2b256 has the same
+// hash as Manifest.Hash. Returns an error if the algorithm is not supported.
+func (manifest Manifest) Digest(algorithm HashAlgorithm) (Digest, error) {
 	encoded, err := manifest.Encode(POLO)
 	if err != nil {
-		return [32]byte{}, err
+		return Digest{}, err
+	}
+
+	return algorithm.Sum(encoded)
+}
+
+// EncodeSyntax returns the encoded bytes form of the Manifest for the specified encoding
+// after migrating it to the given syntax version (which can be older than that of the Manifest).
+//
+// Formats registered with RegisterEncoding encode decoded Manifests, so for them the Manifest is
+// migrated in its POLO form and its elements are decoded again into objects of the same types as the
+// element data of the Manifest before it is encoded (unless it is already of the given syntax version).
+// Migrations to such formats can therefore not introduce element kinds that the Manifest does not have.
+func (manifest Manifest) EncodeSyntax(encoding Encoding, syntax string) ([]byte, error) {
+	format, ok := LookupEncoding(encoding)
+	if !ok {
+		return nil, errors.New("unsupported manifest encoding")
+	}
+
+	builtin := encoding == POLO || encoding == JSON || encoding == YAML
+	if !builtin && manifest.Syntax == syntax {
+		return format.Encode(&manifest)
+	}
+
+	rawEncoding := encoding
+	if !builtin {
+		rawEncoding = POLO
+	}
+
+	raw, err := newRawManifest(manifest, rawEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	if raw, err = raw.migrate(syntax); err != nil {
+		return nil, err
 	}
 
-	return blake2b.Sum256(encoded), nil
+	if !builtin {
+		migrated := &Manifest{Syntax: raw.Syntax, Engine: raw.Engine}
+		if migrated.Elements, err = raw.decodeElements(manifest.elementGenerators()); err != nil {
+			return nil, err
+		}
+
+		return format.Encode(migrated)
+	}
+
+	_, target, _ := lookupSyntax(syntax)
+
+	return target.encode(raw, encoding)
 }
 
 // Encode returns the encoded bytes form of the Manifest for the specified encoding.
+// The Manifest is encoded in its own syntax version, use EncodeSyntax to target another.
 func (manifest Manifest) Encode(encoding Encoding) ([]byte, error) {
-	switch encoding {
-	case JSON:
-		return json.Marshal(manifest)
-	case POLO:
-		return polo.Polorize(manifest)
-	case YAML:
-		return yaml.Marshal(manifest)
-
-	default:
+	format, ok := LookupEncoding(encoding)
+	if !ok {
 		return nil, errors.New("unsupported manifest encoding")
 	}
+
+	return format.Encode(&manifest)
 }
 
 // Header returns the header information of the Manifest as a ManifestHeader
@@ -167,175 +269,127 @@ type ManifestHeader struct {
 	Engine ManifestEngine `yaml:"engine" json:"engine"`
 }
 
-// LogicEngine returns the normalized form of the logic engine value in the ManifestHeader.
-// It is capitalized to uppercase letter and converted into a types.LogicEngine
-func (header ManifestHeader) LogicEngine() EngineKind {
-	return EngineKind(strings.ToUpper(header.Engine.Kind))
-}
-
-func (header ManifestHeader) validate() error {
-	if header.Syntax != "0.1.0" {
-		return errors.New("unsupported manifest syntax")
-	}
+// DecodeManifestHeader decodes the ManifestHeader from the given raw manifest data of the specified encoding type.
+// Only the syntax and engine of the manifest are decoded, its elements are neither decoded nor validated and no
+// runtime needs to be registered for its engine. The syntax version and engine kind are returned as declared,
+// without being validated (see Registry.ValidateManifest or ParseEngineKind).
+func DecodeManifestHeader(data []byte, encoding Encoding) (ManifestHeader, error) {
+	header := ManifestHeader{}
 
-	if _, ok := FetchEngineRuntime(header.LogicEngine()); !ok {
-		return errors.New("unsupported manifest engine: element registry not found")
-	}
-
-	return nil
-}
-
-func (manifest *Manifest) Depolorize(depolorizer *polo.Depolorizer) (err error) {
-	type ManifestPOLO struct {
-		Syntax   string
-		Engine   ManifestEngine
-		Elements []struct {
-			Ptr  ElementPtr
-			Deps []ElementPtr
-			Kind ElementKind
-			Data polo.Any
+	switch encoding {
+	case JSON:
+		if err := json.Unmarshal(data, &header); err != nil {
+			return ManifestHeader{}, errors.Wrap(err, "failed to decode manifest header")
 		}
-	}
-
-	raw := new(ManifestPOLO)
-	if err = depolorizer.Depolorize(raw); err != nil {
-		return err
-	}
-
-	manifest.Syntax = raw.Syntax
-	manifest.Engine = raw.Engine
-
-	if err = manifest.Header().validate(); err != nil {
-		return err
-	}
 
-	runtime, _ := FetchEngineRuntime(manifest.Header().LogicEngine())
+	case YAML:
+		if err := yaml.Unmarshal(data, &header); err != nil {
+			return ManifestHeader{}, errors.Wrap(err, "failed to decode manifest header")
+		}
 
-	manifest.Elements = make([]ManifestElement, 0, len(raw.Elements))
+	case POLO:
+		// The header is decoded from the first two fields of the manifest
+		// pack, so that the elements that follow are never read
+		depolorizer, err := polo.NewDepolorizer(data)
+		if err != nil {
+			return ManifestHeader{}, errors.Wrap(err, "failed to decode manifest header")
+		}
 
-	for _, element := range raw.Elements {
-		generator, ok := runtime.GetElementGenerator(element.Kind)
-		if !ok {
-			return errors.Errorf("unrecognized element kind: '%v'", element.Kind)
+		if depolorizer, err = depolorizer.DepolorizePacked(); err != nil {
+			return ManifestHeader{}, errors.Wrap(err, "failed to decode manifest header")
 		}
 
-		elementDepolorizer, err := polo.NewDepolorizer(element.Data)
-		if err != nil {
-			return err
+		if header.Syntax, err = depolorizer.DepolorizeString(); err != nil {
+			return ManifestHeader{}, errors.Wrap(err, "failed to decode manifest header")
 		}
 
-		object := generator()
-		if err = object.Depolorize(elementDepolorizer); err != nil {
-			return err
+		if err = depolorizer.Depolorize(&header.Engine); err != nil {
+			return ManifestHeader{}, errors.Wrap(err, "failed to decode manifest header")
 		}
 
-		manifest.Elements = append(manifest.Elements, ManifestElement{
-			Ptr:  element.Ptr,
-			Kind: element.Kind,
-			Deps: element.Deps,
-			Data: object,
-		})
+	default:
+		return ManifestHeader{}, errors.New("unsupported manifest encoding")
 	}
 
-	return nil
+	return header, nil
 }
 
-func (manifest *Manifest) UnmarshalJSON(data []byte) (err error) {
-	type ManifestJSON struct {
-		Syntax   string         `json:"syntax"`
-		Engine   ManifestEngine `json:"engine"`
-		Elements []struct {
-			Ptr  ElementPtr      `json:"ptr"`
-			Deps []ElementPtr    `json:"deps"`
-			Kind ElementKind     `json:"kind"`
-			Data json.RawMessage `json:"data"`
-		} `json:"elements"`
-	}
+// LogicEngine returns the normalized form of the logic engine value in the ManifestHeader.
+// It is capitalized to uppercase letter and converted into a types.LogicEngine.
+// Use ParseEngineKind on the engine kind of the header to also validate it.
+func (header ManifestHeader) LogicEngine() EngineKind {
+	return EngineKind(header.Engine.Kind).normalize()
+}
 
-	raw := new(ManifestJSON)
-	if err = json.Unmarshal(data, raw); err != nil {
-		return err
+// validate verifies the syntax and engine kind of the ManifestHeader and resolves the latest
+// EngineRuntime in the Registry for its engine that satisfies the engine version constraint (if any).
+// If the runtime declares its engine flags (see FlagDeclarer), the engine flags are verified with them.
+func (header ManifestHeader) validate(registry *Registry) (EngineRuntime, error) {
+	if _, _, ok := lookupSyntax(header.Syntax); !ok {
+		return nil, errors.Errorf("unsupported manifest syntax '%v'", header.Syntax)
 	}
 
-	manifest.Syntax = raw.Syntax
-	manifest.Engine = raw.Engine
-
-	if err = manifest.Header().validate(); err != nil {
-		return err
+	kind, err := ParseEngineKind(header.Engine.Kind)
+	if err != nil {
+		return nil, errors.Wrap(err, "unsupported manifest engine")
 	}
 
-	runtime, _ := FetchEngineRuntime(manifest.Header().LogicEngine())
-
-	manifest.Elements = make([]ManifestElement, 0, len(raw.Elements))
+	runtime, err := registry.ResolveEngineRuntime(kind, header.Engine.Version)
+	if err != nil {
+		return nil, errors.Wrap(err, "unsupported manifest engine")
+	}
 
-	for _, element := range raw.Elements {
-		generator, ok := runtime.GetElementGenerator(element.Kind)
-		if !ok {
-			return errors.Errorf("unrecognized element kind: '%v'", element.Kind)
+	if declared, ok := FlagsOf(runtime); ok {
+		if _, err = header.Engine.ParseFlags(declared); err != nil {
+			return nil, errors.Wrap(err, "invalid manifest engine flags")
 		}
+	}
 
-		object := generator()
-		if err = json.Unmarshal(element.Data, object); err != nil {
-			return err
-		}
+	return runtime, nil
+}
 
-		manifest.Elements = append(manifest.Elements, ManifestElement{
-			Ptr:  element.Ptr,
-			Kind: element.Kind,
-			Deps: element.Deps,
-			Data: object,
-		})
+// Depolorize implements the polo.Depolorizable interface for Manifest.
+// The elements of the Manifest are decoded with the runtimes in the default Registry.
+func (manifest *Manifest) Depolorize(depolorizer *polo.Depolorizer) error {
+	data, err := depolorizer.DepolorizeAny()
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return manifest.decode(data, POLO, defaultRegistry)
 }
 
-func (manifest *Manifest) UnmarshalYAML(node *yaml.Node) error {
-	type ManifestYAML struct {
-		Syntax   string         `yaml:"syntax"`
-		Engine   ManifestEngine `yaml:"engine"`
-		Elements []struct {
-			Ptr  ElementPtr   `yaml:"ptr"`
-			Deps []ElementPtr `yaml:"deps"`
-			Kind ElementKind  `yaml:"kind"`
-			Data yaml.Node    `yaml:"data"`
-		} `yaml:"elements"`
-	}
+// UnmarshalJSON implements the json.Unmarshaler interface for Manifest.
+// The elements of the Manifest are decoded with the runtimes in the default Registry.
+func (manifest *Manifest) UnmarshalJSON(data []byte) error {
+	return manifest.decode(data, JSON, defaultRegistry)
+}
 
-	raw := new(ManifestYAML)
-	if err := node.Decode(raw); err != nil {
+// UnmarshalYAML implements the yaml.Unmarshaler interface for Manifest.
+// The elements of the Manifest are decoded with the runtimes in the default Registry.
+func (manifest *Manifest) UnmarshalYAML(node *yaml.Node) error {
+	data, err := yaml.Marshal(node)
+	if err != nil {
 		return err
 	}
 
-	manifest.Syntax = raw.Syntax
-	manifest.Engine = raw.Engine
+	return manifest.decode(data, YAML, defaultRegistry)
+}
 
-	if err := manifest.Header().validate(); err != nil {
+// decode decodes manifest data of any supported syntax version into the Manifest,
+// migrating it to the current syntax and decoding its elements with the Registry
+func (manifest *Manifest) decode(data []byte, encoding Encoding, registry *Registry) error {
+	raw, err := decodeManifestData(data, encoding)
+	if err != nil {
 		return err
 	}
 
-	runtime, _ := FetchEngineRuntime(manifest.Header().LogicEngine())
-
-	manifest.Elements = make([]ManifestElement, 0, len(raw.Elements))
-
-	for _, element := range raw.Elements {
-		generator, ok := runtime.GetElementGenerator(element.Kind)
-		if !ok {
-			return errors.Errorf("unrecognized element kind: '%v'", element.Kind)
-		}
-
-		object := generator()
-		if err := element.Data.Decode(object); err != nil {
-			return err
-		}
-
-		manifest.Elements = append(manifest.Elements, ManifestElement{
-			Ptr:  element.Ptr,
-			Kind: element.Kind,
-			Deps: element.Deps,
-			Data: object,
-		})
+	decoded, err := raw.resolve(registry)
+	if err != nil {
+		return err
 	}
 
+	*manifest = *decoded
+
 	return nil
 }
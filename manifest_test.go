@@ -0,0 +1,217 @@
+package engineio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarvalabs/go-polo"
+	"github.com/stretchr/testify/require"
+)
+
+// mockElement is a ManifestElementObject implementation for testing
+type mockElement struct {
+	Name  string `json:"name" yaml:"name"`
+	Value uint64 `json:"value" yaml:"value"`
+}
+
+func (element mockElement) Polorize() (*polo.Polorizer, error) {
+	polorizer := polo.NewPolorizer()
+	polorizer.PolorizeString(element.Name)
+	polorizer.PolorizeUint(element.Value)
+
+	return polorizer, nil
+}
+
+func (element *mockElement) Depolorize(depolorizer *polo.Depolorizer) (err error) {
+	if depolorizer, err = depolorizer.DepolorizePacked(); err != nil {
+		return err
+	}
+
+	if element.Name, err = depolorizer.DepolorizeString(); err != nil {
+		return err
+	}
+
+	element.Value, err = depolorizer.DepolorizeUint()
+
+	return err
+}
+
+// newMockRuntime returns a mock runtime that supports the "mock" element kind
+func newMockRuntime(kind EngineKind, version string) *mockEngineRuntime {
+	return &mockEngineRuntime{
+		kind:    kind,
+		version: version,
+		elements: map[ElementKind]ManifestElementGenerator{
+			"mock": func() ManifestElementObject { return new(mockElement) },
+		},
+	}
+}
+
+// newMockManifest returns a Manifest for the given engine kind with some "mock" elements
+func newMockManifest(kind string) Manifest {
+	return Manifest{
+		Syntax: "0.1.0",
+		Engine: ManifestEngine{Kind: kind, Flags: []string{}},
+		Elements: []ManifestElement{
+			{Ptr: 0, Deps: []ElementPtr{}, Kind: "mock", Data: &mockElement{Name: "foo", Value: 5}},
+			{Ptr: 1, Deps: []ElementPtr{0}, Kind: "mock", Data: &mockElement{Name: "bar", Value: 10}},
+		},
+	}
+}
+
+func TestManifestEngine_Polorize(t *testing.T) {
+	// ManifestEngine without a version must encode exactly like its plain struct form
+	type plainEngine struct {
+		Kind  string
+		Flags []string
+	}
+
+	plain, err := polo.Polorize(plainEngine{Kind: "PISA", Flags: []string{"a", "b"}})
+	require.NoError(t, err)
+
+	encoded, err := polo.Polorize(ManifestEngine{Kind: "PISA", Flags: []string{"a", "b"}})
+	require.NoError(t, err)
+	require.Equal(t, plain, encoded)
+
+	decoded := new(ManifestEngine)
+	require.NoError(t, polo.Depolorize(decoded, plain))
+	require.Equal(t, ManifestEngine{Kind: "PISA", Flags: []string{"a", "b"}}, *decoded)
+
+	versioned := ManifestEngine{Kind: "PISA", Flags: []string{"a"}, Version: "^0.4.0"}
+
+	encoded, err = polo.Polorize(versioned)
+	require.NoError(t, err)
+
+	decoded = new(ManifestEngine)
+	require.NoError(t, polo.Depolorize(decoded, encoded))
+	require.Equal(t, versioned, *decoded)
+}
+
+func TestRegistry_NewManifest(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("mock")
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		encoded, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		decoded, err := registry.NewManifest(encoded, encoding)
+		require.NoError(t, err)
+		require.Equal(t, manifest, *decoded)
+
+		// the runtime is not registered with the default registry
+		_, err = NewManifest(encoded, encoding)
+		require.EqualError(t, err, "unsupported manifest engine: unknown engine 'MOCK'")
+		require.ErrorIs(t, err, ErrUnknownEngine)
+	}
+
+	_, err := registry.NewManifest(nil, Encoding(10))
+	require.EqualError(t, err, "unsupported manifest encoding")
+}
+
+func TestRegistry_ReadManifestFile(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+	directory := t.TempDir()
+
+	for extension, encoding := range map[string]Encoding{".polo": POLO, ".json": JSON, ".yaml": YAML} {
+		encoded, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		path := filepath.Join(directory, "manifest"+extension)
+		require.NoError(t, os.WriteFile(path, encoded, 0o600))
+
+		decoded, err := registry.ReadManifestFile(path)
+		require.NoError(t, err)
+		require.Equal(t, manifest, *decoded)
+	}
+
+	_, err := registry.ReadManifestFile(filepath.Join(directory, "manifest.toml"))
+	require.ErrorContains(t, err, "manifest file not found")
+}
+
+func TestManifest_DefaultRegistry(t *testing.T) {
+	RegisterRuntime(newMockRuntime("DEFAULT", "0.1.0"), nil)
+	t.Cleanup(func() { UnregisterRuntime("DEFAULT") })
+
+	manifest := newMockManifest("default")
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		encoded, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		decoded, err := NewManifest(encoded, encoding)
+		require.NoError(t, err)
+		require.Equal(t, manifest, *decoded)
+	}
+}
+
+func TestNewManifest_VersionedEngine(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("VERSIONED", "0.3.0"), nil)
+	registry.Register(newMockRuntime("VERSIONED", "0.4.0"), nil)
+
+	manifest := newMockManifest("versioned")
+	manifest.Engine.Version = "^0.3.0"
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		encoded, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		decoded, err := registry.NewManifest(encoded, encoding)
+		require.NoError(t, err)
+		require.Equal(t, manifest, *decoded)
+	}
+
+	manifest.Engine.Version = "^1.0.0"
+
+	encoded, err := manifest.Encode(JSON)
+	require.NoError(t, err)
+
+	_, err = registry.NewManifest(encoded, JSON)
+	require.EqualError(t, err, "unsupported manifest engine: "+
+		"no runtime registered for engine 'VERSIONED' satisfies version '^1.0.0'")
+}
+
+func TestDecodeManifestHeader(t *testing.T) {
+	manifest := newMockManifest("unregistered")
+	manifest.Engine.Flags = []string{"strict"}
+	manifest.Engine.Version = "^1.0.0"
+
+	expected := ManifestHeader{
+		Syntax: "0.1.0",
+		Engine: ManifestEngine{Kind: "unregistered", Flags: []string{"strict"}, Version: "^1.0.0"},
+	}
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		encoded, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		header, err := DecodeManifestHeader(encoded, encoding)
+		require.NoError(t, err)
+		require.Equal(t, expected, header)
+	}
+
+	// element data is never decoded
+	header, err := DecodeManifestHeader([]byte(`{
+		"syntax": "9.9.9",
+		"engine": {"kind": "other", "flags": []},
+		"elements": [{"ptr": 0, "kind": "unknown", "data": "opaque"}]
+	}`), JSON)
+	require.NoError(t, err)
+	require.Equal(t, ManifestHeader{Syntax: "9.9.9", Engine: ManifestEngine{Kind: "other", Flags: []string{}}}, header)
+
+	_, err = DecodeManifestHeader([]byte("syntax: [0.1.0"), YAML)
+	require.ErrorContains(t, err, "failed to decode manifest header")
+
+	_, err = DecodeManifestHeader([]byte{0x03, 0x01}, POLO)
+	require.ErrorContains(t, err, "failed to decode manifest header")
+
+	_, err = DecodeManifestHeader(nil, Encoding(10))
+	require.EqualError(t, err, "unsupported manifest encoding")
+}
@@ -0,0 +1,226 @@
+package engineio
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// RawManifest is a Manifest whose element data has not been decoded with an EngineRuntime.
+// The data of each element is kept in its encoded form (the raw bytes of its POLO, JSON or YAML encoding),
+// in the encoding of the RawManifest. It is also the form in which Manifests are migrated between syntax versions.
+//
+// A RawManifest can be decoded, hashed and re-encoded without a runtime for its engine,
+// which allows tooling to handle Manifests of any engine. It can be resolved into a Manifest
+// with ResolveRawManifest once a runtime for its engine is registered.
+type RawManifest struct {
+	Syntax   string
+	Engine   ManifestEngine
+	Elements []RawElement
+
+	encoding Encoding
+}
+
+// RawElement is a ManifestElement with its data in an encoded form
+type RawElement struct {
+	Ptr  ElementPtr
+	Deps []ElementPtr
+	Kind ElementKind
+	Data []byte
+}
+
+// NewRawManifest decodes the given raw data of the specified encoding type into a RawManifest.
+// The data of the elements is not decoded and no runtime needs to be registered for the engine.
+// Unlike NewManifest, the RawManifest is not migrated to the current syntax version, so that it
+// can be re-encoded without any loss. Fails if the encoding or syntax version is unsupported.
+func NewRawManifest(data []byte, encoding Encoding) (*RawManifest, error) {
+	version, err := decodeSyntax(data, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	_, syntax, ok := lookupSyntax(version)
+	if !ok {
+		return nil, errors.Errorf("unsupported manifest syntax '%v'", version)
+	}
+
+	return syntax.decode(data, encoding)
+}
+
+// Raw returns the Manifest as a RawManifest with its element data encoded in the specified encoding
+func (manifest Manifest) Raw(encoding Encoding) (*RawManifest, error) {
+	return newRawManifest(manifest, encoding)
+}
+
+// ResolveRawManifest migrates the RawManifest to the current syntax version and decodes
+// its elements with the runtimes in the default Registry. The RawManifest is not modified.
+func ResolveRawManifest(raw *RawManifest) (*Manifest, error) {
+	return defaultRegistry.ResolveRawManifest(raw)
+}
+
+// ResolveRawManifest migrates the RawManifest to the current syntax version and decodes
+// its elements with the runtimes in the Registry. The RawManifest is not modified.
+func (registry *Registry) ResolveRawManifest(raw *RawManifest) (*Manifest, error) {
+	migrated, err := raw.clone().migrate(CurrentSyntax())
+	if err != nil {
+		return nil, err
+	}
+
+	return migrated.resolve(registry)
+}
+
+// Encoding returns the encoding of the element data in the RawManifest
+func (raw RawManifest) Encoding() Encoding {
+	return raw.encoding
+}
+
+// Header returns the header information of the RawManifest as a ManifestHeader
+func (raw RawManifest) Header() ManifestHeader {
+	return ManifestHeader{raw.Syntax, raw.Engine}
+}
+
+// Hash returns the 256-bit hash of the RawManifest, which is identical to the hash of the Manifest it resolves to.
+// The hash is derived by applying the Blake2b hashing function on the POLO encoded bytes of the RawManifest,
+// and can therefore only be computed for a RawManifest with POLO encoded element data (see RawManifest.Convert).
+func (raw *RawManifest) Hash() (Hash, error) {
+	digest, err := raw.Digest(Blake2b256)
+	if err != nil {
+		return Hash{}, err
+	}
+
+	return digest.Hash, nil
+}
+
+// Digest returns the Digest of the RawManifest for the given HashAlgorithm, which is identical to the Digest
+// of the Manifest it resolves to. Like Hash, it can only be computed for a RawManifest with POLO encoded
+// element data. Returns an error if the algorithm is not supported.
+func (raw *RawManifest) Digest(algorithm HashAlgorithm) (Digest, error) {
+	if raw.encoding != POLO && len(raw.Elements) > 0 {
+		return Digest{}, errors.New("raw manifest can only be hashed with POLO encoded element data")
+	}
+
+	encoded, err := raw.Encode(POLO)
+	if err != nil {
+		return Digest{}, err
+	}
+
+	return algorithm.Sum(encoded)
+}
+
+// Encode returns the encoded bytes form of the RawManifest for the specified encoding in its own syntax version.
+// The element data is written as is if the encoding is the encoding of the RawManifest, and is otherwise
+// converted to the encoding with RawManifest.Convert.
+func (raw *RawManifest) Encode(encoding Encoding) ([]byte, error) {
+	_, syntax, ok := lookupSyntax(raw.Syntax)
+	if !ok {
+		return nil, errors.Errorf("unsupported manifest syntax '%v'", raw.Syntax)
+	}
+
+	converted, err := raw.Convert(encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	return syntax.encode(converted, encoding)
+}
+
+// Convert returns a copy of the RawManifest with its element data converted to the specified encoding.
+//
+// Element data can be converted between JSON and YAML without a runtime, as both describe
+// their values with field names. POLO element data does not describe its fields and can only
+// be converted by resolving the RawManifest into a Manifest and encoding it with Manifest.Raw.
+func (raw *RawManifest) Convert(encoding Encoding) (*RawManifest, error) {
+	converted := raw.clone()
+	converted.encoding = encoding
+
+	switch {
+	case encoding != POLO && encoding != JSON && encoding != YAML:
+		return nil, errors.New("unsupported manifest encoding")
+
+	case encoding == raw.encoding || len(raw.Elements) == 0:
+		return converted, nil
+
+	case encoding == POLO || raw.encoding == POLO:
+		return nil, errors.New("raw manifest element data cannot be converted to or from POLO without a runtime")
+	}
+
+	for idx, element := range converted.Elements {
+		data, err := convertElementData(element.Data, raw.encoding, encoding)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert data of element %v", element.Ptr)
+		}
+
+		converted.Elements[idx].Data = data
+	}
+
+	return converted, nil
+}
+
+// Migrate returns a copy of the RawManifest that is upgraded or downgraded to the given
+// syntax version. Fails if either syntax version is unsupported or if the migration fails.
+func (raw *RawManifest) Migrate(syntax string) (*RawManifest, error) {
+	return raw.clone().migrate(syntax)
+}
+
+// clone returns a deep copy of the RawManifest
+func (raw *RawManifest) clone() *RawManifest {
+	clone := &RawManifest{
+		Syntax:   raw.Syntax,
+		Engine:   raw.Engine,
+		Elements: make([]RawElement, 0, len(raw.Elements)),
+		encoding: raw.encoding,
+	}
+
+	if raw.Engine.Flags != nil {
+		clone.Engine.Flags = append(make([]string, 0, len(raw.Engine.Flags)), raw.Engine.Flags...)
+	}
+
+	for _, element := range raw.Elements {
+		if element.Deps != nil {
+			element.Deps = append(make([]ElementPtr, 0, len(element.Deps)), element.Deps...)
+		}
+
+		element.Data = append([]byte(nil), element.Data...)
+		clone.Elements = append(clone.Elements, element)
+	}
+
+	return clone
+}
+
+// convertElementData converts encoded element data between the JSON and YAML encodings
+func convertElementData(data []byte, from, to Encoding) ([]byte, error) {
+	switch {
+	case from == JSON && to == YAML:
+		// JSON is a subset of YAML, so the data can be decoded as a YAML
+		// node, which is then encoded in the block style instead of the flow style
+		node := new(yaml.Node)
+		if err := yaml.Unmarshal(data, node); err != nil {
+			return nil, err
+		}
+
+		resetNodeStyle(node)
+
+		return yaml.Marshal(node)
+
+	case from == YAML && to == JSON:
+		var value any
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(value)
+
+	default:
+		return nil, errors.New("unsupported element data conversion")
+	}
+}
+
+// resetNodeStyle recursively resets the style of a YAML node and its contents to the default style
+func resetNodeStyle(node *yaml.Node) {
+	node.Style = 0
+
+	for _, child := range node.Content {
+		resetNodeStyle(child)
+	}
+}
@@ -0,0 +1,175 @@
+package engineio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRawManifest(t *testing.T) {
+	manifest := newMockManifest("MOCK")
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		encoded, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		// no runtime is registered for the engine
+		raw, err := NewRawManifest(encoded, encoding)
+		require.NoError(t, err)
+		require.Equal(t, encoding, raw.Encoding())
+		require.Equal(t, manifest.Header(), raw.Header())
+		require.Len(t, raw.Elements, 2)
+		require.Equal(t, ElementPtr(1), raw.Elements[1].Ptr)
+		require.Equal(t, []ElementPtr{0}, raw.Elements[1].Deps)
+		require.Equal(t, ElementKind("mock"), raw.Elements[1].Kind)
+
+		// the raw manifest is re-encoded without any loss
+		reencoded, err := raw.Encode(encoding)
+		require.NoError(t, err)
+		require.Equal(t, encoded, reencoded, "encoding: %v", encoding)
+
+		// the raw manifest is equivalent to the raw form of the manifest
+		expected, err := manifest.Raw(encoding)
+		require.NoError(t, err)
+		require.Equal(t, expected, raw)
+	}
+
+	_, err := NewRawManifest([]byte(`{"syntax": "9.9.9"}`), JSON)
+	require.EqualError(t, err, "unsupported manifest syntax '9.9.9'")
+
+	_, err = NewRawManifest(nil, Encoding(10))
+	require.EqualError(t, err, "unsupported manifest encoding")
+}
+
+func TestRawManifest_Hash(t *testing.T) {
+	manifest := newMockManifest("MOCK")
+
+	expected, err := manifest.Hash()
+	require.NoError(t, err)
+
+	encoded, err := manifest.Encode(POLO)
+	require.NoError(t, err)
+
+	raw, err := NewRawManifest(encoded, POLO)
+	require.NoError(t, err)
+
+	hash, err := raw.Hash()
+	require.NoError(t, err)
+	require.Equal(t, expected, hash)
+
+	raw, err = manifest.Raw(JSON)
+	require.NoError(t, err)
+
+	_, err = raw.Hash()
+	require.EqualError(t, err, "raw manifest can only be hashed with POLO encoded element data")
+}
+
+func TestRawManifest_Convert(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+
+	for _, conversion := range [][2]Encoding{{JSON, YAML}, {YAML, JSON}} {
+		from, to := conversion[0], conversion[1]
+
+		raw, err := manifest.Raw(from)
+		require.NoError(t, err)
+
+		converted, err := raw.Convert(to)
+		require.NoError(t, err)
+		require.Equal(t, to, converted.Encoding())
+		require.Equal(t, from, raw.Encoding())
+
+		// the converted manifest encodes identically to the manifest
+		expected, err := manifest.Encode(to)
+		require.NoError(t, err)
+
+		encoded, err := converted.Encode(to)
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(encoded))
+
+		encoded, err = raw.Encode(to)
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(encoded))
+
+		resolved, err := registry.ResolveRawManifest(converted)
+		require.NoError(t, err)
+		require.Equal(t, manifest, *resolved)
+	}
+
+	raw, err := manifest.Raw(POLO)
+	require.NoError(t, err)
+
+	_, err = raw.Convert(JSON)
+	require.EqualError(t, err, "raw manifest element data cannot be converted to or from POLO without a runtime")
+
+	_, err = raw.Convert(Encoding(10))
+	require.EqualError(t, err, "unsupported manifest encoding")
+
+	// manifests without elements can be converted to any encoding
+	raw.Elements = nil
+
+	converted, err := raw.Convert(JSON)
+	require.NoError(t, err)
+	require.Equal(t, JSON, converted.Encoding())
+}
+
+func TestRegistry_ResolveRawManifest(t *testing.T) {
+	registry := NewRegistry()
+	manifest := newMockManifest("MOCK")
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		raw, err := manifest.Raw(encoding)
+		require.NoError(t, err)
+
+		_, err = registry.ResolveRawManifest(raw)
+		require.EqualError(t, err, "unsupported manifest engine: unknown engine 'MOCK'")
+	}
+
+	// the raw manifest is resolved once the runtime is registered
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		raw, err := manifest.Raw(encoding)
+		require.NoError(t, err)
+
+		resolved, err := registry.ResolveRawManifest(raw)
+		require.NoError(t, err)
+		require.Equal(t, manifest, *resolved)
+	}
+}
+
+func TestRawManifest_Migrate(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+
+	raw, err := manifest.Raw(JSON)
+	require.NoError(t, err)
+
+	withSyntax(t, SyntaxVersion{Version: "0.2.0", Upgrade: func(raw *RawManifest) (*RawManifest, error) {
+		raw.Engine.Flags = append(raw.Engine.Flags, "v2")
+
+		return raw, nil
+	}})
+
+	migrated, err := raw.Migrate("0.2.0")
+	require.NoError(t, err)
+	require.Equal(t, "0.2.0", migrated.Syntax)
+	require.Equal(t, []string{"v2"}, migrated.Engine.Flags)
+
+	// the raw manifest is not modified
+	require.Equal(t, "0.1.0", raw.Syntax)
+	require.Equal(t, []string{}, raw.Engine.Flags)
+
+	// resolving the raw manifest migrates it to the current syntax
+	resolved, err := registry.ResolveRawManifest(raw)
+	require.NoError(t, err)
+	require.Equal(t, "0.2.0", resolved.Syntax)
+	require.Equal(t, "0.1.0", raw.Syntax)
+
+	_, err = raw.Migrate("9.9.9")
+	require.EqualError(t, err, "unsupported manifest syntax '9.9.9'")
+}
@@ -1,38 +1,355 @@
 package engineio
 
-// registry is an in-memory registry of EngineRuntime instances and their respective crypto drivers.
-// Support for different engine runtimes is only available if they are first registered with this package.
-var registry = map[EngineKind]entry{}
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Registry is an in-memory registry of EngineRuntime instances and their respective crypto drivers.
+// Support for different engine runtimes is only available if they are first registered with a Registry.
+// Access to the Registry is synchronized and it is safe to use concurrently from multiple goroutines.
+//
+// Multiple versions of the runtime for an EngineKind can be registered side by side. The entries
+// for each EngineKind are kept sorted by their semantic version, with the latest version first.
+//
+// Manifests can be decoded against the runtimes of a specific Registry with its NewManifest and
+// ReadManifestFile methods. The package level functions operate on a default Registry instance.
+// The zero value of a Registry is an empty Registry that is ready to use, like one from NewRegistry.
+type Registry struct {
+	mutex   sync.RWMutex
+	entries map[EngineKind][]entry
+}
 
 type entry struct {
 	runtime EngineRuntime
 	crypto  CryptoDriver
+	version semver
 }
 
-// RegisterRuntime registers an EngineRuntime with the package along with a CryptoDriver for the runtime.
-// If a runtime instance already exists for the EngineKind, it is overwritten.
-func RegisterRuntime(runtime EngineRuntime, crypto CryptoDriver) {
-	registry[runtime.Kind()] = entry{runtime, crypto}
+// NewRegistry returns a new empty Registry
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[EngineKind][]entry)}
 }
 
-// FetchEngineRuntime retrieves an EngineRuntime for a given EngineKind.
-// If the runtime for the engine kind is not registered, returns false.
-func FetchEngineRuntime(kind EngineKind) (EngineRuntime, bool) {
-	object, exists := registry[kind]
-	if !exists {
+// defaultRegistry is the Registry used by the package level registry and manifest functions
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the default Registry used by package level functions such
+// as RegisterRuntime, NewManifest and ReadManifestFile and the Manifest decoders.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// RegisteredRuntime is a snapshot of a registered EngineRuntime and its CryptoDriver
+type RegisteredRuntime struct {
+	Runtime EngineRuntime
+	Crypto  CryptoDriver
+}
+
+// Register registers an EngineRuntime with the Registry along with a CryptoDriver for the runtime.
+// Runtimes are keyed by their EngineKind and the semantic version returned by their Version method.
+// If a runtime instance already exists for the same EngineKind and version, it is overwritten.
+//
+// Returns an error if the Version of the runtime is not a valid semantic version.
+func (registry *Registry) Register(runtime EngineRuntime, crypto CryptoDriver) error {
+	version, err := parseSemver(runtime.Version())
+	if err != nil {
+		return errors.Wrapf(err, "cannot register %v runtime", runtime.Kind())
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if registry.entries == nil {
+		registry.entries = make(map[EngineKind][]entry)
+	}
+
+	kind := runtime.Kind()
+	object := entry{runtime, crypto, version}
+
+	entries := registry.entries[kind]
+	for idx := range entries {
+		if entries[idx].version.compare(version) == 0 {
+			entries[idx] = object
+
+			return nil
+		}
+	}
+
+	entries = append(entries, object)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].version.compare(entries[j].version) > 0
+	})
+
+	registry.entries[kind] = entries
+
+	return nil
+}
+
+// MustRegister registers an EngineRuntime with the Registry along with a CryptoDriver for the runtime.
+// It is like Register but panics if the Version of the runtime is not a valid semantic version.
+func (registry *Registry) MustRegister(runtime EngineRuntime, crypto CryptoDriver) {
+	if err := registry.Register(runtime, crypto); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes every version of the EngineRuntime (and its CryptoDriver) registered for
+// the given EngineKind. Returns false if no runtime was registered for the engine kind.
+func (registry *Registry) Unregister(kind EngineKind) bool {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if _, exists := registry.entries[kind]; !exists {
+		return false
+	}
+
+	delete(registry.entries, kind)
+
+	return true
+}
+
+// UnregisterVersion removes a specific version of the EngineRuntime (and its CryptoDriver)
+// registered for the given EngineKind. Returns false if no such runtime version was registered.
+func (registry *Registry) UnregisterVersion(kind EngineKind, version string) bool {
+	parsed, err := parseSemver(version)
+	if err != nil {
+		return false
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	entries := registry.entries[kind]
+	for idx := range entries {
+		if entries[idx].version.compare(parsed) != 0 {
+			continue
+		}
+
+		entries = append(entries[:idx:idx], entries[idx+1:]...)
+		if len(entries) == 0 {
+			delete(registry.entries, kind)
+		} else {
+			registry.entries[kind] = entries
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// FetchEngineRuntime retrieves the latest stable (not pre-release) EngineRuntime for a given
+// EngineKind. If no stable runtime for the engine kind is registered, returns false.
+func (registry *Registry) FetchEngineRuntime(kind EngineKind) (EngineRuntime, bool) {
+	object, err := registry.resolve(kind, "")
+	if err != nil {
 		return nil, false
 	}
 
 	return object.runtime, true
 }
 
-// FetchCryptoDriver retrieves an CryptoDriver for a given EngineKind.
-// If the runtime for the engine kind is not registered, returns false.
-func FetchCryptoDriver(kind EngineKind) (CryptoDriver, bool) {
-	object, exists := registry[kind]
-	if !exists {
+// FetchCryptoDriver retrieves the CryptoDriver of the latest stable runtime for a given
+// EngineKind. If no stable runtime for the engine kind is registered, returns false.
+func (registry *Registry) FetchCryptoDriver(kind EngineKind) (CryptoDriver, bool) {
+	object, err := registry.resolve(kind, "")
+	if err != nil {
 		return nil, false
 	}
 
 	return object.crypto, true
 }
+
+// ResolveEngineRuntime retrieves the latest EngineRuntime for a given EngineKind whose version satisfies
+// the given version constraint (such as "^0.4.0" or ">=0.3.0, <0.5.0"). An empty constraint resolves the latest
+// stable version. Pre-release versions (such as "0.5.0-rc.1") are only resolved if the constraint refers to
+// a pre-release of the same version (such as ">=0.5.0-rc.0"), as with npm and Masterminds/semver.
+// Returns an error if the constraint is malformed or unsatisfiable.
+func (registry *Registry) ResolveEngineRuntime(kind EngineKind, constraint string) (EngineRuntime, error) {
+	object, err := registry.resolve(kind, constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	return object.runtime, nil
+}
+
+// ResolveCryptoDriver retrieves the CryptoDriver of the latest runtime for a given EngineKind whose version
+// satisfies the given version constraint. It follows the same resolution rules as ResolveEngineRuntime.
+func (registry *Registry) ResolveCryptoDriver(kind EngineKind, constraint string) (CryptoDriver, error) {
+	object, err := registry.resolve(kind, constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	return object.crypto, nil
+}
+
+// RegisteredKinds returns the EngineKind of every registered runtime, sorted lexicographically.
+func (registry *Registry) RegisteredKinds() []EngineKind {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	return registry.sortedKinds()
+}
+
+// RegisteredVersions returns the versions of every runtime registered
+// for the given EngineKind, sorted from the latest to the oldest.
+func (registry *Registry) RegisteredVersions(kind EngineKind) []string {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	entries := registry.entries[kind]
+
+	versions := make([]string, 0, len(entries))
+	for _, object := range entries {
+		versions = append(versions, object.runtime.Version())
+	}
+
+	return versions
+}
+
+// IterRuntimes returns a channel that yields a snapshot of all registered runtimes, sorted by their
+// EngineKind and then from their latest to the oldest version. The snapshot is captured when the
+// function is called and the returned channel is buffered and closed, so it is safe to (un)register
+// runtimes while iterating or to abandon the iteration early.
+func (registry *Registry) IterRuntimes() <-chan RegisteredRuntime {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	count := 0
+	for _, entries := range registry.entries {
+		count += len(entries)
+	}
+
+	snapshot := make(chan RegisteredRuntime, count)
+
+	for _, kind := range registry.sortedKinds() {
+		for _, object := range registry.entries[kind] {
+			snapshot <- RegisteredRuntime{Runtime: object.runtime, Crypto: object.crypto}
+		}
+	}
+
+	close(snapshot)
+
+	return snapshot
+}
+
+// resolve returns the latest registry entry for the EngineKind that satisfies the version constraint.
+// If no runtime is registered for the EngineKind, the returned error wraps ErrRuntimeNotRegistered
+// if it is a known engine kind or ErrUnknownEngine otherwise.
+func (registry *Registry) resolve(kind EngineKind, constraint string) (entry, error) {
+	parsed, err := parseVersionConstraint(constraint)
+	if err != nil {
+		return entry{}, err
+	}
+
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	entries, exists := registry.entries[kind]
+	if !exists {
+		if !kind.Known() {
+			return entry{}, fmt.Errorf("%w '%v'", ErrUnknownEngine, kind)
+		}
+
+		return entry{}, fmt.Errorf("%w '%v'", ErrRuntimeNotRegistered, kind)
+	}
+
+	for _, object := range entries {
+		if parsed.satisfiedBy(object.version) {
+			return object, nil
+		}
+	}
+
+	if len(parsed) == 0 {
+		return entry{}, fmt.Errorf("%w '%v' with a stable version", ErrRuntimeNotRegistered, kind)
+	}
+
+	return entry{}, fmt.Errorf("%w '%v' satisfies version '%v'", ErrRuntimeNotRegistered, kind, constraint)
+}
+
+// sortedKinds returns the registered engine kinds in lexicographic order.
+// The caller is expected to hold (at least) a read lock on the registry.
+func (registry *Registry) sortedKinds() []EngineKind {
+	kinds := make([]EngineKind, 0, len(registry.entries))
+	for kind := range registry.entries {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+
+	return kinds
+}
+
+// RegisterRuntime registers an EngineRuntime along with a CryptoDriver for the runtime with the default Registry.
+// Runtimes are keyed by their EngineKind and the semantic version returned by their Version method.
+// If a runtime instance already exists for the same EngineKind and version, it is overwritten.
+//
+// Returns an error if the Version of the runtime is not a valid semantic version.
+func RegisterRuntime(runtime EngineRuntime, crypto CryptoDriver) error {
+	return defaultRegistry.Register(runtime, crypto)
+}
+
+// MustRegisterRuntime registers an EngineRuntime along with a CryptoDriver for the runtime with the default
+// Registry. It is like RegisterRuntime but panics if the Version of the runtime is not a valid semantic version.
+func MustRegisterRuntime(runtime EngineRuntime, crypto CryptoDriver) {
+	defaultRegistry.MustRegister(runtime, crypto)
+}
+
+// UnregisterRuntime removes every version of the EngineRuntime (and its CryptoDriver) registered for the
+// given EngineKind from the default Registry. Returns false if no runtime was registered for the engine kind.
+func UnregisterRuntime(kind EngineKind) bool {
+	return defaultRegistry.Unregister(kind)
+}
+
+// UnregisterRuntimeVersion removes a specific version of the EngineRuntime (and its CryptoDriver) registered
+// for the given EngineKind from the default Registry. Returns false if no such runtime version was registered.
+func UnregisterRuntimeVersion(kind EngineKind, version string) bool {
+	return defaultRegistry.UnregisterVersion(kind, version)
+}
+
+// FetchEngineRuntime retrieves the latest EngineRuntime for a given EngineKind from the default Registry.
+// If the runtime for the engine kind is not registered, returns false.
+func FetchEngineRuntime(kind EngineKind) (EngineRuntime, bool) {
+	return defaultRegistry.FetchEngineRuntime(kind)
+}
+
+// FetchCryptoDriver retrieves the CryptoDriver of the latest runtime for a given EngineKind from the
+// default Registry. If the runtime for the engine kind is not registered, returns false.
+func FetchCryptoDriver(kind EngineKind) (CryptoDriver, bool) {
+	return defaultRegistry.FetchCryptoDriver(kind)
+}
+
+// ResolveEngineRuntime retrieves the latest EngineRuntime for a given EngineKind from the default
+// Registry whose version satisfies the given constraint. See Registry.ResolveEngineRuntime for details.
+func ResolveEngineRuntime(kind EngineKind, constraint string) (EngineRuntime, error) {
+	return defaultRegistry.ResolveEngineRuntime(kind, constraint)
+}
+
+// ResolveCryptoDriver retrieves the CryptoDriver of the latest runtime for a given EngineKind from the
+// default Registry whose version satisfies the given constraint. See Registry.ResolveEngineRuntime for details.
+func ResolveCryptoDriver(kind EngineKind, constraint string) (CryptoDriver, error) {
+	return defaultRegistry.ResolveCryptoDriver(kind, constraint)
+}
+
+// RegisteredKinds returns the EngineKind of every runtime in the default Registry, sorted lexicographically.
+func RegisteredKinds() []EngineKind {
+	return defaultRegistry.RegisteredKinds()
+}
+
+// RegisteredVersions returns the versions of every runtime registered for the
+// given EngineKind in the default Registry, sorted from the latest to the oldest.
+func RegisteredVersions(kind EngineKind) []string {
+	return defaultRegistry.RegisteredVersions(kind)
+}
+
+// IterRuntimes returns a channel that yields a snapshot of all runtimes in the default Registry.
+// See Registry.IterRuntimes for details on the ordering and snapshot semantics.
+func IterRuntimes() <-chan RegisteredRuntime {
+	return defaultRegistry.IterRuntimes()
+}
@@ -1,6 +1,7 @@
 package engineio
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -43,9 +44,199 @@ func TestFetchEngineRuntime(t *testing.T) {
 	require.Equal(t, r.Kind(), MERU, "Expected registered engine runtime to have kind %s, but got %s", MERU, r.Kind())
 }
 
+func TestRegistry_Unregister(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&mockEngineRuntime{kind: "TEST"}, nil)
+
+	require.True(t, registry.Unregister("TEST"), "Expected engine runtime to be unregistered")
+	require.False(t, registry.Unregister("TEST"), "Expected engine runtime to already be unregistered")
+
+	_, ok := registry.FetchEngineRuntime("TEST")
+	require.False(t, ok, "Expected engine runtime not to be registered")
+
+	_, ok = registry.FetchCryptoDriver("TEST")
+	require.False(t, ok, "Expected crypto driver not to be registered")
+}
+
+func TestRegistry_RegisteredKinds(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&mockEngineRuntime{kind: "TEST-B"}, nil)
+	registry.Register(&mockEngineRuntime{kind: "TEST-A"}, nil)
+
+	kinds := registry.RegisteredKinds()
+	require.Equal(t, []EngineKind{"TEST-A", "TEST-B"}, kinds)
+
+	iterated := make([]EngineKind, 0, len(kinds))
+
+	for object := range registry.IterRuntimes() {
+		// mutating the registry during iteration must not affect the snapshot
+		registry.Unregister("TEST-B")
+
+		iterated = append(iterated, object.Runtime.Kind())
+	}
+
+	require.Equal(t, kinds, iterated)
+	require.Equal(t, []EngineKind{"TEST-A"}, registry.RegisteredKinds())
+}
+
+func TestRegistry_Concurrency(t *testing.T) {
+	var wg sync.WaitGroup
+
+	registry := NewRegistry()
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			kind := EngineKind("CONCURRENT")
+			if i%2 == 0 {
+				registry.Register(&mockEngineRuntime{kind: kind}, nil)
+			} else {
+				registry.Unregister(kind)
+			}
+
+			_, _ = registry.FetchEngineRuntime(kind)
+			_, _ = registry.FetchCryptoDriver(kind)
+			_ = registry.RegisteredKinds()
+
+			for object := range registry.IterRuntimes() {
+				_ = object.Runtime.Kind()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func TestRegistry_ZeroValue(t *testing.T) {
+	var registry Registry
+
+	_, ok := registry.FetchEngineRuntime("MOCK")
+	require.False(t, ok)
+	require.False(t, registry.Unregister("MOCK"))
+	require.Empty(t, registry.RegisteredKinds())
+
+	runtime := newMockRuntime("MOCK", "0.1.0")
+	require.NoError(t, registry.Register(runtime, nil))
+
+	fetched, ok := registry.FetchEngineRuntime("MOCK")
+	require.True(t, ok)
+	require.Same(t, runtime, fetched)
+}
+
+func TestRegistry_Versions(t *testing.T) {
+	kind := EngineKind("VERSIONED")
+	registry := NewRegistry()
+
+	v3 := &mockEngineRuntime{kind: kind, version: "0.3.1"}
+	v4 := &mockEngineRuntime{kind: kind, version: "v0.4.0"}
+	v5 := &mockEngineRuntime{kind: kind, version: "0.5.0-rc.1"}
+
+	registry.Register(v3, nil)
+	registry.Register(v5, nil)
+	registry.Register(v4, nil)
+
+	require.Equal(t, []string{"0.5.0-rc.1", "v0.4.0", "0.3.1"}, registry.RegisteredVersions(kind))
+
+	// pre-releases are not resolved unless the constraint refers to them
+	latest, ok := registry.FetchEngineRuntime(kind)
+	require.True(t, ok)
+	require.Same(t, v4, latest)
+
+	tests := []struct {
+		constraint string
+		runtime    EngineRuntime
+		err        string
+	}{
+		{"", v4, ""},
+		{"*", v4, ""},
+		{">=0.5.0-rc.0", v5, ""},
+		{"^0.3", v3, ""},
+		{"~0.4.0", v4, ""},
+		{">=0.3.0, <0.5.0", v4, ""},
+		{"<0.5.0 !=0.4.0", v3, ""},
+		{"=0.3.1", v3, ""},
+		{"0.5.0-rc.1", v5, ""},
+		{"^1.0.0", nil, "no runtime registered for engine 'VERSIONED' satisfies version '^1.0.0'"},
+		{">=abc", nil, "invalid version constraint '>=abc': invalid semver 'abc.0.0': malformed numeric component 'abc'"},
+	}
+
+	for _, test := range tests {
+		resolved, err := registry.ResolveEngineRuntime(kind, test.constraint)
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.constraint)
+
+			continue
+		}
+
+		require.NoError(t, err, test.constraint)
+		require.Same(t, test.runtime, resolved, test.constraint)
+	}
+
+	// re-registering the same version overwrites only that version
+	v4b := &mockEngineRuntime{kind: kind, version: "0.4.0"}
+	registry.Register(v4b, nil)
+
+	resolved, err := registry.ResolveEngineRuntime(kind, "~0.4")
+	require.NoError(t, err)
+	require.Same(t, v4b, resolved)
+	require.Len(t, registry.RegisteredVersions(kind), 3)
+
+	require.True(t, registry.UnregisterVersion(kind, "0.5.0-rc.1"))
+	require.False(t, registry.UnregisterVersion(kind, "0.5.0-rc.1"))
+
+	latest, _ = registry.FetchEngineRuntime(kind)
+	require.Same(t, v4b, latest)
+
+	// a runtime with only pre-release versions is not resolved without a constraint
+	registry.Register(&mockEngineRuntime{kind: "PREVIEW", version: "0.1.0-beta"}, nil)
+
+	_, ok = registry.FetchEngineRuntime("PREVIEW")
+	require.False(t, ok)
+
+	_, err = registry.ResolveEngineRuntime("PREVIEW", "")
+	require.EqualError(t, err, "no runtime registered for engine 'PREVIEW' with a stable version")
+
+	_, err = registry.ResolveEngineRuntime("MISSING", "")
+	require.EqualError(t, err, "unknown engine 'MISSING'")
+	require.ErrorIs(t, err, ErrUnknownEngine)
+
+	_, err = registry.ResolveEngineRuntime(PISA, "")
+	require.EqualError(t, err, "no runtime registered for engine 'PISA'")
+	require.ErrorIs(t, err, ErrRuntimeNotRegistered)
+
+	err = registry.Register(&mockEngineRuntime{kind: kind, version: "latest"}, nil)
+	require.EqualError(t, err, "cannot register VERSIONED runtime: invalid semver 'latest': expected major.minor.patch")
+
+	require.Panics(t, func() {
+		registry.MustRegister(&mockEngineRuntime{kind: kind, version: "latest"}, nil)
+	})
+}
+
+func TestRegistry_Isolation(t *testing.T) {
+	first, second := NewRegistry(), NewRegistry()
+	first.Register(&mockEngineRuntime{kind: "ISOLATED"}, nil)
+
+	_, ok := first.FetchEngineRuntime("ISOLATED")
+	require.True(t, ok)
+
+	_, ok = second.FetchEngineRuntime("ISOLATED")
+	require.False(t, ok)
+
+	_, ok = FetchEngineRuntime("ISOLATED")
+	require.False(t, ok)
+
+	require.NotNil(t, DefaultRegistry())
+	require.Same(t, DefaultRegistry(), DefaultRegistry())
+}
+
 // mock EngineRuntime implementation for testing
 type mockEngineRuntime struct {
-	kind EngineKind
+	kind     EngineKind
+	version  string
+	elements map[ElementKind]ManifestElementGenerator
 }
 
 func (m *mockEngineRuntime) Kind() EngineKind {
@@ -53,7 +244,11 @@ func (m *mockEngineRuntime) Kind() EngineKind {
 }
 
 func (m *mockEngineRuntime) Version() string {
-	return "v0.0.0"
+	if m.version == "" {
+		return "v0.0.0"
+	}
+
+	return m.version
 }
 
 func (m *mockEngineRuntime) SpawnEngine(_ EngineFuel, _ Logic, _ CtxDriver, _ EnvDriver) (Engine, error) {
@@ -68,8 +263,10 @@ func (m *mockEngineRuntime) ValidateCalldata(_ Logic, _ IxnDriver) error {
 	return nil
 }
 
-func (m *mockEngineRuntime) GetElementGenerator(_ ElementKind) (ManifestElementGenerator, bool) {
-	return nil, false
+func (m *mockEngineRuntime) GetElementGenerator(kind ElementKind) (ManifestElementGenerator, bool) {
+	generator, ok := m.elements[kind]
+
+	return generator, ok
 }
 
 func (m *mockEngineRuntime) GetCallEncoder(_ *Callsite, _ Logic) (CallEncoder, error) {
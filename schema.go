@@ -0,0 +1,437 @@
+package engineio
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// JSONSchemaDialect is the JSON Schema dialect of the schemas generated by ManifestSchema
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is a JSON Schema document (or a sub-schema of one) for validating manifest files.
+// It only describes the keywords used by the schemas generated by ManifestSchema and ElementSchema,
+// and can be rendered with json.Marshal to be consumed by editors and other validators.
+type JSONSchema struct {
+	Schema      string `yaml:"$schema,omitempty" json:"$schema,omitempty"`
+	Ref         string `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Title       string `yaml:"title,omitempty" json:"title,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	Type     string `yaml:"type,omitempty" json:"type,omitempty"`
+	Enum     []any  `yaml:"enum,omitempty" json:"enum,omitempty"`
+	Const    any    `yaml:"const,omitempty" json:"const,omitempty"`
+	Pattern  string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Minimum  *int64 `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+	Examples []any  `yaml:"examples,omitempty" json:"examples,omitempty"`
+
+	Properties map[string]*JSONSchema `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Required   []string               `yaml:"required,omitempty" json:"required,omitempty"`
+
+	AdditionalProperties *JSONSchema `yaml:"additionalProperties,omitempty" json:"additionalProperties,omitempty"`
+	Items                *JSONSchema `yaml:"items,omitempty" json:"items,omitempty"`
+
+	AllOf []*JSONSchema `yaml:"allOf,omitempty" json:"allOf,omitempty"`
+	If    *JSONSchema   `yaml:"if,omitempty" json:"if,omitempty"`
+	Then  *JSONSchema   `yaml:"then,omitempty" json:"then,omitempty"`
+
+	Defs map[string]*JSONSchema `yaml:"$defs,omitempty" json:"$defs,omitempty"`
+}
+
+// SchemaProvider is an optional interface that can be implemented by a ManifestElementObject (or any type
+// used in its fields) to provide the JSON Schema of its JSON and YAML forms. The schema of types that do not
+// implement it is derived from their Go type with reflection (see ElementSchema).
+type SchemaProvider interface {
+	JSONSchema() *JSONSchema
+}
+
+// ManifestSchema returns the JSON Schema for manifest files of an encoding with the runtimes in the
+// default Registry. See Registry.ManifestSchema for details.
+func ManifestSchema(encoding Encoding) (*JSONSchema, error) {
+	return defaultRegistry.ManifestSchema(encoding)
+}
+
+// ElementSchema returns the JSON Schema for the data of an element kind of the latest runtime for
+// an engine kind in the default Registry. See Registry.ElementSchema for details.
+func ElementSchema(engine EngineKind, kind ElementKind, encoding Encoding) (*JSONSchema, error) {
+	return defaultRegistry.ElementSchema(engine, kind, encoding)
+}
+
+// ManifestSchema returns the JSON Schema for manifest files of the given encoding (JSON or YAML),
+// which editors can use to validate and autocomplete them. The schemas of the encodings only differ
+// in the element data, whose fields are named by their 'json' or 'yaml' tags (see ElementSchema).
+//
+// The schema describes the Manifest envelope, restricts the syntax to the supported syntax versions (see
+// SupportedSyntaxes) and restricts the engine kind to the engines of the runtimes in the Registry. Engine kinds
+// are matched case-insensitively (as with ParseEngineKind) and their canonical spellings are listed as examples.
+// For each engine whose latest runtime describes its element kinds (see CapabilityDescriber), the kind of the
+// elements is restricted to them and the data of each element is validated with the schema for its kind.
+// The sub-schemas are available in the '$defs' of the schema, as '<engine>' for the elements of an engine
+// and '<engine>.<element kind>' for the data of an element kind.
+//
+// Returns an error if the encoding is not JSON or YAML, or if the schema for
+// an element kind described by a runtime cannot be generated.
+func (registry *Registry) ManifestSchema(encoding Encoding) (*JSONSchema, error) {
+	if encoding != JSON && encoding != YAML {
+		return nil, errors.New("unsupported manifest encoding")
+	}
+
+	syntaxes := make([]any, 0)
+	for _, syntax := range SupportedSyntaxes() {
+		syntaxes = append(syntaxes, syntax)
+	}
+
+	schema := &JSONSchema{
+		Schema:      JSONSchemaDialect,
+		Title:       "MOI Logic Manifest",
+		Description: fmt.Sprintf("Logic Manifest of syntax version %v or older", CurrentSyntax()),
+		Type:        "object",
+		Required:    []string{"syntax", "engine", "elements"},
+		Properties: map[string]*JSONSchema{
+			"syntax": {Type: "string", Enum: syntaxes},
+			"engine": {
+				Type:     "object",
+				Required: []string{"kind"},
+				Properties: map[string]*JSONSchema{
+					"kind":    {Type: "string", Examples: make([]any, 0)},
+					"flags":   {Type: "array", Items: &JSONSchema{Type: "string"}},
+					"version": {Type: "string", Description: "Semver constraint for the version of the engine runtime"},
+				},
+			},
+			"elements": {Type: "array", Items: &JSONSchema{Ref: "#/$defs/element"}},
+		},
+		Defs: map[string]*JSONSchema{
+			"element": {
+				Type:     "object",
+				Required: []string{"ptr", "kind", "data"},
+				Properties: map[string]*JSONSchema{
+					"ptr":  pointerSchema(),
+					"deps": {Type: "array", Items: pointerSchema()},
+					"kind": {Type: "string"},
+					"data": {},
+				},
+			},
+		},
+	}
+
+	engines := registry.RegisteredKinds()
+	engineKinds := schema.Properties["engine"].Properties["kind"]
+
+	if len(engines) > 0 {
+		engineKinds.Pattern = engineKindPattern(engines...)
+	}
+
+	for _, engine := range engines {
+		engineKinds.Examples = append(engineKinds.Examples, string(engine))
+
+		runtime, ok := registry.FetchEngineRuntime(engine)
+		if !ok {
+			continue
+		}
+
+		capabilities, ok := CapabilitiesOf(runtime)
+		if !ok {
+			continue
+		}
+
+		elements := &JSONSchema{
+			Ref:        "#/$defs/element",
+			Properties: map[string]*JSONSchema{"kind": {Enum: make([]any, 0)}},
+		}
+
+		for _, kind := range capabilities.Elements {
+			data, err := elementSchema(runtime, kind, encoding)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to generate schema for %v elements", engine)
+			}
+
+			name := fmt.Sprintf("%v.%v", engine, kind)
+			schema.Defs[name] = data
+
+			elements.Properties["kind"].Enum = append(elements.Properties["kind"].Enum, string(kind))
+			elements.AllOf = append(elements.AllOf, &JSONSchema{
+				If: &JSONSchema{
+					Required:   []string{"kind"},
+					Properties: map[string]*JSONSchema{"kind": {Const: string(kind)}},
+				},
+				Then: &JSONSchema{
+					Properties: map[string]*JSONSchema{"data": {Ref: "#/$defs/" + name}},
+				},
+			})
+		}
+
+		schema.Defs[string(engine)] = elements
+		schema.AllOf = append(schema.AllOf, &JSONSchema{
+			If: &JSONSchema{
+				Required: []string{"engine"},
+				Properties: map[string]*JSONSchema{
+					"engine": {
+						Required:   []string{"kind"},
+						Properties: map[string]*JSONSchema{"kind": {Pattern: engineKindPattern(engine)}},
+					},
+				},
+			},
+			Then: &JSONSchema{
+				Properties: map[string]*JSONSchema{
+					"elements": {Items: &JSONSchema{Ref: "#/$defs/" + string(engine)}},
+				},
+			},
+		})
+	}
+
+	return schema, nil
+}
+
+// ElementSchema returns the JSON Schema for the data of an element kind of the latest runtime for an engine kind
+// in the Registry, in the given encoding (JSON or YAML). The schema is provided by the object returned by the
+// element generator of the runtime if it implements SchemaProvider, or derived from its Go type with reflection
+// otherwise. Derived schemas describe the form of the object in the encoding and no properties are required.
+// For JSON, struct fields are named by their 'json' tags and untagged embedded structs are promoted, as with
+// encoding/json. For YAML, struct fields are named by their 'yaml' tags (or their lowercased name) and only
+// embedded structs with the 'inline' flag are promoted, as with yaml.v3.
+//
+// Returns an error if the encoding is not JSON or YAML, if no runtime is registered
+// for the engine kind or if it does not support the element kind.
+func (registry *Registry) ElementSchema(engine EngineKind, kind ElementKind, encoding Encoding) (*JSONSchema, error) {
+	if encoding != JSON && encoding != YAML {
+		return nil, errors.New("unsupported manifest encoding")
+	}
+
+	runtime, err := registry.ResolveEngineRuntime(engine, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return elementSchema(runtime, kind, encoding)
+}
+
+// elementSchema returns the JSON Schema for the data of an element kind of the EngineRuntime in an encoding
+func elementSchema(runtime EngineRuntime, kind ElementKind, encoding Encoding) (*JSONSchema, error) {
+	generator, ok := runtime.GetElementGenerator(kind)
+	if !ok {
+		return nil, errors.Errorf("unrecognized element kind: '%v'", kind)
+	}
+
+	object := generator()
+	if object == nil {
+		return &JSONSchema{}, nil
+	}
+
+	return reflectSchema(reflect.TypeOf(object), encoding), nil
+}
+
+// engineKindPattern returns a regular expression that matches the engine kinds case-insensitively
+// and with surrounding whitespace, which are the spellings of the engine kinds accepted by ParseEngineKind
+func engineKindPattern(engines ...EngineKind) string {
+	alternatives := make([]string, 0, len(engines))
+
+	for _, engine := range engines {
+		var pattern strings.Builder
+
+		for _, char := range string(engine) {
+			if char >= 'A' && char <= 'Z' {
+				fmt.Fprintf(&pattern, "[%c%c]", char, unicode.ToLower(char))
+			} else {
+				pattern.WriteString(regexp.QuoteMeta(string(char)))
+			}
+		}
+
+		alternatives = append(alternatives, pattern.String())
+	}
+
+	return `^\s*(` + strings.Join(alternatives, "|") + `)\s*$`
+}
+
+// pointerSchema returns the JSON Schema for an ElementPtr
+func pointerSchema() *JSONSchema {
+	minimum := int64(0)
+
+	return &JSONSchema{Type: "integer", Minimum: &minimum}
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	yamlMarshalerType = reflect.TypeOf((*yaml.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaReflector derives JSON Schemas for the JSON or YAML form of Go types. The types that are
+// being visited are tracked to break recursion, and a recursive reference to a type accepts any value.
+type schemaReflector struct {
+	encoding Encoding
+	visiting map[reflect.Type]struct{}
+}
+
+// reflectSchema derives the JSON Schema for the JSON or YAML form of a Go type
+func reflectSchema(typ reflect.Type, encoding Encoding) *JSONSchema {
+	reflector := &schemaReflector{encoding: encoding, visiting: make(map[reflect.Type]struct{})}
+
+	return reflector.reflect(typ)
+}
+
+// reflect derives the JSON Schema for a Go type
+func (reflector *schemaReflector) reflect(typ reflect.Type) *JSONSchema {
+	if provided, ok := providedSchema(typ); ok {
+		return provided
+	}
+
+	// Marshalers are checked on the pointer type to detect the methods with pointer receivers
+	base := typ
+	if base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+
+	marshaler := jsonMarshalerType
+	if reflector.encoding == YAML {
+		marshaler = yamlMarshalerType
+	}
+
+	switch {
+	case reflect.PtrTo(base).Implements(marshaler):
+		return &JSONSchema{}
+	case reflect.PtrTo(base).Implements(textMarshalerType):
+		return &JSONSchema{Type: "string"}
+	}
+
+	if _, recursive := reflector.visiting[typ]; recursive {
+		return &JSONSchema{}
+	}
+
+	reflector.visiting[typ] = struct{}{}
+	defer delete(reflector.visiting, typ)
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return reflector.reflect(typ.Elem())
+
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer"}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		minimum := int64(0)
+
+		return &JSONSchema{Type: "integer", Minimum: &minimum}
+
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 strings
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: "string"}
+		}
+
+		return &JSONSchema{Type: "array", Items: reflector.reflect(typ.Elem())}
+
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: reflector.reflect(typ.Elem())}
+
+	case reflect.Struct:
+		schema := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+		reflector.fields(typ, schema)
+
+		return schema
+
+	default:
+		return &JSONSchema{}
+	}
+}
+
+// providedSchema returns the schema of a type (or the type it points to) that implements
+// SchemaProvider with a value or pointer receiver. Returns false if it does not implement it.
+func providedSchema(typ reflect.Type) (*JSONSchema, bool) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	provider, ok := reflect.New(typ).Interface().(SchemaProvider)
+	if !ok {
+		return nil, false
+	}
+
+	return provider.JSONSchema(), true
+}
+
+// fields adds the schemas of the exported fields of a struct type to the properties of the schema.
+// For JSON, fields are named by their 'json' tag (or their name) and the fields of embedded structs without
+// a tag are promoted. For YAML, fields are named by their 'yaml' tag (or their lowercased name) and the
+// fields of embedded structs with the 'inline' flag are promoted.
+func (reflector *schemaReflector) fields(typ reflect.Type, schema *JSONSchema) {
+	key := "json"
+	if reflector.encoding == YAML {
+		key = "yaml"
+	}
+
+	for idx := 0; idx < typ.NumField(); idx++ {
+		field := typ.Field(idx)
+
+		name, flags, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			continue
+		}
+
+		promoted := field.Anonymous && name == ""
+		if reflector.encoding == YAML {
+			promoted = hasTagFlag(flags, "inline")
+		}
+
+		if promoted {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			switch embedded.Kind() {
+			case reflect.Struct:
+				reflector.fields(embedded, schema)
+
+				continue
+
+			case reflect.Map:
+				// Inlined maps of YAML hold the keys that do not match a field
+				if reflector.encoding == YAML {
+					schema.AdditionalProperties = reflector.reflect(embedded.Elem())
+
+					continue
+				}
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+			if reflector.encoding == YAML {
+				name = strings.ToLower(field.Name)
+			}
+		}
+
+		schema.Properties[name] = reflector.reflect(field.Type)
+	}
+}
+
+// hasTagFlag returns whether the comma-separated flags of a struct tag contain a flag
+func hasTagFlag(flags, flag string) bool {
+	for _, candidate := range strings.Split(flags, ",") {
+		if candidate == flag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,248 @@
+package engineio
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// providedElement is a ManifestElementObject that provides its own JSON Schema
+type providedElement struct {
+	mockElement
+}
+
+func (providedElement) JSONSchema() *JSONSchema {
+	return &JSONSchema{Type: "string", Enum: []any{"foo", "bar"}}
+}
+
+// describedRuntime is a mock runtime that describes its element kinds with its capabilities
+type describedRuntime struct {
+	*mockEngineRuntime
+	kinds []ElementKind
+}
+
+func (runtime describedRuntime) Capabilities() RuntimeCapabilities {
+	return RuntimeCapabilities{Elements: runtime.kinds}
+}
+
+func TestRegistry_ManifestSchema(t *testing.T) {
+	described := newMockRuntime("DESCRIBED", "0.1.0")
+	described.elements["provided"] = func() ManifestElementObject { return new(providedElement) }
+
+	registry := NewRegistry()
+	registry.Register(describedRuntime{described, []ElementKind{"mock", "provided"}}, nil)
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	schema, err := registry.ManifestSchema(JSON)
+	require.NoError(t, err)
+	require.Equal(t, JSONSchemaDialect, schema.Schema)
+	require.Equal(t, []string{"syntax", "engine", "elements"}, schema.Required)
+	require.Equal(t, "#/$defs/element", schema.Properties["elements"].Items.Ref)
+
+	syntaxes := make([]any, 0)
+	for _, syntax := range SupportedSyntaxes() {
+		syntaxes = append(syntaxes, syntax)
+	}
+
+	require.Equal(t, syntaxes, schema.Properties["syntax"].Enum)
+	require.Contains(t, schema.Properties["syntax"].Enum, CurrentSyntax())
+
+	// Engine kinds are matched case-insensitively, as they are parsed
+	engineKind := schema.Properties["engine"].Properties["kind"]
+	require.Equal(t, []any{"DESCRIBED", "MOCK"}, engineKind.Examples)
+	require.Equal(t, `^\s*([Dd][Ee][Ss][Cc][Rr][Ii][Bb][Ee][Dd]|[Mm][Oo][Cc][Kk])\s*$`, engineKind.Pattern)
+
+	pattern := regexp.MustCompile(engineKind.Pattern)
+	for _, kind := range []string{"MOCK", "mock", " Mock "} {
+		require.True(t, pattern.MatchString(kind), kind)
+
+		_, err = ParseEngineKind(kind)
+		require.NoError(t, err)
+	}
+
+	require.False(t, pattern.MatchString("MOCKED"))
+
+	// Only the engine whose runtime describes its element kinds has element schemas
+	require.Len(t, schema.AllOf, 1)
+	require.Equal(t, engineKindPattern("DESCRIBED"), schema.AllOf[0].If.Properties["engine"].Properties["kind"].Pattern)
+	require.Equal(t, "#/$defs/DESCRIBED", schema.AllOf[0].Then.Properties["elements"].Items.Ref)
+
+	elements := schema.Defs["DESCRIBED"]
+	require.Equal(t, "#/$defs/element", elements.Ref)
+	require.Equal(t, []any{"mock", "provided"}, elements.Properties["kind"].Enum)
+	require.Len(t, elements.AllOf, 2)
+	require.Equal(t, "provided", elements.AllOf[1].If.Properties["kind"].Const)
+	require.Equal(t, "#/$defs/DESCRIBED.provided", elements.AllOf[1].Then.Properties["data"].Ref)
+
+	require.Equal(t, providedElement{}.JSONSchema(), schema.Defs["DESCRIBED.provided"])
+	require.Contains(t, schema.Defs, "DESCRIBED.mock")
+	require.NotContains(t, schema.Defs, "MOCK")
+
+	encoded, err := json.Marshal(schema.Defs["element"])
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "object",
+		"required": ["ptr", "kind", "data"],
+		"properties": {
+			"ptr": {"type": "integer", "minimum": 0},
+			"deps": {"type": "array", "items": {"type": "integer", "minimum": 0}},
+			"kind": {"type": "string"},
+			"data": {}
+		}
+	}`, string(encoded))
+
+	// The element data of YAML manifests is named by the yaml tags
+	schema, err = registry.ManifestSchema(YAML)
+	require.NoError(t, err)
+	require.Contains(t, schema.Defs["DESCRIBED.mock"].Properties, "name")
+
+	_, err = registry.ManifestSchema(POLO)
+	require.EqualError(t, err, "unsupported manifest encoding")
+
+	// The schemas of element kinds that are described but cannot be generated fail the manifest schema
+	registry = NewRegistry()
+	registry.Register(describedRuntime{newMockRuntime("DESCRIBED", "0.1.0"), []ElementKind{"mock", "missing"}}, nil)
+
+	_, err = registry.ManifestSchema(JSON)
+	require.EqualError(t, err, "failed to generate schema for DESCRIBED elements: unrecognized element kind: 'missing'")
+
+	// Without any engines, the engine kind is not restricted
+	schema, err = NewRegistry().ManifestSchema(JSON)
+	require.NoError(t, err)
+	require.Empty(t, schema.Properties["engine"].Properties["kind"].Pattern)
+}
+
+func TestRegistry_ElementSchema(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	schema, err := registry.ElementSchema("MOCK", "mock", JSON)
+	require.NoError(t, err)
+
+	encoded, err := json.Marshal(schema)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "object",
+		"properties": {"name": {"type": "string"}, "value": {"type": "integer", "minimum": 0}}
+	}`, string(encoded))
+
+	_, err = registry.ElementSchema("MOCK", "missing", JSON)
+	require.EqualError(t, err, "unrecognized element kind: 'missing'")
+
+	_, err = registry.ElementSchema("OTHER", "mock", YAML)
+	require.ErrorIs(t, err, ErrUnknownEngine)
+
+	_, err = registry.ElementSchema("MOCK", "mock", POLO)
+	require.EqualError(t, err, "unsupported manifest encoding")
+}
+
+func TestEngineKindPattern(t *testing.T) {
+	pattern := regexp.MustCompile(engineKindPattern("PISA", "C++.V2"))
+
+	for _, kind := range []string{"PISA", " pisa ", "C++.V2", "c++.v2"} {
+		require.True(t, pattern.MatchString(kind), kind)
+	}
+
+	// the characters of the engine kinds that are not letters are matched literally
+	for _, kind := range []string{"C+.V2", "C++XV2", "CCC.V2", "PISAS"} {
+		require.False(t, pattern.MatchString(kind), kind)
+	}
+}
+
+func TestReflectSchema(t *testing.T) {
+	type Embedded struct {
+		Promoted bool `json:"promoted"`
+	}
+
+	type Node struct {
+		Children []*Node `json:"children"`
+	}
+
+	type object struct {
+		Embedded
+
+		Name     string            `json:"name,omitempty"`
+		Count    int32             `json:"count"`
+		Ratio    float64           `json:"ratio"`
+		Raw      []byte            `json:"raw"`
+		Labels   map[string]uint16 `json:"labels"`
+		Pointer  *string           `json:"pointer"`
+		Fixed    [2]bool           `json:"fixed"`
+		Hash     Hash              `json:"hash"`
+		Kind     EngineKind        `json:"kind"`
+		Provided providedElement   `json:"provided"`
+		Tree     Node              `json:"tree"`
+		Any      any               `json:"any"`
+		Untagged string
+		Skipped  string `json:"-"`
+		hidden   string //nolint:unused
+	}
+
+	schema := reflectSchema(reflect.TypeOf(&object{}), JSON)
+
+	encoded, err := json.Marshal(schema)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"promoted": {"type": "boolean"},
+			"name": {"type": "string"},
+			"count": {"type": "integer"},
+			"ratio": {"type": "number"},
+			"raw": {"type": "string"},
+			"labels": {"type": "object", "additionalProperties": {"type": "integer", "minimum": 0}},
+			"pointer": {"type": "string"},
+			"fixed": {"type": "array", "items": {"type": "boolean"}},
+			"hash": {"type": "string"},
+			"kind": {},
+			"provided": {"type": "string", "enum": ["foo", "bar"]},
+			"tree": {"type": "object", "properties": {"children": {"type": "array", "items": {}}}},
+			"any": {},
+			"Untagged": {"type": "string"}
+		}
+	}`, string(encoded))
+}
+
+func TestReflectSchema_YAML(t *testing.T) {
+	type Embedded struct {
+		Promoted bool `yaml:"promoted"`
+	}
+
+	type Inlined struct {
+		Inner string `yaml:"inner"`
+	}
+
+	type object struct {
+		Embedded
+
+		Inlined  Inlined           `yaml:",inline"`
+		Extra    map[string]uint8  `yaml:",inline"`
+		Name     string            `yaml:"name,omitempty" json:"title"`
+		Labels   map[string]string `json:"labels"`
+		Hash     Hash              `yaml:"hash"`
+		Signed   SignedManifest    `yaml:"signed"`
+		Untagged int64
+		Skipped  string `yaml:"-"`
+	}
+
+	schema := reflectSchema(reflect.TypeOf(object{}), YAML)
+
+	encoded, err := json.Marshal(schema)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"embedded": {"type": "object", "properties": {"promoted": {"type": "boolean"}}},
+			"inner": {"type": "string"},
+			"name": {"type": "string"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"hash": {"type": "string"},
+			"signed": {},
+			"untagged": {"type": "integer"}
+		},
+		"additionalProperties": {"type": "integer", "minimum": 0}
+	}`, string(encoded))
+}
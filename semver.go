@@ -0,0 +1,273 @@
+package engineio
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// semver is a parsed semantic version (https://semver.org) with
+// an optional pre-release. Build metadata is accepted and discarded.
+type semver struct {
+	major, minor, patch uint64
+	prerelease          []string
+}
+
+// parseSemver parses a semantic version string with an optional 'v' prefix.
+func parseSemver(version string) (semver, error) {
+	raw := strings.TrimPrefix(strings.TrimSpace(version), "v")
+
+	// Discard build metadata, it has no bearing on precedence
+	if idx := strings.IndexByte(raw, '+'); idx >= 0 {
+		raw = raw[:idx]
+	}
+
+	var parsed semver
+
+	if idx := strings.IndexByte(raw, '-'); idx >= 0 {
+		if idx == len(raw)-1 {
+			return semver{}, errors.Errorf("invalid semver '%v': empty pre-release", version)
+		}
+
+		parsed.prerelease = strings.Split(raw[idx+1:], ".")
+		raw = raw[:idx]
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return semver{}, errors.Errorf("invalid semver '%v': expected major.minor.patch", version)
+	}
+
+	numbers := make([]uint64, 3)
+
+	for idx, part := range parts {
+		number, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return semver{}, errors.Errorf("invalid semver '%v': malformed numeric component '%v'", version, part)
+		}
+
+		numbers[idx] = number
+	}
+
+	parsed.major, parsed.minor, parsed.patch = numbers[0], numbers[1], numbers[2]
+
+	return parsed, nil
+}
+
+// String returns the canonical string form of the semver (without a 'v' prefix)
+func (version semver) String() string {
+	str := strconv.FormatUint(version.major, 10) + "." +
+		strconv.FormatUint(version.minor, 10) + "." +
+		strconv.FormatUint(version.patch, 10)
+
+	if len(version.prerelease) > 0 {
+		str += "-" + strings.Join(version.prerelease, ".")
+	}
+
+	return str
+}
+
+// compare returns -1, 0 or 1 if the version has a lower, equal or higher precedence than other.
+func (version semver) compare(other semver) int {
+	for _, pair := range [][2]uint64{
+		{version.major, other.major},
+		{version.minor, other.minor},
+		{version.patch, other.patch},
+	} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	switch {
+	case len(version.prerelease) == 0 && len(other.prerelease) == 0:
+		return 0
+	// A version without a pre-release has a higher precedence
+	case len(version.prerelease) == 0:
+		return 1
+	case len(other.prerelease) == 0:
+		return -1
+	}
+
+	for idx := 0; idx < len(version.prerelease) && idx < len(other.prerelease); idx++ {
+		if cmp := comparePrerelease(version.prerelease[idx], other.prerelease[idx]); cmp != 0 {
+			return cmp
+		}
+	}
+
+	switch {
+	case len(version.prerelease) < len(other.prerelease):
+		return -1
+	case len(version.prerelease) > len(other.prerelease):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePrerelease compares two pre-release identifiers. Numeric identifiers are compared
+// numerically and always have a lower precedence than alphanumeric identifiers.
+func comparePrerelease(a, b string) int {
+	numA, errA := strconv.ParseUint(a, 10, 64)
+	numB, errB := strconv.ParseUint(b, 10, 64)
+
+	switch {
+	case errA == nil && errB == nil:
+		switch {
+		case numA < numB:
+			return -1
+		case numA > numB:
+			return 1
+		default:
+			return 0
+		}
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// versionConstraint is a set of version comparators that must all be satisfied.
+// An empty constraint is satisfied by every version.
+type versionConstraint []versionComparator
+
+type versionComparator struct {
+	operator string
+	version  semver
+}
+
+// parseVersionConstraint parses a version constraint string. It is composed of one or more comparators
+// separated by whitespace or commas, all of which must be satisfied. Supported comparators are:
+//   - "1.2.3" or "=1.2.3": exactly the version
+//   - ">1.2.3", ">=1.2.3", "<1.2.3", "<=1.2.3", "!=1.2.3": ranged comparisons
+//   - "^1.2.3": compatible versions (>=1.2.3 <2.0.0, or >=0.2.3 <0.3.0 for 0.x versions)
+//   - "~1.2.3": patch updates only (>=1.2.3 <1.3.0)
+//   - "*" or "x": any version
+//
+// Partial versions such as "1.2" or "1" are padded with zeroes (i.e. "^0.4" is equivalent to "^0.4.0")
+func parseVersionConstraint(constraint string) (versionConstraint, error) {
+	fields := strings.FieldsFunc(constraint, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+
+	parsed := make(versionConstraint, 0, len(fields))
+
+	for _, field := range fields {
+		if field == "*" || field == "x" || field == "X" {
+			continue
+		}
+
+		operator := ""
+
+		for _, op := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(field, op) {
+				operator = op
+
+				break
+			}
+		}
+
+		raw := strings.TrimPrefix(field, operator)
+		if operator == "" {
+			operator = "="
+		}
+
+		version, err := parsePartialSemver(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version constraint '%v'", constraint)
+		}
+
+		parsed = append(parsed, versionComparator{operator, version})
+	}
+
+	return parsed, nil
+}
+
+// parsePartialSemver parses a semver which may omit its minor and patch components
+func parsePartialSemver(version string) (semver, error) {
+	core := strings.TrimPrefix(version, "v")
+	if idx := strings.IndexAny(core, "-+"); idx >= 0 {
+		core = core[:idx]
+	}
+
+	switch strings.Count(core, ".") {
+	case 0:
+		return parseSemver(strings.Replace(version, core, core+".0.0", 1))
+	case 1:
+		return parseSemver(strings.Replace(version, core, core+".0", 1))
+	default:
+		return parseSemver(version)
+	}
+}
+
+// satisfiedBy returns whether the given version satisfies all the comparators of the constraint.
+// A pre-release version only satisfies a constraint if one of its comparators explicitly refers to a
+// pre-release of the same major.minor.patch version (i.e. "^1.0.0" rejects "1.1.0-rc.1"), so that an
+// empty constraint (or "*") is only satisfied by stable versions.
+func (constraint versionConstraint) satisfiedBy(version semver) bool {
+	allowPrerelease := len(version.prerelease) == 0
+
+	for _, comparator := range constraint {
+		if !comparator.satisfiedBy(version) {
+			return false
+		}
+
+		if len(comparator.version.prerelease) > 0 &&
+			comparator.version.major == version.major &&
+			comparator.version.minor == version.minor &&
+			comparator.version.patch == version.patch {
+			allowPrerelease = true
+		}
+	}
+
+	return allowPrerelease
+}
+
+func (comparator versionComparator) satisfiedBy(version semver) bool {
+	cmp := version.compare(comparator.version)
+
+	switch comparator.operator {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+
+	case "~":
+		upper := semver{major: comparator.version.major, minor: comparator.version.minor + 1}
+
+		return cmp >= 0 && version.compare(upper) < 0
+
+	case "^":
+		var upper semver
+
+		switch base := comparator.version; {
+		case base.major > 0:
+			upper = semver{major: base.major + 1}
+		case base.minor > 0:
+			upper = semver{minor: base.minor + 1}
+		default:
+			upper = semver{patch: base.patch + 1}
+		}
+
+		return cmp >= 0 && version.compare(upper) < 0
+
+	default:
+		return false
+	}
+}
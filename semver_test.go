@@ -0,0 +1,96 @@
+package engineio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+		err    string
+	}{
+		{"1.2.3", "1.2.3", ""},
+		{"v0.4.0", "0.4.0", ""},
+		{"1.0.0-alpha.1", "1.0.0-alpha.1", ""},
+		{"1.0.0+build.5", "1.0.0", ""},
+		{"1.0", "", "invalid semver '1.0': expected major.minor.patch"},
+		{"1.x.0", "", "invalid semver '1.x.0': malformed numeric component 'x'"},
+		{"1.0.0-", "", "invalid semver '1.0.0-': empty pre-release"},
+	}
+
+	for _, test := range tests {
+		version, err := parseSemver(test.input)
+		if test.err != "" {
+			require.EqualError(t, err, test.err)
+
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, test.output, version.String())
+	}
+}
+
+func TestSemver_Compare(t *testing.T) {
+	// versions in ascending order of precedence
+	ordered := []string{
+		"0.1.0", "0.1.1", "0.2.0",
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta",
+		"1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1",
+		"1.0.0", "1.10.0", "2.0.0",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := parseSemver(ordered[i])
+			b, _ := parseSemver(ordered[j])
+
+			switch {
+			case i < j:
+				require.Equal(t, -1, a.compare(b), "%v < %v", ordered[i], ordered[j])
+			case i > j:
+				require.Equal(t, 1, a.compare(b), "%v > %v", ordered[i], ordered[j])
+			default:
+				require.Equal(t, 0, a.compare(b), "%v = %v", ordered[i], ordered[j])
+			}
+		}
+	}
+}
+
+func TestVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{"", []string{"0.0.1", "1.2.3"}, []string{"1.2.3-rc.1"}},
+		{"*", []string{"1.2.3"}, []string{"1.2.3-rc.1"}},
+		{"1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"^1.2", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0"}},
+		{"^0.4", []string{"0.4.0", "0.4.7"}, []string{"0.5.0", "0.3.9"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"}},
+		{">1.0.0, <=2.0.0", []string{"1.0.1", "2.0.0"}, []string{"1.0.0", "2.0.1"}},
+		{"!=0.4.0", []string{"0.4.1"}, []string{"0.4.0"}},
+		{"^1.0.0", []string{"1.1.0"}, []string{"1.1.0-rc.1"}},
+		{">=1.1.0-rc.1", []string{"1.1.0-rc.2", "1.1.0"}, []string{"1.2.0-rc.1"}},
+	}
+
+	for _, test := range tests {
+		constraint, err := parseVersionConstraint(test.constraint)
+		require.NoError(t, err)
+
+		for _, version := range test.matches {
+			parsed, _ := parseSemver(version)
+			require.True(t, constraint.satisfiedBy(parsed), "%v should satisfy '%v'", version, test.constraint)
+		}
+
+		for _, version := range test.rejects {
+			parsed, _ := parseSemver(version)
+			require.False(t, constraint.satisfiedBy(parsed), "%v should not satisfy '%v'", version, test.constraint)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package engineio
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidManifestSignature is returned when the signature of a SignedManifest
+// is well-formed but does not verify for its manifest hash and public key
+var ErrInvalidManifestSignature = errors.New("invalid manifest signature")
+
+// ManifestSignatureDomain is the domain tag that is prefixed to the manifest hash in the data signed by
+// SignManifest. It separates manifest signatures from signatures over other data with the same keys.
+const ManifestSignatureDomain = "moi-manifest-v1:"
+
+// manifestSigningData returns the data that is signed for a manifest hash,
+// which is the ManifestSignatureDomain followed by the bytes of the hash
+func manifestSigningData(hash Hash) []byte {
+	data := make([]byte, 0, len(ManifestSignatureDomain)+len(hash))
+	data = append(data, ManifestSignatureDomain...)
+
+	return append(data, hash[:]...)
+}
+
+// ManifestSigner is an interface for generating signatures over Manifests with SignManifest.
+// Signatures must be verifiable by the CryptoDriver registered for the engine of the Manifest.
+type ManifestSigner interface {
+	// PublicKey returns the public key for which the signatures of the signer can be verified
+	PublicKey() []byte
+	// Sign returns the signature of the signer over the given data
+	Sign([]byte) ([]byte, error)
+}
+
+// SignedManifest is an envelope for a Manifest that proves who published it. It carries the Manifest
+// along with its canonical hash (see Manifest.CanonicalHash), and a signature over that hash (prefixed with the
+// ManifestSignatureDomain) with the public key of the signer. Since the canonical hash is signed, the signature
+// remains valid for the Manifest when it is re-encoded or its elements are reordered.
+//
+// A SignedManifest is generated with SignManifest and verified with VerifySignedManifest. It can be
+// encoded to POLO, JSON and YAML. In the JSON and YAML forms, the hash, public key and signature are
+// 0x-prefixed hex strings. The Manifest is decoded with the runtimes in the default Registry,
+// use Registry.NewSignedManifest to decode it with the runtimes in another Registry.
+type SignedManifest struct {
+	Manifest  *Manifest
+	Hash      Hash
+	PublicKey []byte
+	Signature []byte
+}
+
+// SignManifest signs the canonical hash of the Manifest with the ManifestSigner and returns it in a SignedManifest.
+// The signed data is the ManifestSignatureDomain followed by the bytes of the hash.
+func SignManifest(manifest *Manifest, signer ManifestSigner) (*SignedManifest, error) {
+	hash, err := manifest.CanonicalHash()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to hash manifest")
+	}
+
+	signature, err := signer.Sign(manifestSigningData(hash))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign manifest")
+	}
+
+	return &SignedManifest{
+		Manifest:  manifest,
+		Hash:      hash,
+		PublicKey: signer.PublicKey(),
+		Signature: signature,
+	}, nil
+}
+
+// NewSignedManifest decodes the given raw data of the specified encoding type into a SignedManifest.
+// The Manifest in the envelope is decoded with the runtimes in the default Registry.
+// The signature of the SignedManifest is not verified, use VerifySignedManifest to verify it.
+func NewSignedManifest(data []byte, encoding Encoding) (*SignedManifest, error) {
+	return defaultRegistry.NewSignedManifest(data, encoding)
+}
+
+// VerifySignedManifest verifies the SignedManifest with the CryptoDriver of the runtime
+// for its Manifest's engine in the default Registry. See Registry.VerifySignedManifest for details.
+func VerifySignedManifest(signed *SignedManifest) error {
+	return defaultRegistry.VerifySignedManifest(signed)
+}
+
+// NewSignedManifest decodes the given raw data of the specified encoding type into a SignedManifest.
+// The Manifest in the envelope is decoded with the runtimes in the Registry.
+// The signature of the SignedManifest is not verified, use VerifySignedManifest to verify it.
+func (registry *Registry) NewSignedManifest(data []byte, encoding Encoding) (*SignedManifest, error) {
+	signed := new(SignedManifest)
+	if err := signed.decode(data, encoding, registry); err != nil {
+		return nil, err
+	}
+
+	return signed, nil
+}
+
+// VerifySignedManifest verifies that the hash in the SignedManifest is the canonical hash of its Manifest
+// and that the signature over the hash (prefixed with the ManifestSignatureDomain) is valid for its public key.
+// The signature is verified with the CryptoDriver of the runtime in the Registry that the engine of the Manifest
+// resolves to (the latest runtime that satisfies its version constraint, as with FetchCryptoDriver).
+//
+// Returns ErrInvalidManifestSignature if the signature is well-formed but does not verify.
+func (registry *Registry) VerifySignedManifest(signed *SignedManifest) error {
+	if signed.Manifest == nil {
+		return errors.New("signed manifest has no manifest")
+	}
+
+	hash, err := signed.Manifest.CanonicalHash()
+	if err != nil {
+		return errors.Wrap(err, "failed to hash manifest")
+	}
+
+	if hash != signed.Hash {
+		return errors.New("manifest hash mismatch")
+	}
+
+	kind, err := ParseEngineKind(signed.Manifest.Engine.Kind)
+	if err != nil {
+		return errors.Wrap(err, "unsupported manifest engine")
+	}
+
+	crypto, err := registry.ResolveCryptoDriver(kind, signed.Manifest.Engine.Version)
+	if err != nil {
+		return errors.Wrap(err, "unsupported manifest engine")
+	}
+
+	if crypto == nil {
+		return errors.Errorf("no crypto driver registered for engine '%v'", kind)
+	}
+
+	if !crypto.ValidateSignature(signed.Signature) {
+		return errors.New("malformed manifest signature")
+	}
+
+	verified, err := crypto.VerifySignature(manifestSigningData(signed.Hash), signed.Signature, signed.PublicKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to verify manifest signature")
+	}
+
+	if !verified {
+		return ErrInvalidManifestSignature
+	}
+
+	return nil
+}
+
+// Encode returns the encoded bytes form of the SignedManifest for the specified encoding
+func (signed SignedManifest) Encode(encoding Encoding) ([]byte, error) {
+	switch encoding {
+	case JSON:
+		return json.Marshal(signed)
+	case POLO:
+		return polo.Polorize(signed)
+	case YAML:
+		return yaml.Marshal(signed)
+
+	default:
+		return nil, errors.New("unsupported manifest encoding")
+	}
+}
+
+// signedManifestPOLO is the POLO form of a SignedManifest
+type signedManifestPOLO struct {
+	Manifest  polo.Any
+	Hash      []byte
+	PublicKey []byte
+	Signature []byte
+}
+
+// signedManifestJSON is the JSON form of a SignedManifest
+type signedManifestJSON struct {
+	Manifest  json.RawMessage `json:"manifest"`
+	Hash      string          `json:"hash"`
+	PublicKey string          `json:"public_key"`
+	Signature string          `json:"signature"`
+}
+
+// signedManifestYAML is the YAML form of a SignedManifest
+type signedManifestYAML struct {
+	Manifest  yaml.Node `yaml:"manifest"`
+	Hash      string    `yaml:"hash"`
+	PublicKey string    `yaml:"public_key"`
+	Signature string    `yaml:"signature"`
+}
+
+// Polorize implements the polo.Polorizable interface for SignedManifest
+func (signed SignedManifest) Polorize() (*polo.Polorizer, error) {
+	manifest, err := signed.encodeManifest(POLO)
+	if err != nil {
+		return nil, err
+	}
+
+	polorizer := polo.NewPolorizer()
+	if err = polorizer.PolorizeAny(manifest); err != nil {
+		return nil, err
+	}
+
+	polorizer.PolorizeBytes(signed.Hash[:])
+	polorizer.PolorizeBytes(signed.PublicKey)
+	polorizer.PolorizeBytes(signed.Signature)
+
+	return polorizer, nil
+}
+
+// Depolorize implements the polo.Depolorizable interface for SignedManifest.
+// The Manifest is decoded with the runtimes in the default Registry.
+func (signed *SignedManifest) Depolorize(depolorizer *polo.Depolorizer) error {
+	data, err := depolorizer.DepolorizeAny()
+	if err != nil {
+		return err
+	}
+
+	return signed.decode(data, POLO, defaultRegistry)
+}
+
+// MarshalJSON implements the json.Marshaler interface for SignedManifest
+func (signed SignedManifest) MarshalJSON() ([]byte, error) {
+	manifest, err := signed.encodeManifest(JSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(signedManifestJSON{
+		Manifest:  manifest,
+		Hash:      signed.Hash.String(),
+		PublicKey: encodeHex(signed.PublicKey),
+		Signature: encodeHex(signed.Signature),
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for SignedManifest.
+// The Manifest is decoded with the runtimes in the default Registry.
+func (signed *SignedManifest) UnmarshalJSON(data []byte) error {
+	return signed.decode(data, JSON, defaultRegistry)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface for SignedManifest
+func (signed SignedManifest) MarshalYAML() (interface{}, error) {
+	if signed.Manifest == nil {
+		return nil, errors.New("signed manifest has no manifest")
+	}
+
+	manifest := yaml.Node{}
+	if err := manifest.Encode(signed.Manifest); err != nil {
+		return nil, err
+	}
+
+	return signedManifestYAML{
+		Manifest:  manifest,
+		Hash:      signed.Hash.String(),
+		PublicKey: encodeHex(signed.PublicKey),
+		Signature: encodeHex(signed.Signature),
+	}, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for SignedManifest.
+// The Manifest is decoded with the runtimes in the default Registry.
+func (signed *SignedManifest) UnmarshalYAML(node *yaml.Node) error {
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	return signed.decode(data, YAML, defaultRegistry)
+}
+
+// encodeManifest returns the encoded form of the Manifest in the SignedManifest.
+// Returns an error if the SignedManifest has no Manifest.
+func (signed SignedManifest) encodeManifest(encoding Encoding) ([]byte, error) {
+	if signed.Manifest == nil {
+		return nil, errors.New("signed manifest has no manifest")
+	}
+
+	return signed.Manifest.Encode(encoding)
+}
+
+// decode decodes signed manifest data into the SignedManifest, decoding its Manifest with the Registry
+func (signed *SignedManifest) decode(data []byte, encoding Encoding, registry *Registry) (err error) {
+	var (
+		manifest                   []byte
+		hash, publicKey, signature []byte
+	)
+
+	switch encoding {
+	case POLO:
+		decoded := new(signedManifestPOLO)
+		if err = polo.Depolorize(decoded, data); err != nil {
+			return err
+		}
+
+		manifest, hash, publicKey, signature = decoded.Manifest, decoded.Hash, decoded.PublicKey, decoded.Signature
+
+	case JSON:
+		decoded := new(signedManifestJSON)
+		if err = json.Unmarshal(data, decoded); err != nil {
+			return err
+		}
+
+		manifest = decoded.Manifest
+
+		if hash, publicKey, signature, err = decodeSignedHex(decoded.Hash, decoded.PublicKey, decoded.Signature); err != nil {
+			return err
+		}
+
+	case YAML:
+		decoded := new(signedManifestYAML)
+		if err = yaml.Unmarshal(data, decoded); err != nil {
+			return err
+		}
+
+		if manifest, err = yaml.Marshal(&decoded.Manifest); err != nil {
+			return err
+		}
+
+		if hash, publicKey, signature, err = decodeSignedHex(decoded.Hash, decoded.PublicKey, decoded.Signature); err != nil {
+			return err
+		}
+
+	default:
+		return errors.New("unsupported manifest encoding")
+	}
+
+	if len(hash) != len(signed.Hash) {
+		return errors.Errorf("invalid manifest hash: expected %v bytes, got %v", len(signed.Hash), len(hash))
+	}
+
+	decoded, err := registry.NewManifest(manifest, encoding)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode signed manifest")
+	}
+
+	*signed = SignedManifest{Manifest: decoded, PublicKey: publicKey, Signature: signature}
+	copy(signed.Hash[:], hash)
+
+	return nil
+}
+
+// decodeSignedHex decodes the hex encoded hash, public key and signature of a SignedManifest
+func decodeSignedHex(hash, publicKey, signature string) (decodedHash, decodedKey, decodedSig []byte, err error) {
+	if decodedHash, err = decodeHex(hash); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "invalid manifest hash")
+	}
+
+	if decodedKey, err = decodeHex(publicKey); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "invalid public key")
+	}
+
+	if decodedSig, err = decodeHex(signature); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "invalid signature")
+	}
+
+	return decodedHash, decodedKey, decodedSig, nil
+}
@@ -0,0 +1,208 @@
+package engineio
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSigner is a ManifestSigner that generates Ed25519 signatures
+type mockSigner struct {
+	key ed25519.PrivateKey
+	err error
+}
+
+func newMockSigner(seed byte) *mockSigner {
+	return &mockSigner{key: ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))}
+}
+
+func (signer *mockSigner) PublicKey() []byte {
+	return signer.key.Public().(ed25519.PublicKey) //nolint:forcetypeassert
+}
+
+func (signer *mockSigner) Sign(data []byte) ([]byte, error) {
+	if signer.err != nil {
+		return nil, signer.err
+	}
+
+	return ed25519.Sign(signer.key, data), nil
+}
+
+// mockCrypto is a CryptoDriver that verifies Ed25519 signatures
+type mockCrypto struct{}
+
+func (mockCrypto) ValidateSignature(sig []byte) bool {
+	return len(sig) == ed25519.SignatureSize
+}
+
+func (mockCrypto) VerifySignature(data, sig, pub []byte) (bool, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return false, errors.New("invalid public key")
+	}
+
+	return ed25519.Verify(pub, data, sig), nil
+}
+
+func TestSignedManifest(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), mockCrypto{})
+
+	manifest := newMockManifest("MOCK")
+	signer := newMockSigner(1)
+
+	signed, err := SignManifest(&manifest, signer)
+	require.NoError(t, err)
+	require.Equal(t, signer.PublicKey(), signed.PublicKey)
+
+	hash, err := manifest.CanonicalHash()
+	require.NoError(t, err)
+	require.Equal(t, hash, signed.Hash)
+
+	// The signature is over the domain tagged hash, not the bare hash
+	require.True(t, ed25519.Verify(signer.PublicKey(), append([]byte("moi-manifest-v1:"), hash[:]...), signed.Signature))
+	require.False(t, ed25519.Verify(signer.PublicKey(), hash[:], signed.Signature))
+
+	require.NoError(t, registry.VerifySignedManifest(signed))
+
+	// The signature remains valid when the elements of the manifest are reordered
+	reordered := *signed
+	reordered.Manifest = &Manifest{
+		Syntax:   manifest.Syntax,
+		Engine:   manifest.Engine,
+		Elements: []ManifestElement{manifest.Elements[1], manifest.Elements[0]},
+	}
+	require.NoError(t, registry.VerifySignedManifest(&reordered))
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		encoded, err := signed.Encode(encoding)
+		require.NoError(t, err)
+
+		decoded, err := registry.NewSignedManifest(encoded, encoding)
+		require.NoError(t, err, "encoding: %v", encoding)
+		require.Equal(t, signed, decoded)
+		require.NoError(t, registry.VerifySignedManifest(decoded))
+
+		// the runtime is not registered with the default registry
+		_, err = NewSignedManifest(encoded, encoding)
+		require.ErrorContains(t, err, "unknown engine 'MOCK'")
+	}
+
+	_, err = registry.NewSignedManifest(nil, Encoding(10))
+	require.EqualError(t, err, "unsupported manifest encoding")
+
+	_, err = SignManifest(&manifest, &mockSigner{key: signer.key, err: errors.New("locked")})
+	require.EqualError(t, err, "failed to sign manifest: locked")
+}
+
+func TestSignedManifest_JSON(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), mockCrypto{})
+
+	manifest := newMockManifest("MOCK")
+
+	signed, err := SignManifest(&manifest, newMockSigner(1))
+	require.NoError(t, err)
+
+	encoded, err := signed.Encode(JSON)
+	require.NoError(t, err)
+	require.Regexp(t,
+		`"hash":"0x[0-9a-f]{64}","public_key":"0x[0-9a-f]{64}","signature":"0x[0-9a-f]{128}"`,
+		string(encoded),
+	)
+
+	_, err = registry.NewSignedManifest([]byte(`{"manifest": {}, "hash": "0xzz"}`), JSON)
+	require.ErrorContains(t, err, "invalid manifest hash")
+
+	_, err = registry.NewSignedManifest([]byte(`{"manifest": {}, "hash": "0x0102"}`), JSON)
+	require.EqualError(t, err, "invalid manifest hash: expected 32 bytes, got 2")
+
+	_, err = SignedManifest{}.Encode(JSON)
+	require.ErrorContains(t, err, "signed manifest has no manifest")
+}
+
+func TestRegistry_VerifySignedManifest(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), mockCrypto{})
+	registry.Register(newMockRuntime("NOCRYPTO", "0.1.0"), nil)
+
+	sign := func(manifest Manifest) *SignedManifest {
+		signed, err := SignManifest(&manifest, newMockSigner(1))
+		require.NoError(t, err)
+
+		return signed
+	}
+
+	tests := []struct {
+		name   string
+		signed func() *SignedManifest
+		err    string
+	}{
+		{
+			"forged public key",
+			func() *SignedManifest {
+				signed := sign(newMockManifest("MOCK"))
+				signed.PublicKey = newMockSigner(2).PublicKey()
+
+				return signed
+			},
+			"invalid manifest signature",
+		},
+		{
+			"modified manifest",
+			func() *SignedManifest {
+				signed := sign(newMockManifest("MOCK"))
+				signed.Manifest.Engine.Flags = []string{"tampered"}
+
+				return signed
+			},
+			"manifest hash mismatch",
+		},
+		{
+			"malformed signature",
+			func() *SignedManifest {
+				signed := sign(newMockManifest("MOCK"))
+				signed.Signature = signed.Signature[1:]
+
+				return signed
+			},
+			"malformed manifest signature",
+		},
+		{
+			"malformed public key",
+			func() *SignedManifest {
+				signed := sign(newMockManifest("MOCK"))
+				signed.PublicKey = []byte{1}
+
+				return signed
+			},
+			"failed to verify manifest signature: invalid public key",
+		},
+		{
+			"unregistered engine",
+			func() *SignedManifest { return sign(newMockManifest("MISSING")) },
+			"unsupported manifest engine: unknown engine 'MISSING'",
+		},
+		{
+			"no crypto driver",
+			func() *SignedManifest { return sign(newMockManifest("NOCRYPTO")) },
+			"no crypto driver registered for engine 'NOCRYPTO'",
+		},
+		{
+			"no manifest",
+			func() *SignedManifest { return &SignedManifest{} },
+			"signed manifest has no manifest",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.EqualError(t, registry.VerifySignedManifest(test.signed()), test.err)
+		})
+	}
+
+	err := registry.VerifySignedManifest(tests[0].signed())
+	require.ErrorIs(t, err, ErrInvalidManifestSignature)
+}
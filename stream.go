@@ -0,0 +1,544 @@
+package engineio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+)
+
+// ManifestDecoder decodes the elements of a Manifest one at a time from an io.Reader, so that large manifests
+// can be validated without holding all of their elements in memory. Only the encoded data of the element being
+// decoded is buffered. Manifests that are oversized or malformed can be rejected as soon as an offending element
+// is found, by stopping the iteration with Next or by returning an error from the callback of Decode.
+// Wrap the reader with an io.LimitReader to also bound the total number of bytes that are read.
+//
+// Streaming is supported for POLO and JSON encoded manifests of the current syntax version.
+// The header (syntax and engine) of JSON manifests must precede the elements, as it does with Manifest.Encode.
+// A ManifestDecoder is not safe for concurrent use.
+type ManifestDecoder struct {
+	header   ManifestHeader
+	runtime  EngineRuntime
+	encoding Encoding
+	stream   elementStream
+	err      error
+}
+
+// elementStream yields the raw elements of a manifest from an encoded stream, after its header has been read.
+// It returns io.EOF once all the elements have been read.
+type elementStream interface {
+	next() (*RawElement, error)
+}
+
+// NewManifestDecoder returns a ManifestDecoder for the manifest in the reader with the specified encoding.
+// The header of the manifest is read and validated immediately, and its elements are decoded with the
+// runtime in the default Registry that its engine resolves to. See Registry.NewManifestDecoder for details.
+func NewManifestDecoder(reader io.Reader, encoding Encoding) (*ManifestDecoder, error) {
+	return defaultRegistry.NewManifestDecoder(reader, encoding)
+}
+
+// NewManifestDecoder returns a ManifestDecoder for the manifest in the reader with the specified encoding.
+// The header of the manifest is read and validated immediately, and its elements are decoded with the
+// runtime in the Registry that its engine resolves to. Returns an error if the encoding does not support
+// streaming, if the header is malformed or if the syntax version of the manifest is not the current version.
+func (registry *Registry) NewManifestDecoder(reader io.Reader, encoding Encoding) (*ManifestDecoder, error) {
+	var (
+		header ManifestHeader
+		stream elementStream
+		err    error
+	)
+
+	switch encoding {
+	case JSON:
+		header, stream, err = newJSONStream(reader)
+	case POLO:
+		header, stream, err = newPOLOStream(reader)
+	case YAML:
+		return nil, errors.New("streaming is not supported for YAML manifests")
+
+	default:
+		return nil, errors.New("unsupported manifest encoding")
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode manifest header")
+	}
+
+	if header.Syntax != CurrentSyntax() {
+		return nil, errors.Errorf(
+			"streaming requires manifest syntax '%v', got '%v'", CurrentSyntax(), header.Syntax,
+		)
+	}
+
+	runtime, err := header.validate(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ManifestDecoder{header: header, runtime: runtime, encoding: encoding, stream: stream}, nil
+}
+
+// Header returns the ManifestHeader of the manifest being decoded
+func (decoder *ManifestDecoder) Header() ManifestHeader {
+	return decoder.header
+}
+
+// Next decodes and returns the next element of the manifest. Returns io.EOF once all the elements have been
+// decoded. Once Next has returned an error, every subsequent call returns the same error.
+func (decoder *ManifestDecoder) Next() (ManifestElement, error) {
+	if decoder.err != nil {
+		return ManifestElement{}, decoder.err
+	}
+
+	element, err := decoder.next()
+	if err != nil {
+		decoder.err = err
+
+		return ManifestElement{}, err
+	}
+
+	return element, nil
+}
+
+// Decode decodes every remaining element of the manifest and calls the callback with each of them in order.
+// If the callback returns an error, decoding is aborted immediately and the error is returned as is.
+func (decoder *ManifestDecoder) Decode(callback func(ManifestElement) error) error {
+	for {
+		element, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = callback(element); err != nil {
+			decoder.err = err
+
+			return err
+		}
+	}
+}
+
+// next reads the next raw element from the stream and decodes its data with the runtime
+func (decoder *ManifestDecoder) next() (ManifestElement, error) {
+	raw, err := decoder.stream.next()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return ManifestElement{}, io.EOF
+		}
+
+		return ManifestElement{}, errors.Wrap(err, "failed to decode manifest element")
+	}
+
+	generator, ok := decoder.runtime.GetElementGenerator(raw.Kind)
+	if !ok {
+		return ManifestElement{}, errors.Errorf("unrecognized element kind: '%v'", raw.Kind)
+	}
+
+	object := generator()
+	if err = decodeElementData(raw.Data, decoder.encoding, object); err != nil {
+		return ManifestElement{}, errors.Wrapf(err, "failed to decode data of element %v", raw.Ptr)
+	}
+
+	return ManifestElement{Ptr: raw.Ptr, Deps: raw.Deps, Kind: raw.Kind, Data: object}, nil
+}
+
+// jsonStream is an elementStream for JSON encoded manifests
+type jsonStream struct {
+	decoder *json.Decoder
+	done    bool
+}
+
+// newJSONStream reads the header of a JSON encoded manifest until the start of its elements
+func newJSONStream(reader io.Reader) (ManifestHeader, *jsonStream, error) {
+	stream := &jsonStream{decoder: json.NewDecoder(reader)}
+	header := ManifestHeader{}
+
+	if err := stream.expect(json.Delim('{')); err != nil {
+		return header, nil, err
+	}
+
+	var syntax, engine bool
+
+	for stream.decoder.More() {
+		key, err := stream.key()
+		if err != nil {
+			return header, nil, err
+		}
+
+		switch key {
+		case "syntax":
+			syntax = true
+			err = stream.decoder.Decode(&header.Syntax)
+
+		case "engine":
+			engine = true
+			err = stream.decoder.Decode(&header.Engine)
+
+		case "elements":
+			if !syntax || !engine {
+				return header, nil, errors.New("manifest header must precede the elements")
+			}
+
+			token, err := stream.decoder.Token()
+			if err != nil {
+				return header, nil, err
+			}
+
+			switch token {
+			case json.Delim('['):
+				return header, stream, nil
+			case nil:
+				continue
+			default:
+				return header, nil, errors.Errorf("unexpected token '%v' for manifest elements", token)
+			}
+
+		default:
+			err = stream.skip()
+		}
+
+		if err != nil {
+			return header, nil, err
+		}
+	}
+
+	// The manifest has no elements
+	if err := stream.expect(json.Delim('}')); err != nil {
+		return header, nil, err
+	}
+
+	stream.done = true
+
+	return header, stream, nil
+}
+
+func (stream *jsonStream) next() (*RawElement, error) {
+	if stream.done {
+		return nil, io.EOF
+	}
+
+	if !stream.decoder.More() {
+		if err := stream.finish(); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	element := new(struct {
+		Ptr  ElementPtr      `json:"ptr"`
+		Deps []ElementPtr    `json:"deps"`
+		Kind ElementKind     `json:"kind"`
+		Data json.RawMessage `json:"data"`
+	})
+
+	if err := stream.decoder.Decode(element); err != nil {
+		return nil, err
+	}
+
+	return &RawElement{element.Ptr, element.Deps, element.Kind, element.Data}, nil
+}
+
+// finish consumes the end of the elements array and any fields of the manifest that follow it
+func (stream *jsonStream) finish() error {
+	if err := stream.expect(json.Delim(']')); err != nil {
+		return err
+	}
+
+	for stream.decoder.More() {
+		if _, err := stream.key(); err != nil {
+			return err
+		}
+
+		if err := stream.skip(); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.expect(json.Delim('}')); err != nil {
+		return err
+	}
+
+	stream.done = true
+
+	return nil
+}
+
+// expect consumes the next token and verifies that it is the given delimiter
+func (stream *jsonStream) expect(delim json.Delim) error {
+	token, err := stream.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return errors.Errorf("expected '%v', got '%v'", delim, token)
+	}
+
+	return nil
+}
+
+// key consumes the next token as the key of an object field
+func (stream *jsonStream) key() (string, error) {
+	token, err := stream.decoder.Token()
+	if err != nil {
+		return "", err
+	}
+
+	key, ok := token.(string)
+	if !ok {
+		return "", errors.Errorf("expected object key, got '%v'", token)
+	}
+
+	return key, nil
+}
+
+// skip consumes the next value without decoding it
+func (stream *jsonStream) skip() error {
+	return stream.decoder.Decode(new(json.RawMessage))
+}
+
+// poloStream is an elementStream for POLO encoded manifests.
+//
+// A POLO pack is encoded as its wire type, followed by a load tag with the length of its head, the head
+// (a varint for each element with its offset in the body and its wire type) and the body (the concatenated
+// element data). The stream reads the heads of the manifest pack and its elements pack, and then reads the
+// data of each element from the body of the elements pack using their offsets.
+type poloStream struct {
+	reader  io.Reader
+	entries []packEntry
+	index   int
+}
+
+// packEntry is the wire type and the length of the data of an element in a POLO pack.
+// The length is -1 for the last element in the pack, whose data extends to the end of the pack.
+type packEntry struct {
+	wire   polo.WireType
+	length int64
+}
+
+// newPOLOStream reads the header of a POLO encoded manifest until the start of its elements
+func newPOLOStream(reader io.Reader) (ManifestHeader, *poloStream, error) {
+	buffered := bufio.NewReader(reader)
+	header := ManifestHeader{}
+
+	fields, err := readPackHead(buffered)
+	if err != nil {
+		return header, nil, err
+	}
+
+	if len(fields) < 2 {
+		return header, nil, errors.New("manifest pack is missing its header fields")
+	}
+
+	// Decode the syntax and engine fields of the manifest
+	syntax, err := readPackElement(buffered, fields[0])
+	if err != nil {
+		return header, nil, err
+	}
+
+	if err = polo.Depolorize(&header.Syntax, syntax); err != nil {
+		return header, nil, err
+	}
+
+	engine, err := readPackElement(buffered, fields[1])
+	if err != nil {
+		return header, nil, err
+	}
+
+	if err = checkPack(engine); err != nil {
+		return header, nil, errors.Wrap(err, "malformed engine pack")
+	}
+
+	if err = polo.Depolorize(&header.Engine, engine); err != nil {
+		return header, nil, err
+	}
+
+	// The manifest has no elements
+	if len(fields) < 3 || fields[2].wire == polo.WireNull {
+		return header, &poloStream{}, nil
+	}
+
+	if fields[2].wire != polo.WirePack {
+		return header, nil, errors.Errorf("expected pack wire for manifest elements, got %v", fields[2].wire)
+	}
+
+	// Bound the elements pack to its length, if any fields follow it
+	elements := buffered
+	if fields[2].length >= 0 {
+		elements = bufio.NewReader(io.LimitReader(buffered, fields[2].length))
+	}
+
+	entries, err := readPackLoad(elements)
+	if err != nil {
+		return header, nil, err
+	}
+
+	return header, &poloStream{reader: elements, entries: entries}, nil
+}
+
+func (stream *poloStream) next() (*RawElement, error) {
+	if stream.index >= len(stream.entries) {
+		return nil, io.EOF
+	}
+
+	data, err := readPackElement(stream.reader, stream.entries[stream.index])
+	if err != nil {
+		return nil, err
+	}
+
+	stream.index++
+
+	if err = checkPack(data); err != nil {
+		return nil, errors.Wrap(err, "malformed element pack")
+	}
+
+	decoded := new(struct {
+		Ptr  ElementPtr
+		Deps []ElementPtr
+		Kind ElementKind
+		Data polo.Any
+	})
+
+	if err = polo.Depolorize(decoded, data); err != nil {
+		return nil, err
+	}
+
+	return &RawElement{decoded.Ptr, decoded.Deps, decoded.Kind, decoded.Data}, nil
+}
+
+// readPackHead reads the wire type of a POLO pack and the entries in its head
+func readPackHead(reader *bufio.Reader) ([]packEntry, error) {
+	wire, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if polo.WireType(wire&15) != polo.WirePack {
+		return nil, errors.Errorf("expected pack wire for manifest, got %v", polo.WireType(wire&15))
+	}
+
+	return readPackLoad(reader)
+}
+
+// readPackLoad reads the load tag and the entries in the head of a POLO pack whose wire type has been read
+func readPackLoad(reader *bufio.Reader) ([]packEntry, error) {
+	load, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if polo.WireType(load&15) != polo.WireLoad {
+		return nil, errors.New("missing load tag for pack")
+	}
+
+	head, err := readBytes(reader, int64(load>>4))
+	if err != nil {
+		return nil, errors.Wrap(err, "missing pack head")
+	}
+
+	entries := make([]packEntry, 0)
+	previous := uint64(0)
+
+	for position := 0; position < len(head); {
+		tag, consumed := binary.Uvarint(head[position:])
+		if consumed <= 0 {
+			return nil, errors.New("malformed pack head")
+		}
+
+		position += consumed
+
+		offset := tag >> 4
+		if len(entries) == 0 && offset != 0 {
+			return nil, errors.New("malformed pack head: non-zero first offset")
+		}
+
+		if offset < previous {
+			return nil, errors.New("malformed pack head: decreasing offsets")
+		}
+
+		if len(entries) > 0 {
+			entries[len(entries)-1].length = int64(offset - previous)
+		}
+
+		entries = append(entries, packEntry{wire: polo.WireType(tag & 15), length: -1})
+		previous = offset
+	}
+
+	return entries, nil
+}
+
+// readPackElement reads the data of a pack element and returns it as a standalone POLO encoding
+func readPackElement(reader io.Reader, entry packEntry) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if entry.length < 0 {
+		data, err = io.ReadAll(reader)
+	} else {
+		data, err = readBytes(reader, entry.length)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{byte(entry.wire)}, data...), nil
+}
+
+// checkPack verifies that the offsets in the heads of a POLO encoded pack (or document) and the packs nested
+// within it are within the bounds of their data. The depolorizer does not bound these offsets, so that packs
+// from truncated or malformed manifests must be checked before they are depolorized.
+// Values of other wire types are not checked.
+func checkPack(data []byte) error {
+	wire, consumed := binary.Uvarint(data)
+	if consumed <= 0 {
+		return errors.New("missing wire type")
+	}
+
+	if kind := polo.WireType(wire & 15); kind != polo.WirePack && kind != polo.WireDoc {
+		return nil
+	}
+
+	reader := bufio.NewReader(bytes.NewReader(data[consumed:]))
+
+	entries, err := readPackLoad(reader)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		element, err := readPackElement(reader, entry)
+		if err != nil {
+			return err
+		}
+
+		if err = checkPack(element); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readBytes reads exactly length bytes from the reader. The bytes are read incrementally,
+// so that a malformed length does not allocate more memory than the data that is available.
+func readBytes(reader io.Reader, length int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, length))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) != length {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return data, nil
+}
@@ -0,0 +1,297 @@
+package engineio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// countingReader is an io.Reader that counts the number of bytes read from it
+type countingReader struct {
+	reader io.Reader
+	count  int
+}
+
+func (reader *countingReader) Read(buffer []byte) (int, error) {
+	n, err := reader.reader.Read(buffer)
+	reader.count += n
+
+	return n, err
+}
+
+// newLargeMockManifest returns a Manifest with the given number of "mock" elements
+func newLargeMockManifest(count int) Manifest {
+	manifest := newMockManifest("MOCK")
+	manifest.Elements = make([]ManifestElement, 0, count)
+
+	for idx := 0; idx < count; idx++ {
+		deps := []ElementPtr{}
+		if idx > 0 {
+			deps = append(deps, ElementPtr(idx-1))
+		}
+
+		manifest.Elements = append(manifest.Elements, ManifestElement{
+			Ptr: ElementPtr(idx), Deps: deps, Kind: "mock",
+			Data: &mockElement{Name: fmt.Sprintf("element-%v-%v", idx, strings.Repeat("x", 64)), Value: uint64(idx)},
+		})
+	}
+
+	return manifest
+}
+
+func TestManifestDecoder(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	for _, manifest := range []Manifest{newMockManifest("MOCK"), newLargeMockManifest(500)} {
+		for _, encoding := range []Encoding{POLO, JSON} {
+			encoded, err := manifest.Encode(encoding)
+			require.NoError(t, err)
+
+			decoder, err := registry.NewManifestDecoder(bytes.NewReader(encoded), encoding)
+			require.NoError(t, err)
+			require.Equal(t, manifest.Header(), decoder.Header())
+
+			elements := make([]ManifestElement, 0)
+			require.NoError(t, decoder.Decode(func(element ManifestElement) error {
+				elements = append(elements, element)
+
+				return nil
+			}))
+
+			require.Equal(t, manifest.Elements, elements, "encoding: %v", encoding)
+
+			// the decoder remains exhausted
+			_, err = decoder.Next()
+			require.ErrorIs(t, err, io.EOF)
+		}
+	}
+}
+
+func TestManifestDecoder_NoElements(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+
+	for _, elements := range [][]ManifestElement{nil, {}} {
+		manifest.Elements = elements
+
+		for _, encoding := range []Encoding{POLO, JSON} {
+			encoded, err := manifest.Encode(encoding)
+			require.NoError(t, err)
+
+			decoder, err := registry.NewManifestDecoder(bytes.NewReader(encoded), encoding)
+			require.NoError(t, err)
+
+			_, err = decoder.Next()
+			require.ErrorIs(t, err, io.EOF)
+		}
+	}
+}
+
+func TestManifestDecoder_JSON(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	// unknown fields are skipped
+	decoder, err := registry.NewManifestDecoder(strings.NewReader(`{
+		"comment": {"nested": [1, 2]},
+		"syntax": "0.1.0",
+		"engine": {"kind": "mock", "flags": []},
+		"elements": [{"ptr": 0, "deps": [], "kind": "mock", "data": {"name": "foo", "value": 5}}],
+		"trailer": true
+	}`), JSON)
+	require.NoError(t, err)
+
+	element, err := decoder.Next()
+	require.NoError(t, err)
+	require.Equal(t, &mockElement{Name: "foo", Value: 5}, element.Data)
+
+	_, err = decoder.Next()
+	require.ErrorIs(t, err, io.EOF)
+
+	_, err = registry.NewManifestDecoder(strings.NewReader(`{
+		"elements": [],
+		"syntax": "0.1.0",
+		"engine": {"kind": "mock", "flags": []}
+	}`), JSON)
+	require.EqualError(t, err, "failed to decode manifest header: manifest header must precede the elements")
+
+	// malformed elements are rejected when they are reached
+	decoder, err = registry.NewManifestDecoder(strings.NewReader(`{
+		"syntax": "0.1.0",
+		"engine": {"kind": "mock", "flags": []},
+		"elements": [
+			{"ptr": 0, "deps": [], "kind": "mock", "data": {"name": "foo", "value": 5}},
+			{"ptr": 1, "deps": [], "kind": "unknown", "data": {}},
+	`), JSON)
+	require.NoError(t, err)
+
+	_, err = decoder.Next()
+	require.NoError(t, err)
+
+	_, err = decoder.Next()
+	require.EqualError(t, err, "unrecognized element kind: 'unknown'")
+
+	// the error is sticky
+	_, err = decoder.Next()
+	require.EqualError(t, err, "unrecognized element kind: 'unknown'")
+}
+
+func TestManifestDecoder_EarlyAbort(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newLargeMockManifest(2000)
+	errTooLarge := errors.New("too many elements")
+
+	for _, encoding := range []Encoding{POLO, JSON} {
+		encoded, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		reader := &countingReader{reader: bytes.NewReader(encoded)}
+
+		decoder, err := registry.NewManifestDecoder(reader, encoding)
+		require.NoError(t, err)
+
+		count := 0
+		err = decoder.Decode(func(ManifestElement) error {
+			if count++; count > 10 {
+				return errTooLarge
+			}
+
+			return nil
+		})
+
+		require.ErrorIs(t, err, errTooLarge)
+		require.Equal(t, 11, count)
+
+		// most of the manifest was never read
+		require.Less(t, reader.count, len(encoded)/4, "encoding: %v", encoding)
+
+		_, err = decoder.Next()
+		require.ErrorIs(t, err, errTooLarge)
+	}
+}
+
+func TestManifestDecoder_Errors(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+
+	_, err := registry.NewManifestDecoder(strings.NewReader("syntax: 0.1.0"), YAML)
+	require.EqualError(t, err, "streaming is not supported for YAML manifests")
+
+	_, err = registry.NewManifestDecoder(strings.NewReader(""), Encoding(10))
+	require.EqualError(t, err, "unsupported manifest encoding")
+
+	unknown := newMockManifest("UNKNOWN")
+	old := newMockManifest("MOCK")
+	old.Syntax = "0.0.1"
+
+	for _, encoding := range []Encoding{POLO, JSON} {
+		encoded, err := unknown.Encode(encoding)
+		require.NoError(t, err)
+
+		_, err = registry.NewManifestDecoder(bytes.NewReader(encoded), encoding)
+		require.EqualError(t, err, "unsupported manifest engine: unknown engine 'UNKNOWN'")
+
+		encoded, err = old.Encode(encoding)
+		require.NoError(t, err)
+
+		_, err = registry.NewManifestDecoder(bytes.NewReader(encoded), encoding)
+		require.EqualError(t, err, "streaming requires manifest syntax '0.1.0', got '0.0.1'")
+
+		// truncated manifests fail when the missing element is reached
+		encoded, err = manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		decoder, err := registry.NewManifestDecoder(bytes.NewReader(encoded[:len(encoded)-8]), encoding)
+		require.NoError(t, err)
+
+		_, err = decoder.Next()
+		require.NoError(t, err)
+
+		_, err = decoder.Next()
+		require.Error(t, err)
+	}
+
+	// element packs that are truncated within their head are rejected before they are depolorized
+	encoded, err := manifest.Encode(POLO)
+	require.NoError(t, err)
+
+	decoder, err := registry.NewManifestDecoder(bytes.NewReader(encoded[:45]), POLO)
+	require.NoError(t, err)
+
+	_, err = decoder.Next()
+	require.NoError(t, err)
+
+	_, err = decoder.Next()
+	require.EqualError(t, err, "failed to decode manifest element: malformed element pack: unexpected EOF")
+
+	_, err = registry.NewManifestDecoder(bytes.NewReader([]byte{0x06, 0x01}), POLO)
+	require.EqualError(t, err, "failed to decode manifest header: expected pack wire for manifest, got word")
+
+	_, err = registry.NewManifestDecoder(bytes.NewReader(nil), POLO)
+	require.EqualError(t, err, "failed to decode manifest header: EOF")
+}
+
+func TestManifestDecoder_Truncated(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	encoded, err := newLargeMockManifest(5).Encode(POLO)
+	require.NoError(t, err)
+
+	// truncated manifests do not panic the decoder (truncations within
+	// the data of the last element cannot always be detected)
+	for length := 0; length < len(encoded); length++ {
+		require.NotPanics(t, func() {
+			decoder, err := registry.NewManifestDecoder(bytes.NewReader(encoded[:length]), POLO)
+			if err != nil {
+				return
+			}
+
+			_ = decoder.Decode(func(ManifestElement) error { return nil })
+		}, "length: %v", length)
+	}
+}
+
+func TestCheckPack(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"empty", []byte{}, "missing wire type"},
+		{"word", []byte{0x06, 0x01}, ""},
+		{"pack", []byte{0x0e, 0x2f, 0x03, 0x13, 0x01, 0x02}, ""},
+		{"nested pack", []byte{0x0e, 0x1f, 0x0e, 0x1f, 0x03, 0x01}, ""},
+		{"missing load", []byte{0x0e}, "EOF"},
+		{"truncated head", []byte{0x0e, 0x2f, 0x03}, "missing pack head: unexpected EOF"},
+		{"truncated body", []byte{0x0e, 0x2f, 0x03, 0x53, 0x01}, "unexpected EOF"},
+		{"non-zero first offset", []byte{0x0e, 0x1f, 0x53, 0x01}, "malformed pack head: non-zero first offset"},
+		{"decreasing offsets", []byte{0x0e, 0x3f, 0x03, 0x23, 0x13, 0x01, 0x02}, "malformed pack head: decreasing offsets"},
+		{"truncated nested pack", []byte{0x0e, 0x1f, 0x0e, 0x2f, 0x03, 0x23, 0x01}, "unexpected EOF"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkPack(test.data)
+			if test.err == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,557 @@
+package engineio
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sarvalabs/go-polo"
+	"gopkg.in/yaml.v3"
+)
+
+// manifestSyntax describes a version of the Manifest syntax specification.
+//
+// Every syntax version declares how to decode and encode its raw form, along with an upgrade
+// function that migrates a raw manifest of its version to the next version in the registry and
+// a downgrade function that migrates a raw manifest of the next version back to its version.
+// The upgrade and downgrade functions are nil for the current (latest) syntax version,
+// or if the layout of a Manifest does not change between the version and the next version.
+type manifestSyntax struct {
+	version string
+
+	decode func([]byte, Encoding) (*RawManifest, error)
+	encode func(*RawManifest, Encoding) ([]byte, error)
+
+	upgrade   func(*RawManifest) (*RawManifest, error)
+	downgrade func(*RawManifest) (*RawManifest, error)
+}
+
+// syntaxes is the registry of Manifest syntax versions, ordered from the oldest to the current version
+var syntaxes = struct {
+	mutex    sync.RWMutex
+	versions []manifestSyntax
+}{
+	versions: []manifestSyntax{
+		{version: "0.1.0", decode: decodeRawManifest, encode: encodeRawManifest},
+	},
+}
+
+// CurrentSyntax returns the current version of the Manifest syntax specification.
+// Manifests decoded with NewManifest are always migrated to the current syntax.
+func CurrentSyntax() string {
+	syntaxes.mutex.RLock()
+	defer syntaxes.mutex.RUnlock()
+
+	return syntaxes.versions[len(syntaxes.versions)-1].version
+}
+
+// SyntaxVersion declares a version of the Manifest syntax specification for RegisterSyntax.
+//
+// Manifests of every syntax version share the same layout for their raw form (see RawManifest),
+// with the decode and encode functions of a version translating between its encoded form and a
+// RawManifest, and the migrations transforming the contents of a RawManifest between versions.
+type SyntaxVersion struct {
+	// Version is the semantic version of the syntax, which must be newer than the current syntax version
+	Version string
+
+	// Decode decodes manifest data of the syntax version in the given encoding into a RawManifest with its
+	// element data in that encoding. If nil, the data is decoded like manifests of the previous version.
+	Decode func([]byte, Encoding) (*RawManifest, error)
+	// Encode encodes a RawManifest of the syntax version whose element data is in the given encoding.
+	// If nil, the RawManifest is encoded like manifests of the previous version.
+	Encode func(*RawManifest, Encoding) ([]byte, error)
+
+	// Upgrade migrates a RawManifest of the previous version to the syntax version.
+	// It can be nil if the layout of a Manifest does not change between the versions.
+	Upgrade func(*RawManifest) (*RawManifest, error)
+	// Downgrade migrates a RawManifest of the syntax version back to the previous version.
+	// It can be nil if the layout of a Manifest does not change between the versions.
+	Downgrade func(*RawManifest) (*RawManifest, error)
+}
+
+// RegisterSyntax registers a new version of the Manifest syntax specification, which becomes the current
+// syntax version. Manifests of the previous current version are migrated to it with its Upgrade function,
+// and migrated back from it with its Downgrade function. Manifests of the version are decoded and encoded
+// with its Decode and Encode functions.
+//
+// Returns an error if the version is not a valid semantic version that is newer than the current version.
+func RegisterSyntax(syntax SyntaxVersion) error {
+	parsed, err := parseSemver(syntax.Version)
+	if err != nil {
+		return errors.Wrap(err, "invalid manifest syntax")
+	}
+
+	syntaxes.mutex.Lock()
+	defer syntaxes.mutex.Unlock()
+
+	current := syntaxes.versions[len(syntaxes.versions)-1]
+	if latest, err := parseSemver(current.version); err == nil && parsed.compare(latest) <= 0 {
+		return errors.Errorf(
+			"manifest syntax '%v' is not newer than the current syntax '%v'", syntax.Version, current.version,
+		)
+	}
+
+	declared := manifestSyntax{version: syntax.Version, decode: current.decode, encode: current.encode}
+
+	if decode := syntax.Decode; decode != nil {
+		// The encoding of the element data is recorded for decoders declared outside the package
+		declared.decode = func(data []byte, encoding Encoding) (*RawManifest, error) {
+			raw, err := decode(data, encoding)
+			if err != nil {
+				return nil, err
+			}
+
+			raw.encoding = encoding
+
+			return raw, nil
+		}
+	}
+
+	if encode := syntax.Encode; encode != nil {
+		declared.encode = func(raw *RawManifest, encoding Encoding) ([]byte, error) {
+			if encoding != raw.encoding && len(raw.Elements) > 0 {
+				return nil, errors.New("manifest element data does not match the encoding")
+			}
+
+			return encode(raw, encoding)
+		}
+	}
+
+	// The registry is copied on write, so that readers can use a snapshot without holding the lock
+	versions := make([]manifestSyntax, 0, len(syntaxes.versions)+1)
+	versions = append(versions, syntaxes.versions...)
+
+	versions[len(versions)-1].upgrade = syntax.Upgrade
+	versions[len(versions)-1].downgrade = syntax.Downgrade
+	versions = append(versions, declared)
+
+	syntaxes.versions = versions
+
+	return nil
+}
+
+// SupportedSyntaxes returns every version of the Manifest syntax specification
+// that can be decoded and encoded, ordered from the oldest to the current version.
+func SupportedSyntaxes() []string {
+	syntaxes.mutex.RLock()
+	defer syntaxes.mutex.RUnlock()
+
+	versions := make([]string, 0, len(syntaxes.versions))
+	for _, syntax := range syntaxes.versions {
+		versions = append(versions, syntax.version)
+	}
+
+	return versions
+}
+
+// lookupSyntax returns the position of a syntax version in the registry and its
+// declaration. The position is -1 and false is returned if the version is unsupported.
+func lookupSyntax(version string) (int, manifestSyntax, bool) {
+	syntaxes.mutex.RLock()
+	defer syntaxes.mutex.RUnlock()
+
+	for idx, syntax := range syntaxes.versions {
+		if syntax.version == version {
+			return idx, syntax, true
+		}
+	}
+
+	return -1, manifestSyntax{}, false
+}
+
+// decodeSyntax decodes the syntax version from some encoded manifest data
+func decodeSyntax(data []byte, encoding Encoding) (string, error) {
+	switch encoding {
+	case JSON:
+		header := new(struct {
+			Syntax string `json:"syntax"`
+		})
+
+		if err := json.Unmarshal(data, header); err != nil {
+			return "", err
+		}
+
+		return header.Syntax, nil
+
+	case YAML:
+		header := new(struct {
+			Syntax string `yaml:"syntax"`
+		})
+
+		if err := yaml.Unmarshal(data, header); err != nil {
+			return "", err
+		}
+
+		return header.Syntax, nil
+
+	case POLO:
+		depolorizer, err := polo.NewDepolorizer(data)
+		if err != nil {
+			return "", err
+		}
+
+		if depolorizer, err = depolorizer.DepolorizePacked(); err != nil {
+			return "", err
+		}
+
+		return depolorizer.DepolorizeString()
+
+	default:
+		return "", errors.New("unsupported manifest encoding")
+	}
+}
+
+// decodeManifestData decodes some encoded manifest data of any supported syntax
+// version into a RawManifest and migrates it to the current syntax version
+func decodeManifestData(data []byte, encoding Encoding) (*RawManifest, error) {
+	version, err := decodeSyntax(data, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	_, syntax, ok := lookupSyntax(version)
+	if !ok {
+		return nil, errors.Errorf("unsupported manifest syntax '%v'", version)
+	}
+
+	raw, err := syntax.decode(data, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	return raw.migrate(CurrentSyntax())
+}
+
+// migrate upgrades or downgrades the RawManifest to the given syntax version
+func (raw *RawManifest) migrate(target string) (*RawManifest, error) {
+	current, _, ok := lookupSyntax(raw.Syntax)
+	if !ok {
+		return nil, errors.Errorf("unsupported manifest syntax '%v'", raw.Syntax)
+	}
+
+	position, _, ok := lookupSyntax(target)
+	if !ok {
+		return nil, errors.Errorf("unsupported manifest syntax '%v'", target)
+	}
+
+	var err error
+
+	for current != position {
+		syntaxes.mutex.RLock()
+		versions := syntaxes.versions
+		syntaxes.mutex.RUnlock()
+
+		if current < position {
+			if upgrade := versions[current].upgrade; upgrade != nil {
+				if raw, err = upgrade(raw); err != nil {
+					return nil, errors.Wrapf(err, "failed to upgrade manifest syntax from '%v'", versions[current].version)
+				}
+			}
+
+			current++
+		} else {
+			if downgrade := versions[current-1].downgrade; downgrade != nil {
+				if raw, err = downgrade(raw); err != nil {
+					return nil, errors.Wrapf(err, "failed to downgrade manifest syntax from '%v'", versions[current].version)
+				}
+			}
+
+			current--
+		}
+
+		raw.Syntax = versions[current].version
+	}
+
+	return raw, nil
+}
+
+// resolve decodes the element data of the RawManifest with the runtime that
+// its header resolves to in the Registry and returns it as a Manifest
+func (raw *RawManifest) resolve(registry *Registry) (*Manifest, error) {
+	manifest := &Manifest{Syntax: raw.Syntax, Engine: raw.Engine}
+
+	runtime, err := manifest.Header().validate(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Elements, err = raw.decodeElements(runtime.GetElementGenerator); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// decodeElements decodes the element data of the RawManifest into the objects of their kind from the generator
+func (raw *RawManifest) decodeElements(generator func(ElementKind) (ManifestElementGenerator, bool)) (
+	[]ManifestElement, error,
+) {
+	elements := make([]ManifestElement, 0, len(raw.Elements))
+
+	for _, element := range raw.Elements {
+		generate, ok := generator(element.Kind)
+		if !ok {
+			return nil, errors.Errorf("unrecognized element kind: '%v'", element.Kind)
+		}
+
+		object := generate()
+		if err := decodeElementData(element.Data, raw.encoding, object); err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, ManifestElement{
+			Ptr:  element.Ptr,
+			Kind: element.Kind,
+			Deps: element.Deps,
+			Data: object,
+		})
+	}
+
+	return elements, nil
+}
+
+// elementGenerators returns a generator of element objects for the element kinds of the Manifest, which
+// generates new objects of the same type as the data of its elements. It allows a migrated RawManifest
+// to be decoded into the element types of the Manifest it was created from without a runtime.
+func (manifest Manifest) elementGenerators() func(ElementKind) (ManifestElementGenerator, bool) {
+	types := make(map[ElementKind]reflect.Type)
+
+	for _, element := range manifest.Elements {
+		if objectType := reflect.TypeOf(element.Data); objectType != nil && objectType.Kind() == reflect.Pointer {
+			types[element.Kind] = objectType.Elem()
+		}
+	}
+
+	return func(kind ElementKind) (ManifestElementGenerator, bool) {
+		objectType, ok := types[kind]
+		if !ok {
+			return nil, false
+		}
+
+		return func() ManifestElementObject {
+			return reflect.New(objectType).Interface().(ManifestElementObject) //nolint:forcetypeassert
+		}, true
+	}
+}
+
+// newRawManifest encodes the element data of the Manifest into a RawManifest of the given encoding
+func newRawManifest(manifest Manifest, encoding Encoding) (*RawManifest, error) {
+	raw := &RawManifest{
+		Syntax:   manifest.Syntax,
+		Engine:   manifest.Engine,
+		Elements: make([]RawElement, 0, len(manifest.Elements)),
+		encoding: encoding,
+	}
+
+	for _, element := range manifest.Elements {
+		data, err := encodeElementData(element.Data, encoding)
+		if err != nil {
+			return nil, err
+		}
+
+		raw.Elements = append(raw.Elements, RawElement{element.Ptr, element.Deps, element.Kind, data})
+	}
+
+	// The flags and dependencies are copied, so that migrations cannot modify the Manifest
+	return raw.clone(), nil
+}
+
+// decodeElementData decodes the encoded data of an element into an object
+func decodeElementData(data []byte, encoding Encoding, object ManifestElementObject) error {
+	switch encoding {
+	case JSON:
+		return json.Unmarshal(data, object)
+	case YAML:
+		return yaml.Unmarshal(data, object)
+	case POLO:
+		depolorizer, err := polo.NewDepolorizer(data)
+		if err != nil {
+			return err
+		}
+
+		return object.Depolorize(depolorizer)
+
+	default:
+		return errors.New("unsupported manifest encoding")
+	}
+}
+
+// encodeElementData encodes the data object of an element
+func encodeElementData(object ManifestElementObject, encoding Encoding) ([]byte, error) {
+	switch encoding {
+	case JSON:
+		return json.Marshal(object)
+	case YAML:
+		return yaml.Marshal(object)
+	case POLO:
+		return polo.Polorize(object)
+
+	default:
+		return nil, errors.New("unsupported manifest encoding")
+	}
+}
+
+// decodeRawManifest decodes manifest data into a RawManifest.
+// It is the decoder of the 0.1.0 syntax version.
+func decodeRawManifest(data []byte, encoding Encoding) (*RawManifest, error) {
+	raw := &RawManifest{encoding: encoding}
+
+	switch encoding {
+	case JSON:
+		decoded := new(struct {
+			Syntax   string         `json:"syntax"`
+			Engine   ManifestEngine `json:"engine"`
+			Elements []struct {
+				Ptr  ElementPtr      `json:"ptr"`
+				Deps []ElementPtr    `json:"deps"`
+				Kind ElementKind     `json:"kind"`
+				Data json.RawMessage `json:"data"`
+			} `json:"elements"`
+		})
+
+		if err := json.Unmarshal(data, decoded); err != nil {
+			return nil, err
+		}
+
+		raw.Syntax, raw.Engine = decoded.Syntax, decoded.Engine
+		for _, element := range decoded.Elements {
+			raw.Elements = append(raw.Elements, RawElement{element.Ptr, element.Deps, element.Kind, element.Data})
+		}
+
+	case YAML:
+		decoded := new(struct {
+			Syntax   string         `yaml:"syntax"`
+			Engine   ManifestEngine `yaml:"engine"`
+			Elements []struct {
+				Ptr  ElementPtr   `yaml:"ptr"`
+				Deps []ElementPtr `yaml:"deps"`
+				Kind ElementKind  `yaml:"kind"`
+				Data yaml.Node    `yaml:"data"`
+			} `yaml:"elements"`
+		})
+
+		if err := yaml.Unmarshal(data, decoded); err != nil {
+			return nil, err
+		}
+
+		raw.Syntax, raw.Engine = decoded.Syntax, decoded.Engine
+		for _, element := range decoded.Elements {
+			element := element
+
+			encoded, err := yaml.Marshal(&element.Data)
+			if err != nil {
+				return nil, err
+			}
+
+			raw.Elements = append(raw.Elements, RawElement{element.Ptr, element.Deps, element.Kind, encoded})
+		}
+
+	case POLO:
+		decoded := new(struct {
+			Syntax   string
+			Engine   ManifestEngine
+			Elements []struct {
+				Ptr  ElementPtr
+				Deps []ElementPtr
+				Kind ElementKind
+				Data polo.Any
+			}
+		})
+
+		if err := polo.Depolorize(decoded, data); err != nil {
+			return nil, err
+		}
+
+		raw.Syntax, raw.Engine = decoded.Syntax, decoded.Engine
+		for _, element := range decoded.Elements {
+			raw.Elements = append(raw.Elements, RawElement{element.Ptr, element.Deps, element.Kind, element.Data})
+		}
+
+	default:
+		return nil, errors.New("unsupported manifest encoding")
+	}
+
+	return raw, nil
+}
+
+// encodeRawManifest encodes a RawManifest into the given encoding, which must be the encoding
+// of its element data, unless it has no elements. It is the encoder of the 0.1.0 syntax version.
+func encodeRawManifest(raw *RawManifest, encoding Encoding) ([]byte, error) {
+	if encoding != raw.encoding && len(raw.Elements) > 0 {
+		return nil, errors.New("manifest element data does not match the encoding")
+	}
+
+	switch encoding {
+	case JSON:
+		type element struct {
+			Ptr  ElementPtr      `json:"ptr"`
+			Deps []ElementPtr    `json:"deps"`
+			Kind ElementKind     `json:"kind"`
+			Data json.RawMessage `json:"data"`
+		}
+
+		elements := make([]element, 0, len(raw.Elements))
+		for _, elem := range raw.Elements {
+			elements = append(elements, element{elem.Ptr, elem.Deps, elem.Kind, elem.Data})
+		}
+
+		return json.Marshal(struct {
+			Syntax   string         `json:"syntax"`
+			Engine   ManifestEngine `json:"engine"`
+			Elements []element      `json:"elements"`
+		}{raw.Syntax, raw.Engine, elements})
+
+	case YAML:
+		type element struct {
+			Ptr  ElementPtr   `yaml:"ptr"`
+			Deps []ElementPtr `yaml:"deps"`
+			Kind ElementKind  `yaml:"kind"`
+			Data *yaml.Node   `yaml:"data"`
+		}
+
+		elements := make([]element, 0, len(raw.Elements))
+
+		for _, elem := range raw.Elements {
+			node := new(yaml.Node)
+			if err := yaml.Unmarshal(elem.Data, node); err != nil {
+				return nil, err
+			}
+
+			// Unwrap the document node of the decoded element data
+			if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+				node = node.Content[0]
+			}
+
+			elements = append(elements, element{elem.Ptr, elem.Deps, elem.Kind, node})
+		}
+
+		return yaml.Marshal(struct {
+			Syntax   string         `yaml:"syntax"`
+			Engine   ManifestEngine `yaml:"engine"`
+			Elements []element      `yaml:"elements"`
+		}{raw.Syntax, raw.Engine, elements})
+
+	case POLO:
+		type element struct {
+			Ptr  ElementPtr
+			Deps []ElementPtr
+			Kind ElementKind
+			Data polo.Any
+		}
+
+		elements := make([]element, 0, len(raw.Elements))
+		for _, elem := range raw.Elements {
+			elements = append(elements, element{elem.Ptr, elem.Deps, elem.Kind, elem.Data})
+		}
+
+		return polo.Polorize(struct {
+			Syntax   string
+			Engine   ManifestEngine
+			Elements []element
+		}{raw.Syntax, raw.Engine, elements})
+
+	default:
+		return nil, errors.New("unsupported manifest encoding")
+	}
+}
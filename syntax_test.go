@@ -0,0 +1,220 @@
+package engineio
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// withSyntax appends a syntax version to the registry for the duration of the test
+func withSyntax(t *testing.T, syntax SyntaxVersion) {
+	t.Helper()
+
+	syntaxes.mutex.RLock()
+	previous := syntaxes.versions
+	syntaxes.mutex.RUnlock()
+
+	require.NoError(t, RegisterSyntax(syntax))
+
+	t.Cleanup(func() {
+		syntaxes.mutex.Lock()
+		defer syntaxes.mutex.Unlock()
+
+		syntaxes.versions = previous
+	})
+}
+
+func TestSyntaxes(t *testing.T) {
+	require.Equal(t, "0.1.0", CurrentSyntax())
+	require.Equal(t, []string{"0.1.0"}, SupportedSyntaxes())
+
+	withSyntax(t, SyntaxVersion{Version: "0.2.0"})
+	require.Equal(t, "0.2.0", CurrentSyntax())
+	require.Equal(t, []string{"0.1.0", "0.2.0"}, SupportedSyntaxes())
+}
+
+func TestRegisterSyntax(t *testing.T) {
+	withSyntax(t, SyntaxVersion{Version: "0.2.0"})
+
+	require.EqualError(t, RegisterSyntax(SyntaxVersion{Version: "latest"}),
+		"invalid manifest syntax: invalid semver 'latest': expected major.minor.patch")
+	require.EqualError(t, RegisterSyntax(SyntaxVersion{Version: "0.2.0"}),
+		"manifest syntax '0.2.0' is not newer than the current syntax '0.2.0'")
+	require.EqualError(t, RegisterSyntax(SyntaxVersion{Version: "0.1.5"}),
+		"manifest syntax '0.1.5' is not newer than the current syntax '0.2.0'")
+
+	require.Equal(t, []string{"0.1.0", "0.2.0"}, SupportedSyntaxes())
+}
+
+func TestRegisterSyntax_Codec(t *testing.T) {
+	// The hypothetical 0.2.0 syntax lists the elements of JSON manifests under "objects"
+	withSyntax(t, SyntaxVersion{
+		Version: "0.2.0",
+		Decode: func(data []byte, encoding Encoding) (*RawManifest, error) {
+			if encoding == JSON {
+				data = bytes.Replace(data, []byte(`"objects":`), []byte(`"elements":`), 1)
+			}
+
+			return decodeRawManifest(data, encoding)
+		},
+		Encode: func(raw *RawManifest, encoding Encoding) ([]byte, error) {
+			data, err := encodeRawManifest(raw, encoding)
+			if err != nil || encoding != JSON {
+				return data, err
+			}
+
+			return bytes.Replace(data, []byte(`"elements":`), []byte(`"objects":`), 1), nil
+		},
+	})
+
+	manifest := newMockManifest("mock")
+
+	legacy, err := manifest.Encode(JSON)
+	require.NoError(t, err)
+
+	current, err := manifest.EncodeSyntax(JSON, "0.2.0")
+	require.NoError(t, err)
+	require.Equal(t, bytes.Replace(legacy, []byte(`"0.1.0"`), []byte(`"0.2.0"`), 1),
+		bytes.Replace(current, []byte(`"objects":`), []byte(`"elements":`), 1))
+
+	raw, err := NewRawManifest(current, JSON)
+	require.NoError(t, err)
+	require.Equal(t, JSON, raw.Encoding())
+	require.Len(t, raw.Elements, len(manifest.Elements))
+
+	encoded, err := raw.Encode(JSON)
+	require.NoError(t, err)
+	require.Equal(t, current, encoded)
+}
+
+func TestManifest_EncodeSyntax(t *testing.T) {
+	manifest := newMockManifest("mock")
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		expected, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		// encoding to the current syntax is identical to Encode
+		encoded, err := manifest.EncodeSyntax(encoding, CurrentSyntax())
+		require.NoError(t, err)
+		require.Equal(t, expected, encoded, "encoding: %v", encoding)
+	}
+
+	_, err := manifest.EncodeSyntax(JSON, "9.9.9")
+	require.EqualError(t, err, "unsupported manifest syntax '9.9.9'")
+
+	_, err = manifest.EncodeSyntax(Encoding(10), CurrentSyntax())
+	require.EqualError(t, err, "unsupported manifest encoding")
+}
+
+func TestNewManifest_Migration(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "1.0.0"), nil)
+
+	original := newMockManifest("mock")
+
+	encoded := make(map[Encoding][]byte)
+
+	for _, encoding := range []Encoding{POLO, JSON, YAML} {
+		data, err := original.Encode(encoding)
+		require.NoError(t, err)
+
+		encoded[encoding] = data
+	}
+
+	// In the hypothetical 0.2.0 syntax, every manifest declares a "v2" engine flag
+	withSyntax(t, SyntaxVersion{
+		Version: "0.2.0",
+		Upgrade: func(raw *RawManifest) (*RawManifest, error) {
+			raw.Engine.Flags = append(raw.Engine.Flags, "v2")
+
+			return raw, nil
+		},
+		Downgrade: func(raw *RawManifest) (*RawManifest, error) {
+			if len(raw.Engine.Flags) == 0 || raw.Engine.Flags[len(raw.Engine.Flags)-1] != "v2" {
+				return nil, errors.New("missing v2 flag")
+			}
+
+			raw.Engine.Flags = raw.Engine.Flags[:len(raw.Engine.Flags)-1]
+
+			return raw, nil
+		},
+	})
+
+	for encoding, data := range encoded {
+		manifest, err := registry.NewManifest(data, encoding)
+		require.NoError(t, err)
+		require.Equal(t, "0.2.0", manifest.Syntax)
+		require.Equal(t, []string{"v2"}, manifest.Engine.Flags)
+		require.Equal(t, original.Elements, manifest.Elements)
+
+		// the migrated manifest can be encoded back into the original syntax
+		downgraded, err := manifest.EncodeSyntax(encoding, "0.1.0")
+		require.NoError(t, err)
+		require.Equal(t, data, downgraded, "encoding: %v", encoding)
+
+		// current syntax manifests are decoded without migration
+		current, err := manifest.Encode(encoding)
+		require.NoError(t, err)
+
+		decoded, err := registry.NewManifest(current, encoding)
+		require.NoError(t, err)
+		require.Equal(t, manifest, decoded)
+	}
+
+	// registered encodings are migrated without a runtime in the default Registry
+	encoding := withEncoding(t, base64Format)
+
+	migrated, err := registry.NewManifest(encoded[JSON], JSON)
+	require.NoError(t, err)
+
+	downgraded, err := migrated.EncodeSyntax(encoding, "0.1.0")
+	require.NoError(t, err)
+
+	expected, err := original.Encode(encoding)
+	require.NoError(t, err)
+	require.Equal(t, expected, downgraded)
+
+	invalid := newMockManifest("mock")
+	invalid.Syntax = "0.2.0"
+
+	_, err = invalid.EncodeSyntax(JSON, "0.1.0")
+	require.EqualError(t, err, "failed to downgrade manifest syntax from '0.2.0': missing v2 flag")
+
+	invalid.Syntax = "0.0.1"
+
+	data, err := invalid.Encode(JSON)
+	require.NoError(t, err)
+
+	_, err = registry.NewManifest(data, JSON)
+	require.EqualError(t, err, "unsupported manifest syntax '0.0.1'")
+}
+
+func TestManifest_EncodeSyntax_Isolation(t *testing.T) {
+	// The hypothetical 0.2.0 syntax renames the flags and relocates the dependencies in place
+	withSyntax(t, SyntaxVersion{Version: "0.2.0", Downgrade: func(raw *RawManifest) (*RawManifest, error) {
+		for idx := range raw.Engine.Flags {
+			raw.Engine.Flags[idx] = "legacy-" + raw.Engine.Flags[idx]
+		}
+
+		for _, element := range raw.Elements {
+			for idx := range element.Deps {
+				element.Deps[idx] += 10
+			}
+		}
+
+		return raw, nil
+	}})
+
+	manifest := newMockManifest("mock")
+	manifest.Syntax = "0.2.0"
+	manifest.Engine.Flags = []string{"debug"}
+
+	_, err := manifest.EncodeSyntax(JSON, "0.1.0")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"debug"}, manifest.Engine.Flags)
+	require.Equal(t, []ElementPtr{0}, manifest.Elements[1].Deps)
+}
@@ -0,0 +1,194 @@
+package engineio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ManifestIssue describes a problem with an element of a Manifest
+type ManifestIssue struct {
+	// Ptr is the pointer of the offending element
+	Ptr ElementPtr
+	// Message describes the problem with the element
+	Message string
+}
+
+// String implements the Stringer interface for ManifestIssue
+func (issue ManifestIssue) String() string {
+	return fmt.Sprintf("%v [ptr: %v]", issue.Message, issue.Ptr)
+}
+
+// ManifestValidationError is the error returned when a Manifest fails validation.
+// It contains every issue that was found with the elements of the Manifest.
+type ManifestValidationError struct {
+	Issues []ManifestIssue
+}
+
+// Error implements the error interface for ManifestValidationError
+func (err *ManifestValidationError) Error() string {
+	issues := make([]string, 0, len(err.Issues))
+	for _, issue := range err.Issues {
+		issues = append(issues, issue.String())
+	}
+
+	return fmt.Sprintf("invalid manifest: %v", strings.Join(issues, "; "))
+}
+
+// ManifestValidator is an optional interface that can be implemented by an EngineRuntime to validate
+// the engine specific rules of a Manifest (such as the allowed dependencies between element kinds).
+// It is only invoked for Manifests that are structurally valid (see Manifest.Validate).
+type ManifestValidator interface {
+	ValidateManifest(*Manifest) []ManifestIssue
+}
+
+// Validate verifies the structure of the elements of the Manifest. It checks that
+// the element pointers are unique, that every dependency refers to an existing element
+// and that the dependencies between the elements do not form a cycle.
+//
+// Returns a *ManifestValidationError with every issue that was found, or nil if there are none.
+func (manifest Manifest) Validate() error {
+	if issues := manifest.structuralIssues(); len(issues) > 0 {
+		return &ManifestValidationError{Issues: issues}
+	}
+
+	return nil
+}
+
+// ValidateManifest verifies the structure of the elements of the Manifest (see Manifest.Validate)
+// and then the engine specific rules of the EngineRuntime in the Registry that the Manifest resolves
+// to, if it implements the ManifestValidator interface. Returns an error if the runtime cannot be
+// resolved or a *ManifestValidationError with every issue that was found.
+func (registry *Registry) ValidateManifest(manifest *Manifest) error {
+	runtime, err := manifest.Header().validate(registry)
+	if err != nil {
+		return err
+	}
+
+	if err = manifest.Validate(); err != nil {
+		return err
+	}
+
+	validator, ok := runtime.(ManifestValidator)
+	if !ok {
+		return nil
+	}
+
+	if issues := validator.ValidateManifest(manifest); len(issues) > 0 {
+		return &ManifestValidationError{Issues: issues}
+	}
+
+	return nil
+}
+
+// ValidateManifest verifies the structure of the Manifest and the engine specific rules of the
+// EngineRuntime in the default Registry that it resolves to. See Registry.ValidateManifest for details.
+func ValidateManifest(manifest *Manifest) error {
+	return defaultRegistry.ValidateManifest(manifest)
+}
+
+// structuralIssues returns the issues with the uniqueness, dependencies
+// and acyclicity of the elements in the Manifest, in the order of the elements
+func (manifest Manifest) structuralIssues() []ManifestIssue {
+	issues := make([]ManifestIssue, 0)
+
+	// Collect the dependencies of each unique element pointer
+	graph := make(map[ElementPtr][]ElementPtr, len(manifest.Elements))
+	order := make([]ElementPtr, 0, len(manifest.Elements))
+
+	for _, element := range manifest.Elements {
+		if _, exists := graph[element.Ptr]; exists {
+			issues = append(issues, ManifestIssue{element.Ptr, "duplicate element pointer"})
+
+			continue
+		}
+
+		graph[element.Ptr] = element.Deps
+		order = append(order, element.Ptr)
+	}
+
+	for _, ptr := range order {
+		seen := make(map[ElementPtr]struct{}, len(graph[ptr]))
+
+		for _, dep := range graph[ptr] {
+			// Duplicate dependencies are tolerated (Canonical removes them) and are only checked once
+			if _, duplicate := seen[dep]; duplicate {
+				continue
+			}
+
+			seen[dep] = struct{}{}
+
+			if _, exists := graph[dep]; !exists {
+				issues = append(issues, ManifestIssue{ptr, fmt.Sprintf("dependency on missing element %v", dep)})
+			}
+		}
+	}
+
+	return append(issues, dependencyCycles(graph, order)...)
+}
+
+// dependencyCycles returns an issue for each cycle in the dependency graph of the elements that is
+// found with a depth-first search from each element in the given order. Each issue is reported for
+// the element at which its cycle was entered and lists the elements that form the cycle.
+func dependencyCycles(graph map[ElementPtr][]ElementPtr, order []ElementPtr) []ManifestIssue {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	issues := make([]ManifestIssue, 0)
+	states := make(map[ElementPtr]int, len(graph))
+	path := make([]ElementPtr, 0)
+
+	// Back edges that have been reported, to avoid reporting a cycle again for duplicate dependencies
+	reported := make(map[[2]ElementPtr]struct{})
+
+	var visit func(ElementPtr)
+	visit = func(ptr ElementPtr) {
+		states[ptr] = visiting
+		path = append(path, ptr)
+
+		for _, dep := range graph[ptr] {
+			if _, exists := graph[dep]; !exists {
+				continue
+			}
+
+			switch states[dep] {
+			case unvisited:
+				visit(dep)
+
+			case visiting:
+				if _, ok := reported[[2]ElementPtr{ptr, dep}]; ok {
+					continue
+				}
+
+				reported[[2]ElementPtr{ptr, dep}] = struct{}{}
+
+				// Extract the cycle from the current path, starting at the dependency
+				start := len(path) - 1
+				for path[start] != dep {
+					start--
+				}
+
+				cycle := make([]string, 0, len(path)-start+1)
+				for _, element := range path[start:] {
+					cycle = append(cycle, fmt.Sprint(element))
+				}
+
+				cycle = append(cycle, fmt.Sprint(dep))
+				issues = append(issues, ManifestIssue{dep, "dependency cycle: " + strings.Join(cycle, " -> ")})
+			}
+		}
+
+		path = path[:len(path)-1]
+		states[ptr] = visited
+	}
+
+	for _, ptr := range order {
+		if states[ptr] == unvisited {
+			visit(ptr)
+		}
+	}
+
+	return issues
+}
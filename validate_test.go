@@ -0,0 +1,127 @@
+package engineio
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest_Validate(t *testing.T) {
+	element := func(ptr ElementPtr, deps ...ElementPtr) ManifestElement {
+		return ManifestElement{Ptr: ptr, Deps: deps, Kind: "mock", Data: &mockElement{}}
+	}
+
+	tests := []struct {
+		name     string
+		elements []ManifestElement
+		issues   []ManifestIssue
+	}{
+		{"empty", []ManifestElement{}, nil},
+		{"valid", []ManifestElement{element(0), element(1, 0), element(2, 0, 1)}, nil},
+		{"forward dependency", []ManifestElement{element(1, 0), element(0)}, nil},
+		{
+			"duplicate pointers",
+			[]ManifestElement{element(0), element(1), element(0), element(1)},
+			[]ManifestIssue{{0, "duplicate element pointer"}, {1, "duplicate element pointer"}},
+		},
+		{
+			"missing dependencies",
+			[]ManifestElement{element(0, 5), element(1, 0, 6, 7)},
+			[]ManifestIssue{
+				{0, "dependency on missing element 5"},
+				{1, "dependency on missing element 6"},
+				{1, "dependency on missing element 7"},
+			},
+		},
+		{
+			"duplicate dependencies",
+			[]ManifestElement{element(0), element(1, 0, 0), element(2, 5, 5)},
+			[]ManifestIssue{{2, "dependency on missing element 5"}},
+		},
+		{
+			"self dependency",
+			[]ManifestElement{element(0, 0)},
+			[]ManifestIssue{{0, "dependency cycle: 0 -> 0"}},
+		},
+		{
+			"cycles",
+			[]ManifestElement{element(0, 2), element(1, 0), element(2, 1), element(3, 4), element(4, 3, 3)},
+			[]ManifestIssue{
+				{0, "dependency cycle: 0 -> 2 -> 1 -> 0"},
+				{3, "dependency cycle: 3 -> 4 -> 3"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest := newMockManifest("mock")
+			manifest.Elements = test.elements
+
+			err := manifest.Validate()
+			if test.issues == nil {
+				require.NoError(t, err)
+
+				return
+			}
+
+			validation := new(ManifestValidationError)
+			require.True(t, errors.As(err, &validation))
+			require.Equal(t, test.issues, validation.Issues)
+		})
+	}
+}
+
+func TestManifestValidationError(t *testing.T) {
+	err := &ManifestValidationError{Issues: []ManifestIssue{
+		{0, "duplicate element pointer"},
+		{1, "dependency on missing element 5"},
+	}}
+
+	require.EqualError(t, err,
+		"invalid manifest: duplicate element pointer [ptr: 0]; dependency on missing element 5 [ptr: 1]")
+}
+
+// mockValidatingRuntime is a mock runtime that requires every element to have a non-zero value
+type mockValidatingRuntime struct {
+	*mockEngineRuntime
+}
+
+func (runtime mockValidatingRuntime) ValidateManifest(manifest *Manifest) []ManifestIssue {
+	issues := make([]ManifestIssue, 0)
+
+	for _, element := range manifest.Elements {
+		if element.Data.(*mockElement).Value == 0 { //nolint:forcetypeassert
+			issues = append(issues, ManifestIssue{element.Ptr, "element value must be non-zero"})
+		}
+	}
+
+	return issues
+}
+
+func TestRegistry_ValidateManifest(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(mockValidatingRuntime{newMockRuntime("VALIDATING", "1.0.0")}, nil)
+	registry.Register(newMockRuntime("PLAIN", "1.0.0"), nil)
+
+	manifest := newMockManifest("validating")
+	require.NoError(t, registry.ValidateManifest(&manifest))
+
+	// engine specific rules are checked with the runtime
+	manifest.Elements[1].Data = &mockElement{Name: "zero"}
+	require.EqualError(t, registry.ValidateManifest(&manifest),
+		"invalid manifest: element value must be non-zero [ptr: 1]")
+
+	// engine specific rules are not checked for structurally invalid manifests
+	manifest.Elements[1].Deps = []ElementPtr{1}
+	require.EqualError(t, registry.ValidateManifest(&manifest), "invalid manifest: dependency cycle: 1 -> 1 [ptr: 1]")
+
+	// runtimes without a validator only check the structure of the manifest
+	manifest.Engine.Kind = "plain"
+	manifest.Elements[1].Deps = []ElementPtr{0}
+	require.NoError(t, registry.ValidateManifest(&manifest))
+
+	manifest.Engine.Kind = "missing"
+	require.ErrorIs(t, registry.ValidateManifest(&manifest), ErrUnknownEngine)
+}
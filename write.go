@@ -0,0 +1,189 @@
+package engineio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// WriteManifestFile encodes the Manifest and writes it to a file at the specified filepath.
+//
+// The encoding format of the file is determined from the file extension ('.polo', '.json', '.yaml' or '.yml', or
+// the extensions of a registered EncodingFormat) and the file is compressed if the extension is followed by
+// '.gz' (gzip) or '.zst'/'.zstd' (zstd).
+// The canonical form of the Manifest is written (see Manifest.Canonical), with JSON and YAML manifests in a
+// canonical format (indented with two spaces and terminated with a newline), so that Manifests that describe
+// the same logic always produce the same file and diff cleanly.
+//
+// The file is written atomically by writing to a temporary file in the same directory and renaming it to
+// the filepath. The permissions of an existing file at the filepath are preserved.
+func WriteManifestFile(path string, manifest *Manifest) error {
+	path, _ = filepath.Abs(path)
+
+	encoding, ok := fileEncoding(path)
+	if !ok {
+		return errors.Errorf("manifest file has unsupported extension: '%v'", filepath.Ext(path))
+	}
+
+	canonical := manifest.Canonical()
+
+	data, err := formatManifest(&canonical, encoding)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode manifest")
+	}
+
+	if data, err = compressManifestData(data, strings.ToLower(filepath.Ext(path))); err != nil {
+		return errors.Wrap(err, "failed to compress manifest data")
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// formatManifest encodes the Manifest for the specified encoding in its canonical file format.
+// POLO manifests are identical to Manifest.Encode, while JSON and YAML manifests are indented
+// with two spaces and terminated with a newline.
+func formatManifest(manifest *Manifest, encoding Encoding) ([]byte, error) {
+	switch encoding {
+	case JSON:
+		encoded, err := manifest.Encode(JSON)
+		if err != nil {
+			return nil, err
+		}
+
+		buffer := new(bytes.Buffer)
+		if err = json.Indent(buffer, encoded, "", "  "); err != nil {
+			return nil, err
+		}
+
+		buffer.WriteByte('\n')
+
+		return buffer.Bytes(), nil
+
+	case YAML:
+		buffer := new(bytes.Buffer)
+
+		encoder := yaml.NewEncoder(buffer)
+		encoder.SetIndent(2)
+
+		if err := encoder.Encode(manifest); err != nil {
+			return nil, err
+		}
+
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+
+		return buffer.Bytes(), nil
+
+	default:
+		return manifest.Encode(encoding)
+	}
+}
+
+// compressManifestData compresses the data with the compression for the given file extension.
+// The data is returned as is if the extension is not a compression extension.
+func compressManifestData(data []byte, extension string) ([]byte, error) {
+	switch extension {
+	case ".gz":
+		buffer := new(bytes.Buffer)
+
+		// The gzip header is written without a modification
+		// time, so that the compressed output is deterministic
+		compressor := gzip.NewWriter(buffer)
+		if _, err := compressor.Write(data); err != nil {
+			return nil, err
+		}
+
+		if err := compressor.Close(); err != nil {
+			return nil, err
+		}
+
+		return buffer.Bytes(), nil
+
+	case ".zst", ".zstd":
+		compressor, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		defer compressor.Close()
+
+		return compressor.EncodeAll(data, nil), nil
+
+	default:
+		return data, nil
+	}
+}
+
+// writeFileAtomic writes the data to a temporary file in the directory of the
+// filepath and renames it to the filepath once it has been completely written.
+// The temporary file is removed if the write fails at any point.
+func writeFileAtomic(path string, data []byte) (err error) {
+	mode := os.FileMode(0o644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create manifest file")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	if _, err = file.Write(data); err != nil {
+		return errors.Wrap(err, "failed to write manifest file")
+	}
+
+	if err = file.Sync(); err != nil {
+		return errors.Wrap(err, "failed to write manifest file")
+	}
+
+	if err = file.Chmod(mode); err != nil {
+		return errors.Wrap(err, "failed to write manifest file")
+	}
+
+	if err = file.Close(); err != nil {
+		return errors.Wrap(err, "failed to write manifest file")
+	}
+
+	if err = os.Rename(file.Name(), path); err != nil {
+		return errors.Wrap(err, "failed to write manifest file")
+	}
+
+	// The directory is synced to make the rename durable. The file has been written once it is renamed,
+	// so a failure to sync the directory is not reported (the temporary file must not be removed either).
+	_ = syncDir(filepath.Dir(path))
+
+	return nil
+}
+
+// syncDir flushes the entries of a directory to storage. Returns nil if
+// the directory cannot be opened or synced on the platform of the process.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+
+	defer dir.Close()
+
+	if err = dir.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTSUP) {
+		return err
+	}
+
+	return nil
+}
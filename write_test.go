@@ -0,0 +1,180 @@
+package engineio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteManifestFile(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(newMockRuntime("MOCK", "0.1.0"), nil)
+
+	manifest := newMockManifest("MOCK")
+	directory := t.TempDir()
+
+	for _, name := range []string{
+		"manifest.polo", "manifest.json", "manifest.yaml", "manifest.yml",
+		"manifest.yaml.gz", "manifest.json.zst", "manifest.polo.zstd",
+	} {
+		path := filepath.Join(directory, name)
+		require.NoError(t, WriteManifestFile(path, &manifest))
+
+		written, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		decoded, err := registry.ReadManifestFile(path)
+		require.NoError(t, err, "file: %v", name)
+		require.Equal(t, manifest, *decoded)
+
+		// rewriting the manifest produces an identical file
+		require.NoError(t, WriteManifestFile(path, decoded))
+
+		rewritten, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, written, rewritten, "file: %v", name)
+	}
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(directory)
+	require.NoError(t, err)
+	require.Len(t, entries, 7)
+
+	for _, name := range []string{"manifest", "manifest.toml", "manifest.gz"} {
+		err = WriteManifestFile(filepath.Join(directory, name), &manifest)
+		require.ErrorContains(t, err, "manifest file has unsupported extension")
+	}
+
+	err = WriteManifestFile(filepath.Join(directory, "missing", "manifest.json"), &manifest)
+	require.ErrorContains(t, err, "failed to create manifest file")
+}
+
+func TestWriteManifestFile_Format(t *testing.T) {
+	manifest := newMockManifest("MOCK")
+	directory := t.TempDir()
+
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{
+			"manifest.json",
+			`{
+  "syntax": "0.1.0",
+  "engine": {
+    "kind": "MOCK",
+    "flags": []
+  },
+  "elements": [
+    {
+      "ptr": 0,
+      "deps": [],
+      "kind": "mock",
+      "data": {
+        "name": "foo",
+        "value": 5
+      }
+    },
+    {
+      "ptr": 1,
+      "deps": [
+        0
+      ],
+      "kind": "mock",
+      "data": {
+        "name": "bar",
+        "value": 10
+      }
+    }
+  ]
+}
+`,
+		},
+		{
+			"manifest.yaml",
+			`syntax: 0.1.0
+engine:
+  kind: MOCK
+  flags: []
+elements:
+  - ptr: 0
+    deps: []
+    kind: mock
+    data:
+      name: foo
+      value: 5
+  - ptr: 1
+    deps:
+      - 0
+    kind: mock
+    data:
+      name: bar
+      value: 10
+`,
+		},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(directory, test.name)
+		require.NoError(t, WriteManifestFile(path, &manifest))
+
+		written, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, test.output, string(written))
+	}
+}
+
+func TestWriteManifestFile_Canonical(t *testing.T) {
+	manifest := newMockManifest("MOCK")
+	directory := t.TempDir()
+
+	// the manifest is authored with its elements out of order and unsorted flags
+	authored := newMockManifest("mock")
+	authored.Engine.Flags = []string{"fuel=10", "debug", "debug"}
+	authored.Elements[0], authored.Elements[1] = authored.Elements[1], authored.Elements[0]
+
+	manifest.Engine.Flags = []string{"debug", "fuel=10"}
+
+	for _, name := range []string{"manifest.polo", "manifest.json", "manifest.yaml"} {
+		expected := filepath.Join(directory, "expected."+name)
+		require.NoError(t, WriteManifestFile(expected, &manifest))
+
+		path := filepath.Join(directory, name)
+		require.NoError(t, WriteManifestFile(path, &authored))
+
+		want, err := os.ReadFile(expected)
+		require.NoError(t, err)
+
+		written, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, want, written, "file: %v", name)
+	}
+}
+
+func TestWriteManifestFile_Permissions(t *testing.T) {
+	manifest := newMockManifest("MOCK")
+	path := filepath.Join(t.TempDir(), "manifest.json")
+
+	require.NoError(t, WriteManifestFile(path, &manifest))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	// the permissions of an existing file are preserved
+	require.NoError(t, os.Chmod(path, 0o600))
+	require.NoError(t, WriteManifestFile(path, &manifest))
+
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestSyncDir(t *testing.T) {
+	require.NoError(t, syncDir(t.TempDir()))
+
+	// Directories that cannot be opened are not synced
+	require.NoError(t, syncDir(filepath.Join(t.TempDir(), "missing")))
+}